@@ -0,0 +1,184 @@
+// Package access decides what the users of the API may see and change.
+//
+// Registrars manage everything. Department admins manage the students,
+// courses and instructors of their department and the departments below it.
+// Instructors see only the students enrolled in their own courses, and
+// students only themselves. Courses, departments and instructors are the
+// catalogue of the university and visible to every user.
+package access
+
+import (
+	"context"
+	"errors"
+	"exercise1/db"
+)
+
+type Role string
+
+const (
+	Registrar       Role = "registrar"
+	DepartmentAdmin Role = "department_admin"
+	Instructor      Role = "instructor"
+	Student         Role = "student"
+)
+
+// Roles lists every role.
+var Roles = []Role{Registrar, DepartmentAdmin, Instructor, Student}
+
+var ErrForbidden = errors.New("not allowed")
+
+// Principal is the user a request is made for. StudentId, InstructorId and
+// DepartmentId are the records the user is linked to; which of them matter
+// depends on the role.
+type Principal struct {
+	UserId       uint
+	Role         Role
+	StudentId    *uint
+	InstructorId *uint
+	DepartmentId *uint
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context whose requests are made for p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of ctx.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Relations answers what the policies need to know from the database.
+type Relations interface {
+	// StudentsOf returns the IDs of the students enrolled in the courses the
+	// instructor teaches.
+	StudentsOf(instructorId uint) map[uint]bool
+	// Subtree returns the IDs of the department and all departments below
+	// it.
+	Subtree(departmentId uint) map[uint]bool
+}
+
+// Policy holds the decisions for one principal. It asks Relations at most
+// once per question, so it should live no longer than a request.
+type Policy struct {
+	principal   Principal
+	relations   Relations
+	students    map[uint]bool
+	departments map[uint]bool
+}
+
+func NewPolicy(p Principal, relations Relations) *Policy {
+	return &Policy{principal: p, relations: relations}
+}
+
+func (p *Policy) Principal() Principal {
+	return p.principal
+}
+
+// CanViewStudent reports whether the principal may see student.
+func (p *Policy) CanViewStudent(student db.Student) bool {
+	switch p.principal.Role {
+	case Registrar:
+		return true
+	case DepartmentAdmin:
+		return p.inDepartment(student.DepartmentId)
+	case Instructor:
+		if p.principal.InstructorId == nil {
+			return false
+		}
+		if p.students == nil {
+			p.students = p.relations.StudentsOf(*p.principal.InstructorId)
+		}
+		return p.students[student.Id]
+	case Student:
+		return p.isStudent(student.Id)
+	}
+	return false
+}
+
+// VisibleStudents returns the students the principal may see.
+func (p *Policy) VisibleStudents(students []db.Student) []db.Student {
+	visible := []db.Student{}
+	for _, student := range students {
+		if p.CanViewStudent(student) {
+			visible = append(visible, student)
+		}
+	}
+	return visible
+}
+
+// CanManageStudent reports whether the principal may create, change or
+// delete student.
+func (p *Policy) CanManageStudent(student db.Student) bool {
+	return p.principal.Role == Registrar || p.principal.Role == DepartmentAdmin && p.inDepartment(student.DepartmentId)
+}
+
+// CanExportStudent reports whether the principal may export everything
+// stored about student: the student themselves or whoever manages them.
+func (p *Policy) CanExportStudent(student db.Student) bool {
+	return p.CanManageStudent(student) || p.principal.Role == Student && p.isStudent(student.Id)
+}
+
+func (p *Policy) CanManageCourse(course db.Course) bool {
+	return p.principal.Role == Registrar || p.principal.Role == DepartmentAdmin && p.inDepartment(&course.DepartmentId)
+}
+
+func (p *Policy) CanManageInstructor(instructor db.Instructor) bool {
+	return p.principal.Role == Registrar || p.principal.Role == DepartmentAdmin && p.inDepartment(&instructor.DepartmentId)
+}
+
+// CanManageDepartment reports whether the principal may change department.
+// Department admins may change their own department and those below it.
+func (p *Policy) CanManageDepartment(department db.Department) bool {
+	return p.principal.Role == Registrar || p.principal.Role == DepartmentAdmin && p.inDepartment(&department.Id)
+}
+
+// CanCreateOrDeleteDepartment reports whether the principal may create or
+// delete department. Department admins may do so only below their own
+// department, which they cannot delete.
+func (p *Policy) CanCreateOrDeleteDepartment(department db.Department) bool {
+	if p.principal.Role == Registrar {
+		return true
+	}
+	if p.principal.Role != DepartmentAdmin || p.principal.DepartmentId == nil || department.Id == *p.principal.DepartmentId {
+		return false
+	}
+	return p.inDepartment(department.ParentId)
+}
+
+// CanEnroll reports whether the principal may enroll the student in course
+// or drop them from it. Students may do so for themselves.
+func (p *Policy) CanEnroll(studentId uint, course db.Course) bool {
+	switch p.principal.Role {
+	case Registrar:
+		return true
+	case DepartmentAdmin:
+		return p.inDepartment(&course.DepartmentId)
+	case Student:
+		return p.isStudent(studentId)
+	}
+	return false
+}
+
+// CanViewReports reports whether the principal may see the reports, which
+// aggregate over the whole university.
+func (p *Policy) CanViewReports() bool {
+	return p.principal.Role == Registrar || p.principal.Role == DepartmentAdmin
+}
+
+func (p *Policy) isStudent(studentId uint) bool {
+	return p.principal.StudentId != nil && *p.principal.StudentId == studentId
+}
+
+func (p *Policy) inDepartment(departmentId *uint) bool {
+	if departmentId == nil || p.principal.DepartmentId == nil {
+		return false
+	}
+	if p.departments == nil {
+		p.departments = p.relations.Subtree(*p.principal.DepartmentId)
+	}
+	return p.departments[*departmentId]
+}
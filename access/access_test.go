@@ -0,0 +1,217 @@
+package access
+
+import (
+	"context"
+	"exercise1/db"
+	"testing"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// fakeRelations has faculty 1 with department 2 below it and department 3
+// apart, and instructor 10 teaching students 100 and 101.
+type fakeRelations struct {
+	calls int
+}
+
+func (r *fakeRelations) StudentsOf(instructorId uint) map[uint]bool {
+	r.calls++
+	if instructorId == 10 {
+		return map[uint]bool{100: true, 101: true}
+	}
+	return map[uint]bool{}
+}
+
+func (r *fakeRelations) Subtree(departmentId uint) map[uint]bool {
+	r.calls++
+	switch departmentId {
+	case 1:
+		return map[uint]bool{1: true, 2: true}
+	case 2:
+		return map[uint]bool{2: true}
+	case 3:
+		return map[uint]bool{3: true}
+	}
+	return map[uint]bool{}
+}
+
+var (
+	registrar   = Principal{UserId: 1, Role: Registrar}
+	admin       = Principal{UserId: 2, Role: DepartmentAdmin, DepartmentId: uintPtr(1)}
+	instructor  = Principal{UserId: 3, Role: Instructor, InstructorId: uintPtr(10)}
+	student     = Principal{UserId: 4, Role: Student, StudentId: uintPtr(100)}
+	unknownRole = Principal{UserId: 5, Role: "dean"}
+
+	ownStudent      = db.Student{Id: 100, DepartmentId: uintPtr(2)}
+	taughtStudent   = db.Student{Id: 101, DepartmentId: uintPtr(3)}
+	otherStudent    = db.Student{Id: 102, DepartmentId: uintPtr(3)}
+	homelessStudent = db.Student{Id: 103}
+
+	facultyCourse = db.Course{Id: 20, DepartmentId: 2}
+	otherCourse   = db.Course{Id: 21, DepartmentId: 3}
+)
+
+func TestCanViewStudent(t *testing.T) {
+	tests := []struct {
+		principal Principal
+		student   db.Student
+		allowed   bool
+	}{
+		{registrar, otherStudent, true},
+		{registrar, homelessStudent, true},
+		{admin, ownStudent, true},
+		{admin, taughtStudent, false},
+		{admin, homelessStudent, false},
+		{instructor, ownStudent, true},
+		{instructor, taughtStudent, true},
+		{instructor, otherStudent, false},
+		{student, ownStudent, true},
+		{student, taughtStudent, false},
+		{unknownRole, ownStudent, false},
+		{Principal{Role: Instructor}, ownStudent, false},
+		{Principal{Role: Student}, ownStudent, false},
+	}
+	for _, test := range tests {
+		policy := NewPolicy(test.principal, &fakeRelations{})
+		if actual := policy.CanViewStudent(test.student); actual != test.allowed {
+			t.Errorf("%s may view student %d: expected %v, got %v", test.principal.Role, test.student.Id, test.allowed, actual)
+		}
+	}
+}
+
+func TestVisibleStudents(t *testing.T) {
+	students := []db.Student{ownStudent, taughtStudent, otherStudent, homelessStudent}
+	expected := map[Role]int{Registrar: 4, DepartmentAdmin: 1, Instructor: 2, Student: 1}
+	for _, principal := range []Principal{registrar, admin, instructor, student} {
+		relations := &fakeRelations{}
+		visible := NewPolicy(principal, relations).VisibleStudents(students)
+		if len(visible) != expected[principal.Role] {
+			t.Errorf("%s expected to see %d students, got %v", principal.Role, expected[principal.Role], visible)
+		}
+		if relations.calls > 1 {
+			t.Errorf("%s expected relations to be looked up once, got %d times", principal.Role, relations.calls)
+		}
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	tests := []struct {
+		principal  Principal
+		students   [2]bool // own department, other department
+		courses    [2]bool
+		instructor [2]bool
+	}{
+		{registrar, [2]bool{true, true}, [2]bool{true, true}, [2]bool{true, true}},
+		{admin, [2]bool{true, false}, [2]bool{true, false}, [2]bool{true, false}},
+		{instructor, [2]bool{false, false}, [2]bool{false, false}, [2]bool{false, false}},
+		{student, [2]bool{false, false}, [2]bool{false, false}, [2]bool{false, false}},
+	}
+	for _, test := range tests {
+		policy := NewPolicy(test.principal, &fakeRelations{})
+		if actual := [2]bool{policy.CanManageStudent(ownStudent), policy.CanManageStudent(otherStudent)}; actual != test.students {
+			t.Errorf("%s managing students: expected %v, got %v", test.principal.Role, test.students, actual)
+		}
+		if actual := [2]bool{policy.CanManageCourse(facultyCourse), policy.CanManageCourse(otherCourse)}; actual != test.courses {
+			t.Errorf("%s managing courses: expected %v, got %v", test.principal.Role, test.courses, actual)
+		}
+		instructors := [2]bool{policy.CanManageInstructor(db.Instructor{DepartmentId: 1}), policy.CanManageInstructor(db.Instructor{DepartmentId: 3})}
+		if instructors != test.instructor {
+			t.Errorf("%s managing instructors: expected %v, got %v", test.principal.Role, test.instructor, instructors)
+		}
+	}
+}
+
+func TestCanExportStudent(t *testing.T) {
+	tests := []struct {
+		principal Principal
+		student   db.Student
+		allowed   bool
+	}{
+		{registrar, otherStudent, true},
+		{admin, ownStudent, true},
+		{admin, otherStudent, false},
+		{instructor, taughtStudent, false},
+		{student, ownStudent, true},
+		{student, otherStudent, false},
+	}
+	for _, test := range tests {
+		policy := NewPolicy(test.principal, &fakeRelations{})
+		if actual := policy.CanExportStudent(test.student); actual != test.allowed {
+			t.Errorf("%s may export student %d: expected %v, got %v", test.principal.Role, test.student.Id, test.allowed, actual)
+		}
+	}
+}
+
+func TestDepartmentAdminsManageTheirSubtree(t *testing.T) {
+	policy := NewPolicy(admin, &fakeRelations{})
+
+	if !policy.CanManageDepartment(db.Department{Id: 1}) || !policy.CanManageDepartment(db.Department{Id: 2}) {
+		t.Errorf("expected admin to manage their department and the one below it")
+	}
+	if policy.CanManageDepartment(db.Department{Id: 3}) {
+		t.Errorf("expected admin not to manage another department")
+	}
+	if !policy.CanCreateOrDeleteDepartment(db.Department{Id: 2, ParentId: uintPtr(1)}) {
+		t.Errorf("expected admin to delete a department below their own")
+	}
+	if policy.CanCreateOrDeleteDepartment(db.Department{Id: 1}) {
+		t.Errorf("expected admin not to delete their own department")
+	}
+	if policy.CanCreateOrDeleteDepartment(db.Department{Name: "Physics", ParentId: uintPtr(3)}) {
+		t.Errorf("expected admin not to create a department below another one")
+	}
+	if policy.CanCreateOrDeleteDepartment(db.Department{Name: "Top level"}) {
+		t.Errorf("expected admin not to create a root department")
+	}
+}
+
+func TestCanEnroll(t *testing.T) {
+	tests := []struct {
+		principal Principal
+		studentId uint
+		course    db.Course
+		allowed   bool
+	}{
+		{registrar, 102, otherCourse, true},
+		{admin, 102, facultyCourse, true},
+		{admin, 100, otherCourse, false},
+		{instructor, 100, facultyCourse, false},
+		{student, 100, otherCourse, true},
+		{student, 101, otherCourse, false},
+	}
+	for _, test := range tests {
+		policy := NewPolicy(test.principal, &fakeRelations{})
+		if actual := policy.CanEnroll(test.studentId, test.course); actual != test.allowed {
+			t.Errorf("%s may enroll student %d in course %d: expected %v, got %v", test.principal.Role, test.studentId, test.course.Id, test.allowed, actual)
+		}
+	}
+}
+
+func TestCanViewReports(t *testing.T) {
+	expected := map[Role]bool{Registrar: true, DepartmentAdmin: true, Instructor: false, Student: false}
+	for _, principal := range []Principal{registrar, admin, instructor, student} {
+		if actual := NewPolicy(principal, &fakeRelations{}).CanViewReports(); actual != expected[principal.Role] {
+			t.Errorf("%s may view reports: expected %v, got %v", principal.Role, expected[principal.Role], actual)
+		}
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatalf("expected no principal in an empty context")
+	}
+	if p, ok := FromContext(WithPrincipal(context.Background(), student)); !ok || p.UserId != student.UserId {
+		t.Fatalf("expected the student principal, got %+v", p)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	if !HasScope("read:students write:enrollments", WriteEnrollments) {
+		t.Errorf("expected write:enrollments to be found")
+	}
+	if HasScope("read:students", WriteStudents) || HasScope("", ReadStudents) {
+		t.Errorf("expected missing scopes not to be found")
+	}
+}
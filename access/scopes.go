@@ -0,0 +1,37 @@
+package access
+
+import "strings"
+
+// Scope is something an API key may do. API keys act for batch jobs and
+// partner systems rather than users: within their scopes they may do what a
+// registrar may.
+type Scope string
+
+const (
+	ReadStudents     Scope = "read:students"
+	WriteStudents    Scope = "write:students"
+	ReadCourses      Scope = "read:courses"
+	WriteCourses     Scope = "write:courses"
+	ReadDepartments  Scope = "read:departments"
+	WriteDepartments Scope = "write:departments"
+	ReadInstructors  Scope = "read:instructors"
+	WriteInstructors Scope = "write:instructors"
+	WriteEnrollments Scope = "write:enrollments"
+	ReadReports      Scope = "read:reports"
+)
+
+// Scopes lists every scope.
+var Scopes = []Scope{
+	ReadStudents, WriteStudents, ReadCourses, WriteCourses, ReadDepartments, WriteDepartments,
+	ReadInstructors, WriteInstructors, WriteEnrollments, ReadReports,
+}
+
+// HasScope reports whether scope is one of the space separated scopes.
+func HasScope(scopes string, scope Scope) bool {
+	for _, s := range strings.Fields(scopes) {
+		if Scope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"exercise1/access"
+	"exercise1/auth"
+	"exercise1/validation"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requireUser lets only requests with a valid access token in their
+// Authorization header through, with its claims in their context.
+func requireUser(authService *auth.Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="exercise1"`)
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		claims, err := authService.Authenticate(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="exercise1", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, auth.ErrInvalidToken.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
+}
+
+// authenticate lets requests with an API key in their X-API-Key header
+// through when the key has scope, with the key in their context, and leaves
+// all others to requireUser. Without a scope the route is for users only.
+func authenticate(authService *auth.Service, scope access.Scope, next http.Handler) http.Handler {
+	users := requireUser(authService, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get("X-API-Key")
+		if secret == "" {
+			users.ServeHTTP(w, r)
+			return
+		}
+		if scope == "" {
+			writeError(w, http.StatusForbidden, "API keys cannot be used here")
+			return
+		}
+		key, err := authService.AuthenticateAPIKey(r.Context(), secret)
+		if err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		if !access.HasScope(key.Scopes, scope) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("API key lacks scope %s", scope))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithAPIKey(r.Context(), key)))
+	})
+}
+
+type loginRequest struct {
+	Email    string
+	Password string
+}
+
+func login(authService *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request loginRequest
+		if !decodeBody(w, r, &request) {
+			return
+		}
+		tokens, err := authService.Login(r.Context(), request.Email, request.Password)
+		if err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		writeTokens(w, tokens)
+	}
+}
+
+type refreshRequest struct {
+	RefreshToken string
+}
+
+func refresh(authService *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request refreshRequest
+		if !decodeBody(w, r, &request) {
+			return
+		}
+		tokens, err := authService.Refresh(r.Context(), request.RefreshToken)
+		if err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		writeTokens(w, tokens)
+	}
+}
+
+func logout(authService *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request refreshRequest
+		if !decodeBody(w, r, &request) {
+			return
+		}
+		if err := authService.Logout(r.Context(), request.RefreshToken); err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type passwordResetRequest struct {
+	Email string
+}
+
+// requestPasswordReset answers the same whether or not the email has an
+// account, so it cannot be used to find out.
+func requestPasswordReset(authService *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request passwordResetRequest
+		if !decodeBody(w, r, &request) {
+			return
+		}
+		if err := authService.RequestPasswordReset(r.Context(), request.Email); err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type passwordResetConfirmation struct {
+	Token    string
+	Password string
+}
+
+func confirmPasswordReset(authService *auth.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request passwordResetConfirmation
+		if !decodeBody(w, r, &request) {
+			return
+		}
+		if err := authService.ResetPassword(r.Context(), request.Token, request.Password); err != nil {
+			writeAuthError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeTokens(w http.ResponseWriter, tokens auth.Tokens) {
+	// Tokens must not end up in shared caches.
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// writeAuthError maps errors of the auth package to HTTP statuses. A locked
+// account tells the client when to try again.
+func writeAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	var locked *auth.LockedError
+	if errors.As(err, &locked) {
+		retryAfter := math.Ceil(time.Until(locked.Until).Seconds())
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(retryAfter))))
+		writeError(w, http.StatusTooManyRequests, locked.Error())
+		return
+	}
+	var limited *auth.RateLimitedError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(limited.RetryAfter.Seconds())))))
+		writeError(w, http.StatusTooManyRequests, limited.Error())
+		return
+	}
+	var invalid validation.Errors
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials), errors.Is(err, auth.ErrInvalidToken):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, auth.ErrNoResetTokenSender):
+		writeError(w, http.StatusNotImplemented, err.Error())
+	case errors.As(err, &invalid):
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": "validation failed", "fields": invalid})
+	default:
+		writeInternalError(w, r, "authentication failed", err)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"exercise1/db"
+	"exercise1/service"
+	"net/http"
+)
+
+func listCourses(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, db.FindAllCourses(r.Context()))
+}
+
+func getCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	course := db.FindCourseById(r.Context(), id)
+	if course.Id == 0 {
+		writeError(w, http.StatusNotFound, "course not found")
+		return
+	}
+	setETag(w, course.Version)
+	writeJSON(w, http.StatusOK, course)
+}
+
+// coursePatch holds the fields of a course clients may change; the fields left
+// out stay as they are.
+type coursePatch struct {
+	Name         *string
+	DepartmentId *uint
+	InstructorId *uint
+	Credits      *uint
+	Term         *string
+}
+
+func (p coursePatch) course() db.Course {
+	var courseWithUpdatedFields db.Course
+	if p.Name != nil {
+		courseWithUpdatedFields.Name = *p.Name
+	}
+	if p.DepartmentId != nil {
+		courseWithUpdatedFields.DepartmentId = *p.DepartmentId
+	}
+	courseWithUpdatedFields.InstructorId = p.InstructorId
+	if p.Credits != nil {
+		courseWithUpdatedFields.Credits = *p.Credits
+	}
+	if p.Term != nil {
+		courseWithUpdatedFields.Term = *p.Term
+	}
+	return courseWithUpdatedFields
+}
+
+func patchCourse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	var patch coursePatch
+	if !decodePatch(w, r, &patch) {
+		return
+	}
+
+	course := db.FindCourseById(r.Context(), id)
+	if course.Id == 0 {
+		writeError(w, http.StatusNotFound, "course not found")
+		return
+	}
+	course.Version = version
+	if err := service.UpdateCourse(r.Context(), course, patch.course()); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+
+	course = db.FindCourseById(r.Context(), id)
+	setETag(w, course.Version)
+	writeJSON(w, http.StatusOK, course)
+}
+
+func getCourseStaff(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	if course := db.FindCourseById(r.Context(), id); course.Id == 0 {
+		writeError(w, http.StatusNotFound, "course not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, db.FindCourseStaff(r.Context(), uint(id)))
+}
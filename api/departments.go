@@ -0,0 +1,73 @@
+package api
+
+import (
+	"exercise1/db"
+	"exercise1/service"
+	"net/http"
+)
+
+func listDepartments(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, db.FindAllDepartments(r.Context()))
+}
+
+func getDepartment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	department := db.FindDepartmentById(r.Context(), id)
+	if department.Id == 0 {
+		writeError(w, http.StatusNotFound, "department not found")
+		return
+	}
+	setETag(w, department.Version)
+	writeJSON(w, http.StatusOK, department)
+}
+
+// departmentPatch holds the fields of a department clients may change; the fields left
+// out stay as they are.
+type departmentPatch struct {
+	Name *string
+	Kind *string
+}
+
+func (p departmentPatch) department() db.Department {
+	var departmentWithUpdatedFields db.Department
+	if p.Name != nil {
+		departmentWithUpdatedFields.Name = *p.Name
+	}
+	if p.Kind != nil {
+		departmentWithUpdatedFields.Kind = *p.Kind
+	}
+	return departmentWithUpdatedFields
+}
+
+func patchDepartment(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	var patch departmentPatch
+	if !decodePatch(w, r, &patch) {
+		return
+	}
+
+	department := db.FindDepartmentById(r.Context(), id)
+	if department.Id == 0 {
+		writeError(w, http.StatusNotFound, "department not found")
+		return
+	}
+	department.Version = version
+	if err := service.UpdateDepartment(r.Context(), department, patch.department()); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+
+	department = db.FindDepartmentById(r.Context(), id)
+	setETag(w, department.Version)
+	writeJSON(w, http.StatusOK, department)
+}
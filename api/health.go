@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"exercise1/db"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds the database ping of the readiness probe, so a
+// hanging database fails the probe instead of blocking it.
+const readinessTimeout = 2 * time.Second
+
+// liveness answers whether the process should be restarted. A database
+// outage alone does not make it fail.
+func liveness(w http.ResponseWriter, r *http.Request) {
+	if err := db.Live(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "down", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "up"})
+}
+
+// readiness answers whether the process should receive traffic, which
+// requires the database to answer.
+func readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	stats := db.Stats()
+	body := map[string]interface{}{
+		"status":          "ready",
+		"openConnections": stats.OpenConnections,
+		"inUse":           stats.InUse,
+		"idle":            stats.Idle,
+	}
+	if err := db.Ready(ctx); err != nil {
+		body["status"] = "unavailable"
+		body["error"] = err.Error()
+		writeJSON(w, http.StatusServiceUnavailable, body)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"exercise1/db"
+	"exercise1/service"
+	"net/http"
+)
+
+func listInstructors(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, db.FindAllInstructors(r.Context()))
+}
+
+func getInstructor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	instructor := db.FindInstructorById(r.Context(), id)
+	if instructor.Id == 0 {
+		writeError(w, http.StatusNotFound, "instructor not found")
+		return
+	}
+	setETag(w, instructor.Version)
+	writeJSON(w, http.StatusOK, instructor)
+}
+
+// instructorPatch holds the fields of a instructor clients may change; the fields left
+// out stay as they are.
+type instructorPatch struct {
+	FullName     *string
+	Age          *uint
+	DepartmentId *uint
+}
+
+func (p instructorPatch) instructor() db.Instructor {
+	var instructorWithUpdatedFields db.Instructor
+	if p.FullName != nil {
+		instructorWithUpdatedFields.FullName = *p.FullName
+	}
+	if p.Age != nil {
+		instructorWithUpdatedFields.Age = *p.Age
+	}
+	if p.DepartmentId != nil {
+		instructorWithUpdatedFields.DepartmentId = *p.DepartmentId
+	}
+	return instructorWithUpdatedFields
+}
+
+func patchInstructor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	var patch instructorPatch
+	if !decodePatch(w, r, &patch) {
+		return
+	}
+
+	instructor := db.FindInstructorById(r.Context(), id)
+	if instructor.Id == 0 {
+		writeError(w, http.StatusNotFound, "instructor not found")
+		return
+	}
+	instructor.Version = version
+	if err := service.UpdateInstructor(r.Context(), instructor, patch.instructor()); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+
+	instructor = db.FindInstructorById(r.Context(), id)
+	setETag(w, instructor.Version)
+	writeJSON(w, http.StatusOK, instructor)
+}
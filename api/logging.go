@@ -0,0 +1,58 @@
+package api
+
+import (
+	"exercise1/logging"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var logger = logging.For("api")
+
+// requestIDHeader carries the ID of a request, so it can be followed through
+// the logs of every service it passes.
+const requestIDHeader = "X-Request-ID"
+
+// probes are requested every few seconds and only logged at debug level.
+var probes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// logRequests gives every request an ID, taken from the X-Request-ID header
+// when the caller sent one, returns it in the response and logs the request
+// once it is done.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(logging.WithRequestID(r.Context(), id))
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		level := slog.LevelInfo
+		switch {
+		case recorder.status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case probes[r.URL.Path]:
+			level = slog.LevelDebug
+		}
+		logger.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration", time.Since(start))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
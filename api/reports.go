@@ -0,0 +1,38 @@
+package api
+
+import (
+	"exercise1/report"
+	"exercise1/service"
+	"net/http"
+)
+
+// reportRoute lets only principals that may see the reports through.
+func reportRoute(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := service.AuthorizeReports(r.Context()); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func departmentsReport(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, report.Departments(r.Context()))
+}
+
+func citiesReport(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, report.Cities(r.Context()))
+}
+
+func enrollmentsReport(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, report.EnrollmentsPerCourse(r.Context()))
+}
+
+func coursesWithoutStudentsReport(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, report.CoursesWithoutStudents(r.Context()))
+}
+
+func workloadReport(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, report.Workloads(r.Context()))
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"exercise1/access"
+	"exercise1/auth"
+	"exercise1/db"
+	"exercise1/gql"
+	"exercise1/validation"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHandler returns the HTTP API. Apart from logging in and the probes,
+// every route requires an access token issued by authService. Most routes
+// also accept an API key with the scope given for the route.
+func NewHandler(authService *auth.Service) http.Handler {
+	mux := http.NewServeMux()
+	// public traces the requests of pattern, continuing the trace of the
+	// caller when the request carries a traceparent header.
+	public := func(pattern string, handler http.Handler) {
+		mux.Handle(pattern, otelhttp.NewHandler(handler, pattern))
+	}
+	// route is like public, but lets only authenticated users and API keys
+	// with scope through.
+	route := func(pattern string, scope access.Scope, handler http.HandlerFunc) {
+		public(pattern, authenticate(authService, scope, allDepartments(handler)))
+	}
+
+	public("POST /auth/login", login(authService))
+	public("POST /auth/refresh", refresh(authService))
+	public("POST /auth/logout", logout(authService))
+	public("POST /auth/password-reset", requestPasswordReset(authService))
+	public("POST /auth/password-reset/confirm", confirmPasswordReset(authService))
+
+	route("GET /students", access.ReadStudents, listStudents)
+	route("GET /students/{id}", access.ReadStudents, getStudent)
+	route("PATCH /students/{id}", access.WriteStudents, patchStudent)
+	route("GET /students/{id}/export", access.ReadStudents, exportStudent)
+	route("POST /students/{id}/erase", access.WriteStudents, eraseStudent)
+	route("PUT /students/{id}/courses/{courseId}", access.WriteEnrollments, enrollStudent)
+	route("DELETE /students/{id}/courses/{courseId}", access.WriteEnrollments, dropStudent)
+
+	route("GET /courses", access.ReadCourses, listCourses)
+	route("GET /courses/{id}", access.ReadCourses, getCourse)
+	route("PATCH /courses/{id}", access.WriteCourses, patchCourse)
+	route("GET /courses/{id}/staff", access.ReadCourses, getCourseStaff)
+
+	route("GET /departments", access.ReadDepartments, listDepartments)
+	route("GET /departments/{id}", access.ReadDepartments, getDepartment)
+	route("PATCH /departments/{id}", access.WriteDepartments, patchDepartment)
+
+	route("GET /instructors", access.ReadInstructors, listInstructors)
+	route("GET /instructors/{id}", access.ReadInstructors, getInstructor)
+	route("PATCH /instructors/{id}", access.WriteInstructors, patchInstructor)
+
+	route("GET /reports/departments", access.ReadReports, reportRoute(departmentsReport))
+	route("GET /reports/cities", access.ReadReports, reportRoute(citiesReport))
+	route("GET /reports/enrollments", access.ReadReports, reportRoute(enrollmentsReport))
+	route("GET /reports/courses-without-students", access.ReadReports, reportRoute(coursesWithoutStudentsReport))
+	route("GET /reports/workload", access.ReadReports, reportRoute(workloadReport))
+
+	mux.HandleFunc("GET /healthz", liveness)
+	mux.HandleFunc("GET /readyz", readiness)
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	graphqlHandler, err := gql.NewHandler(gql.DefaultLimits)
+	if err != nil {
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+	// GraphQL queries span every scope, so it is for users only.
+	public("/graphql", requireUser(authService, allDepartments(graphqlHandler)))
+
+	return logRequests(mux)
+}
+
+// allDepartments runs the requests of next in a transaction that sees the
+// rows of every department, see db.AllDepartments. The service package
+// limits department admins to theirs again.
+func allDepartments(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served := false
+		err := db.AllDepartments(r.Context(), func(ctx context.Context) error {
+			served = true
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+		switch {
+		case err == nil:
+		case served:
+			logger.ErrorContext(r.Context(), "committing the request failed", "error", err)
+		default:
+			writeInternalError(w, r, "starting the transaction of the request failed", err)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeInternalError logs err and answers with a generic message, as the
+// error may tell clients about the database and its contents.
+func writeInternalError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.ErrorContext(r.Context(), message, "error", err)
+	writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+// writeUpdateError maps errors returned by the Update functions to HTTP
+// statuses. A version conflict means the If-Match precondition failed, and
+// validation errors list every rejected field.
+func writeUpdateError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, access.ErrForbidden) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	var conflict *db.ConflictError
+	if errors.As(err, &conflict) {
+		setETag(w, conflict.ActualVersion)
+		writeError(w, http.StatusPreconditionFailed, conflict.Error())
+		return
+	}
+	var invalid validation.Errors
+	if errors.As(err, &invalid) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error": "validation failed", "fields": invalid})
+		return
+	}
+	writeInternalError(w, r, "update failed", err)
+}
+
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	return namedPathId(w, r, "id")
+}
+
+func namedPathId(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid id %q", r.PathValue(name)))
+		return 0, false
+	}
+	return id, true
+}
+
+func setETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", fmt.Sprintf("%q", strconv.FormatUint(uint64(version), 10)))
+}
+
+// ifMatchVersion reads the version the client based its change on from the
+// If-Match header. Updates without it are rejected, because blindly
+// overwriting the row is exactly what the header is there to prevent.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		writeError(w, http.StatusPreconditionRequired, "If-Match header is required")
+		return 0, false
+	}
+
+	tag := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	version, err := strconv.ParseUint(strings.Trim(tag, `"`), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid If-Match header %q", header))
+		return 0, false
+	}
+	return uint(version), true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return decode(w, json.NewDecoder(r.Body), v)
+}
+
+// decodePatch decodes the body of a PATCH request into patch, rejecting
+// fields it does not have, so that clients learn a field cannot be changed
+// instead of it being ignored.
+func decodePatch(w http.ResponseWriter, r *http.Request, patch interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decode(w, decoder, patch)
+}
+
+func decode(w http.ResponseWriter, decoder *json.Decoder, v interface{}) bool {
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"exercise1/access"
+	"exercise1/db"
+	"exercise1/service"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+func listStudents(w http.ResponseWriter, r *http.Request) {
+	students, err := service.FindAllStudents(r.Context())
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, students)
+}
+
+func getStudent(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	student, err := service.FindStudentById(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if student.Id == 0 {
+		writeError(w, http.StatusNotFound, "student not found")
+		return
+	}
+	setETag(w, student.Version)
+	writeJSON(w, http.StatusOK, student)
+}
+
+type studentPatch struct {
+	Age *int
+}
+
+func patchStudent(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	var patch studentPatch
+	if !decodePatch(w, r, &patch) {
+		return
+	}
+	if patch.Age == nil {
+		writeError(w, http.StatusBadRequest, "only Age can be updated")
+		return
+	}
+
+	student, err := service.FindStudentById(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if student.Id == 0 {
+		writeError(w, http.StatusNotFound, "student not found")
+		return
+	}
+	student.Version = version
+	if err := service.UpdateStudentAge(r.Context(), student, *patch.Age); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+
+	student, _ = service.FindStudentById(r.Context(), id)
+	setETag(w, student.Version)
+	writeJSON(w, http.StatusOK, student)
+}
+
+// exportStudent answers a request of the student for their data with
+// everything stored about them.
+func exportStudent(w http.ResponseWriter, r *http.Request) {
+	student, ok := findStudent(w, r)
+	if !ok {
+		return
+	}
+	export, err := service.ExportStudent(r.Context(), student)
+	if err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="student-%d.json"`, student.Id))
+	writeJSON(w, http.StatusOK, export)
+}
+
+// eraseStudent anonymises the student for good.
+func eraseStudent(w http.ResponseWriter, r *http.Request) {
+	student, ok := findStudent(w, r)
+	if !ok {
+		return
+	}
+	if err := service.EraseStudent(r.Context(), student); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func findStudent(w http.ResponseWriter, r *http.Request) (db.Student, bool) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return db.Student{}, false
+	}
+	student, err := service.FindStudentById(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return db.Student{}, false
+	}
+	if student.Id == 0 {
+		writeError(w, http.StatusNotFound, "student not found")
+		return db.Student{}, false
+	}
+	return student, true
+}
+
+// enrollStudent enrolls the student in the course, as batch jobs importing
+// registrations do.
+func enrollStudent(w http.ResponseWriter, r *http.Request) {
+	changeEnrollment(w, r, service.EnrollStudentForCourse)
+}
+
+func dropStudent(w http.ResponseWriter, r *http.Request) {
+	changeEnrollment(w, r, service.DropStudentFromCourse)
+}
+
+func changeEnrollment(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, studentId, courseId uint) error) {
+	studentId, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+	courseId, ok := namedPathId(w, r, "courseId")
+	if !ok {
+		return
+	}
+	err := change(r.Context(), uint(studentId), uint(courseId))
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, access.ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, http.StatusNotFound, "student, course or enrollment not found")
+	default:
+		writeInternalError(w, r, "changing enrollment failed", err)
+	}
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"exercise1/access"
+	"exercise1/db"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// apiKeyPrefix starts every API key, so that leaked keys are easy to spot,
+// e.g. by secret scanners.
+const apiKeyPrefix = "uk_"
+
+// shownPrefixLength is how much of a key is stored in the clear to tell keys
+// apart.
+const shownPrefixLength = len(apiKeyPrefix) + 6
+
+// RateLimitedError is returned by AuthenticateAPIKey when the key made more
+// requests than its rate limit allows.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func newAPIKey() (key, hash string, err error) {
+	token, _, err := opaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + token
+	return key, hashToken(key), nil
+}
+
+// CreateAPIKey stores key and returns it with the secret the client
+// authenticates with. Only its hash is stored, so the secret cannot be shown
+// again.
+func (s *Service) CreateAPIKey(ctx context.Context, key db.APIKey) (string, db.APIKey, error) {
+	secret, hash, err := newAPIKey()
+	if err != nil {
+		return "", db.APIKey{}, err
+	}
+	key.Prefix, key.KeyHash = secret[:shownPrefixLength], hash
+	key, err = db.CreateAPIKey(ctx, key)
+	if err != nil {
+		return "", db.APIKey{}, err
+	}
+	return secret, key, nil
+}
+
+// RotateAPIKey replaces the key with id by a new one with the same scopes.
+// The old key keeps working for the configured grace period, so clients can
+// switch over without downtime.
+func (s *Service) RotateAPIKey(ctx context.Context, id uint) (string, db.APIKey, error) {
+	secret, hash, err := newAPIKey()
+	if err != nil {
+		return "", db.APIKey{}, err
+	}
+	replacement := db.APIKey{Prefix: secret[:shownPrefixLength], KeyHash: hash}
+	key, err := db.RotateAPIKey(ctx, id, replacement, s.now().Add(time.Duration(s.config.APIKeyRotationGrace)))
+	if err != nil {
+		return "", db.APIKey{}, err
+	}
+	return secret, key, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id uint) error {
+	return db.RevokeAPIKey(ctx, id, s.now())
+}
+
+// AuthenticateAPIKey returns the key with the given secret. Unknown, revoked
+// and expired keys return ErrInvalidToken, and keys over their rate limit a
+// *RateLimitedError.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, secret string) (db.APIKey, error) {
+	key := db.FindAPIKey(s.scope(ctx), hashToken(secret))
+	now := s.now()
+	if key.Id == 0 || key.RevokedAt != nil || key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		return db.APIKey{}, ErrInvalidToken
+	}
+
+	limit := key.RateLimit
+	if limit == 0 {
+		limit = uint(s.config.APIKeyRateLimit)
+	}
+	if wait := s.limiter.take(key.Id, limit, now); wait > 0 {
+		return db.APIKey{}, &RateLimitedError{RetryAfter: wait}
+	}
+
+	if err := db.TouchAPIKey(s.scope(ctx), key.Id, now); err != nil {
+		logger.WarnContext(ctx, "failed to record use of API key", "key", key.Id, "error", err)
+	}
+	return key, nil
+}
+
+type apiKeyKey struct{}
+
+// WithAPIKey returns ctx with the API key of the request. The key acts as a
+// registrar of its tenant; which requests it may make at all is up to its
+// scopes.
+func WithAPIKey(ctx context.Context, key db.APIKey) context.Context {
+	if key.TenantId != nil {
+		ctx = db.WithTenant(ctx, *key.TenantId)
+	}
+	ctx = access.WithPrincipal(ctx, access.Principal{Role: access.Registrar})
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the API key of ctx.
+func APIKeyFromContext(ctx context.Context) (db.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(db.APIKey)
+	return key, ok
+}
+
+// limiter is a token bucket per API key: a key may make up to its limit of
+// requests at once and regains them at that many per minute. The buckets
+// live in memory, so every instance of the server limits on its own.
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[uint]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{buckets: map[uint]*bucket{}}
+}
+
+// take takes a request of the key with id from its bucket, which holds
+// perMinute requests. It returns 0 when the request may be made and how long
+// until it may otherwise.
+func (l *limiter) take(id uint, perMinute uint, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := float64(perMinute)
+	perNanosecond := capacity / float64(time.Minute)
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[id] = b
+	}
+	if now.After(b.last) {
+		b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.last))*perNanosecond)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / perNanosecond))
+}
@@ -0,0 +1,274 @@
+// Package auth authenticates the users of the API. Users log in with their
+// email and password and get a short-lived access token (a JWT sent as
+// "Authorization: Bearer ...") and a long-lived refresh token to get new ones
+// with. Repeated failed logins lock the account for a while, and forgotten
+// passwords are replaced with single-use reset tokens. Batch jobs and partner
+// systems authenticate with scoped, rate limited API keys instead.
+package auth
+
+import (
+	"context"
+	"errors"
+	"exercise1/config"
+	"exercise1/db"
+	"exercise1/logging"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MinSecretLength is the minimum length in bytes of the secret signing the
+// access tokens.
+const MinSecretLength = 32
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	// ErrNoResetTokenSender is returned by RequestPasswordReset when no
+	// ResetTokenSender is configured.
+	ErrNoResetTokenSender = errors.New("password reset tokens cannot be delivered; ask an administrator for one")
+)
+
+// LockedError is returned by Login while the account is locked after too
+// many failed logins.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("account is locked until %s after too many failed logins", e.Until.UTC().Format(time.RFC3339))
+}
+
+var logger = logging.For("auth")
+
+// ResetTokenSender delivers a password reset token to user, e.g. by email.
+type ResetTokenSender func(ctx context.Context, user db.User, token string) error
+
+type Service struct {
+	config config.Auth
+	// Tenant is the tenant the server runs for in multi-tenant mode. Users
+	// and API keys of other tenants cannot authenticate with it.
+	Tenant *uint
+	// SendResetToken delivers the tokens of RequestPasswordReset. Without it
+	// reset tokens can only be issued on the command line.
+	SendResetToken ResetTokenSender
+	limiter        *limiter
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+func New(cfg config.Auth) *Service {
+	return &Service{config: cfg, limiter: newLimiter(), now: time.Now}
+}
+
+// scope returns ctx scoped to the tenant of the service, for the requests
+// made before the caller is authenticated.
+func (s *Service) scope(ctx context.Context) context.Context {
+	if s.Tenant == nil {
+		return ctx
+	}
+	return db.WithTenant(ctx, *s.Tenant)
+}
+
+// Login checks the password of the user with email and returns new tokens.
+// Unknown emails and wrong passwords both return ErrInvalidCredentials, and
+// a locked account returns a *LockedError.
+func (s *Service) Login(ctx context.Context, email, password string) (Tokens, error) {
+	ctx = s.scope(ctx)
+	user := db.FindUserByEmail(ctx, email)
+	if user.Id == 0 {
+		checkPassword(dummyHash(), password)
+		return Tokens{}, ErrInvalidCredentials
+	}
+	now := s.now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		return Tokens{}, &LockedError{Until: *user.LockedUntil}
+	}
+
+	ok, err := checkPassword(user.PasswordHash, password)
+	if err != nil {
+		return Tokens{}, err
+	}
+	if !ok {
+		lockedUntil := now.Add(time.Duration(s.config.LockoutDuration))
+		failures, err := db.RecordFailedLogin(ctx, user.Id, uint(s.config.MaxFailedLogins), lockedUntil)
+		if err != nil {
+			return Tokens{}, err
+		}
+		if failures >= uint(s.config.MaxFailedLogins) {
+			logger.WarnContext(ctx, "account locked", "user", user.Id, "until", lockedUntil)
+			return Tokens{}, &LockedError{Until: lockedUntil}
+		}
+		return Tokens{}, ErrInvalidCredentials
+	}
+
+	if err := db.RecordSuccessfulLogin(ctx, user.Id); err != nil {
+		return Tokens{}, err
+	}
+	return s.issue(ctx, user)
+}
+
+// Refresh exchanges a refresh token for new tokens. Every refresh token can
+// be used once; presenting one that was already used means it was stolen,
+// so all sessions of its user are ended.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	ctx = s.scope(ctx)
+	token := db.FindRefreshToken(ctx, hashToken(refreshToken))
+	if token.Id == 0 {
+		return Tokens{}, ErrInvalidToken
+	}
+	// Refresh tokens are not scoped themselves, but their users are: the
+	// tokens of other tenants are as unknown as if they did not exist.
+	user := db.FindUserById(ctx, int(token.UserId))
+	if user.Id == 0 {
+		return Tokens{}, ErrInvalidToken
+	}
+	now := s.now()
+	if token.RevokedAt != nil {
+		return Tokens{}, s.revokeReused(ctx, token.UserId, now)
+	}
+	if !token.ExpiresAt.After(now) {
+		return Tokens{}, ErrInvalidToken
+	}
+	if err := db.RevokeRefreshToken(ctx, token.Id, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Someone else refreshed with the same token in the meantime.
+			return Tokens{}, s.revokeReused(ctx, token.UserId, now)
+		}
+		return Tokens{}, err
+	}
+	return s.issue(ctx, user)
+}
+
+func (s *Service) revokeReused(ctx context.Context, userId uint, now time.Time) error {
+	logger.WarnContext(ctx, "refresh token reused, ending all sessions", "user", userId)
+	if err := db.RevokeUserRefreshTokens(ctx, userId, now); err != nil {
+		return err
+	}
+	return ErrInvalidToken
+}
+
+// Logout revokes refreshToken. Access tokens issued with it stay valid until
+// they expire. Unknown tokens, including those of other tenants, are ignored.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	ctx = s.scope(ctx)
+	token := db.FindRefreshToken(ctx, hashToken(refreshToken))
+	if token.Id == 0 || token.RevokedAt != nil {
+		return nil
+	}
+	// As in Refresh, the tokens of other tenants are found by their users.
+	if user := db.FindUserById(ctx, int(token.UserId)); user.Id == 0 {
+		return nil
+	}
+	err := db.RevokeRefreshToken(ctx, token.Id, s.now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Authenticate verifies an access token and returns its claims. Tokens
+// issued for another tenant are invalid.
+func (s *Service) Authenticate(accessToken string) (*Claims, error) {
+	claims, err := parseAccessToken([]byte(s.config.TokenSecret), accessToken, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !sameTenant(claims.TenantId, s.Tenant) {
+		return nil, fmt.Errorf("%w: issued for another tenant", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func sameTenant(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// RequestPasswordReset issues a reset token for the user with email and
+// passes it to SendResetToken. Unknown emails are ignored, so the caller
+// cannot tell which emails have accounts.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.SendResetToken == nil {
+		return ErrNoResetTokenSender
+	}
+	ctx = s.scope(ctx)
+	user := db.FindUserByEmail(ctx, email)
+	if user.Id == 0 {
+		return nil
+	}
+	token, err := s.IssueResetToken(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+	return s.SendResetToken(ctx, user, token)
+}
+
+// IssueResetToken returns a new password reset token for the user.
+func (s *Service) IssueResetToken(ctx context.Context, userId uint) (string, error) {
+	token, hash, err := opaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = db.CreatePasswordResetToken(ctx, db.PasswordResetToken{
+		UserId:    userId,
+		TokenHash: hash,
+		ExpiresAt: s.now().Add(time.Duration(s.config.ResetTokenTTL)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword sets the password of the user the reset token was issued
+// to. This unlocks the account and ends all its sessions.
+func (s *Service) ResetPassword(ctx context.Context, resetToken, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	ctx = s.scope(ctx)
+	now := s.now()
+	token, err := db.UsePasswordResetToken(ctx, hashToken(resetToken), now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if err := db.SetUserPassword(ctx, token.UserId, hash); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The user belongs to another tenant.
+			return ErrInvalidToken
+		}
+		return err
+	}
+	return db.RevokeUserRefreshTokens(ctx, token.UserId, now)
+}
+
+// issue returns an access token and a new refresh token for user.
+func (s *Service) issue(ctx context.Context, user db.User) (Tokens, error) {
+	now := s.now()
+	ttl := time.Duration(s.config.AccessTokenTTL)
+	access, err := accessToken([]byte(s.config.TokenSecret), user, now, ttl)
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, hash, err := opaqueToken()
+	if err != nil {
+		return Tokens{}, err
+	}
+	err = db.CreateRefreshToken(ctx, db.RefreshToken{
+		UserId:    user.Id,
+		TokenHash: hash,
+		ExpiresAt: now.Add(time.Duration(s.config.RefreshTokenTTL)),
+	})
+	if err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{AccessToken: access, TokenType: "Bearer", ExpiresIn: int(ttl.Seconds()), RefreshToken: refresh}, nil
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"exercise1/access"
+	"exercise1/config"
+	"exercise1/db"
+	"exercise1/db/dbtest"
+	"exercise1/validation"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	cost = bcrypt.MinCost
+}
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	now := time.Now()
+	token, err := accessToken(secret, db.User{Id: 7, Role: "student", StudentId: uintPtr(3)}, now, time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	claims, err := parseAccessToken(secret, token, now.Add(30*time.Second))
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if claims.UserId() != 7 || claims.StudentId == nil || *claims.StudentId != 3 || claims.InstructorId != nil {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if p := claims.Principal(); p.Role != access.Student || p.UserId != 7 || *p.StudentId != 3 {
+		t.Errorf("unexpected principal %+v", p)
+	}
+}
+
+func TestAccessTokenRejected(t *testing.T) {
+	now := time.Now()
+	valid, _ := accessToken(secret, db.User{Id: 7}, now, time.Minute)
+	otherSecret, _ := accessToken([]byte("another secret of at least 32 bytes"), db.User{Id: 7}, now, time.Minute)
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Issuer: issuer, Subject: "7", ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	noExpiry, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Issuer: issuer, Subject: "7"}).SignedString(secret)
+
+	tests := map[string]struct {
+		token string
+		at    time.Time
+	}{
+		"expired":      {valid, now.Add(2 * time.Minute)},
+		"other secret": {otherSecret, now},
+		"unsigned":     {unsigned, now},
+		"no expiry":    {noExpiry, now},
+		"garbage":      {"not.a.token", now},
+	}
+	for name, test := range tests {
+		if _, err := parseAccessToken(secret, test.token, test.at); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if ok, err := checkPassword(hash, "correct horse"); !ok || err != nil {
+		t.Errorf("expected password to match, got %v, %v", ok, err)
+	}
+	if ok, err := checkPassword(hash, "wrong horse"); ok || err != nil {
+		t.Errorf("expected other password not to match, got %v, %v", ok, err)
+	}
+
+	for _, password := range []string{"short", string(make([]byte, 73))} {
+		var invalid validation.Errors
+		if _, err := HashPassword(password); !errors.As(err, &invalid) {
+			t.Errorf("expected password of %d bytes to be rejected, got %v", len(password), err)
+		}
+	}
+}
+
+func TestOpaqueTokensAreStoredAsHashes(t *testing.T) {
+	token, hash, err := opaqueToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	other, _, _ := opaqueToken()
+	if token == other {
+		t.Errorf("expected random tokens, got %s twice", token)
+	}
+	if hash == token || hash != hashToken(token) {
+		t.Errorf("expected the hash of %s, got %s", token, hash)
+	}
+}
+
+func TestServiceAuthenticate(t *testing.T) {
+	s := New(config.Auth{TokenSecret: string(secret)})
+	token, _ := accessToken(secret, db.User{Id: 1}, time.Now(), time.Minute)
+
+	claims, err := s.Authenticate(token)
+	if err != nil || claims.UserId() != 1 {
+		t.Fatalf("expected user 1, got %v, %v", claims, err)
+	}
+	ctx := WithClaims(context.Background(), claims)
+	if fromCtx, ok := FromContext(ctx); !ok || fromCtx != claims {
+		t.Errorf("expected claims from context, got %v", fromCtx)
+	}
+	if p, ok := access.FromContext(ctx); !ok || p.UserId != 1 {
+		t.Errorf("expected principal from context, got %+v", p)
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("expected no claims without authentication")
+	}
+}
+
+func TestServiceAuthenticateChecksTenant(t *testing.T) {
+	s := New(config.Auth{TokenSecret: string(secret)})
+	s.Tenant = uintPtr(2)
+	own, _ := accessToken(secret, db.User{Id: 1, TenantId: uintPtr(2)}, time.Now(), time.Minute)
+	other, _ := accessToken(secret, db.User{Id: 1, TenantId: uintPtr(3)}, time.Now(), time.Minute)
+	none, _ := accessToken(secret, db.User{Id: 1}, time.Now(), time.Minute)
+
+	claims, err := s.Authenticate(own)
+	if err != nil {
+		t.Fatalf("expected a token of the tenant to be valid, got %v", err)
+	}
+	if tenantId, ok := db.TenantFromContext(WithClaims(context.Background(), claims)); !ok || tenantId != 2 {
+		t.Errorf("expected the queries of the caller to be scoped to tenant 2, got %d, %v", tenantId, ok)
+	}
+	for name, token := range map[string]string{"other tenant": other, "no tenant": none} {
+		if _, err := s.Authenticate(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestLogoutIgnoresOtherTenants(t *testing.T) {
+	f := dbtest.New(t)
+	var tenants []db.Tenant
+	for _, slug := range []string{"north", "south"} {
+		tenant, err := db.CreateTenant(f.Ctx, db.Tenant{Slug: slug, Name: slug + " campus"})
+		if err != nil {
+			t.Fatalf("creating tenant %s failed: %v", slug, err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	north, south := New(config.Auth{TokenSecret: string(secret)}), New(config.Auth{TokenSecret: string(secret)})
+	north.Tenant, south.Tenant = &tenants[0].Id, &tenants[1].Id
+
+	southCtx := db.WithTenant(f.Ctx, tenants[1].Id)
+	user, err := db.CreateUser(southCtx, db.User{Email: "registrar@example.com", PasswordHash: "hash", Role: "registrar"})
+	if err != nil {
+		t.Fatalf("creating a user failed: %v", err)
+	}
+	token, hash, _ := opaqueToken()
+	if err := db.CreateRefreshToken(southCtx, db.RefreshToken{UserId: user.Id, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("creating a refresh token failed: %v", err)
+	}
+
+	if err := north.Logout(f.Ctx, token); err != nil {
+		t.Fatalf("expected the token of another tenant to be ignored, got %v", err)
+	}
+	if found := db.FindRefreshToken(southCtx, hash); found.RevokedAt != nil {
+		t.Fatalf("expected the token to stay valid after a logout on another tenant, got %+v", found)
+	}
+	if err := south.Logout(f.Ctx, token); err != nil {
+		t.Fatalf("expected the logout to succeed, got %v", err)
+	}
+	if found := db.FindRefreshToken(southCtx, hash); found.RevokedAt == nil {
+		t.Fatalf("expected the token to be revoked, got %+v", found)
+	}
+}
+
+func TestAPIKeysAreStoredAsHashes(t *testing.T) {
+	key, hash, err := newAPIKey()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) || hash != hashToken(key) {
+		t.Errorf("expected a key starting with %s and its hash, got %s and %s", apiKeyPrefix, key, hash)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if wait := l.take(1, 3, now); wait != 0 {
+			t.Fatalf("expected request %d within the burst to pass, got wait %s", i+1, wait)
+		}
+	}
+	if wait := l.take(1, 3, now); wait != 20*time.Second {
+		t.Errorf("expected to wait for the next of 3 requests a minute, got %s", wait)
+	}
+	if wait := l.take(2, 3, now); wait != 0 {
+		t.Errorf("expected other keys to have their own bucket, got wait %s", wait)
+	}
+	if wait := l.take(1, 3, now.Add(20*time.Second)); wait != 0 {
+		t.Errorf("expected a request to pass once refilled, got wait %s", wait)
+	}
+}
+
+func TestWithAPIKey(t *testing.T) {
+	ctx := WithAPIKey(context.Background(), db.APIKey{Id: 4, Scopes: "read:students"})
+	if key, ok := APIKeyFromContext(ctx); !ok || key.Id != 4 {
+		t.Errorf("expected API key 4 from context, got %+v", key)
+	}
+	if p, ok := access.FromContext(ctx); !ok || p.Role != access.Registrar {
+		t.Errorf("expected API keys to act as registrars, got %+v", p)
+	}
+	if _, ok := db.TenantFromContext(ctx); ok {
+		t.Errorf("expected a key without tenant to leave the queries unscoped")
+	}
+	ctx = WithAPIKey(context.Background(), db.APIKey{Id: 5, TenantId: uintPtr(2)})
+	if tenantId, ok := db.TenantFromContext(ctx); !ok || tenantId != 2 {
+		t.Errorf("expected the queries of the key to be scoped to tenant 2, got %d, %v", tenantId, ok)
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+	"exercise1/validation"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	// bcrypt ignores everything after the first 72 bytes, so longer
+	// passwords are rejected rather than silently truncated.
+	maxPasswordLength = 72
+)
+
+// cost is the bcrypt cost of new hashes; tests lower it.
+var cost = bcrypt.DefaultCost
+
+// dummyHash is compared against when the email of a login is unknown, so
+// the response takes as long as for a known one and does not reveal which
+// emails have accounts. It is computed on first use, keeping the cost off
+// commands that never log anyone in.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), cost)
+	return string(hash)
+})
+
+// HashPassword checks the length of password and returns its bcrypt hash.
+// Passwords that are too short or too long return validation.Errors.
+func HashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", validation.Errors{{Field: "Password", Message: "must be between 8 and 72 bytes long"}}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	return string(hash), err
+}
+
+// checkPassword reports whether password matches hash.
+func checkPassword(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"exercise1/auth"
+	"exercise1/db"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// anonymiseCommand copies the database into an empty one with fake personal
+// data, for developers to work with, see db.CopyAnonymised. The connection
+// string of the copy is read from a file, as it holds a password.
+func anonymiseCommand(e *env, args []string) error {
+	fs := newFlagSet(e, "anonymise")
+	targetFile := fs.String("target-dsn-file", "", "file containing the connection string of the empty database to copy into")
+	password := fs.String("password", "", "password of every user in the copy; without it they need a reset token to log in")
+	batchSize := fs.Int("batch-size", 500, "rows copied per batch")
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	if *targetFile == "" {
+		return usagef("--target-dsn-file is required")
+	}
+	if *batchSize < 1 {
+		return usagef("--batch-size must be at least 1")
+	}
+
+	data, err := os.ReadFile(*targetFile)
+	if err != nil {
+		return fmt.Errorf("failed to read target connection string: %w", err)
+	}
+	if *password == "" {
+		random := make([]byte, 24)
+		if _, err := rand.Read(random); err != nil {
+			return err
+		}
+		*password = base64.RawURLEncoding.EncodeToString(random)
+	}
+	hash, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	total := map[string]int{}
+	err = db.CopyAnonymised(e.ctx, strings.TrimSpace(string(data)), *batchSize, hash, func(table string, rows int) {
+		total[table] += rows
+		fmt.Fprintf(e.stderr, "%s: %d rows copied\n", table, total[table])
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stderr, "the anonymised copy is complete")
+	return nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"exercise1/auth"
+	"exercise1/db"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func apiKeyCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"create": createAPIKey,
+		"list":   listAPIKeys,
+		"rotate": rotateAPIKey,
+		"revoke": revokeAPIKey,
+	})
+}
+
+func apiKeysTable(keys []db.APIKey) table {
+	t := table{
+		headers: []string{"ID", "NAME", "PREFIX", "SCOPES", "RATE LIMIT", "EXPIRES", "LAST USED", "REVOKED"},
+		value:   keys,
+	}
+	for _, key := range keys {
+		t.rows = append(t.rows, []string{u(key.Id), key.Name, key.Prefix, key.Scopes, u(key.RateLimit), timestamp(key.ExpiresAt), timestamp(key.LastUsedAt), timestamp(key.RevokedAt)})
+	}
+	return t
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// createAPIKey prints the new key, which cannot be shown again.
+func createAPIKey(e *env, args []string) error {
+	fs := newFlagSet(e, "apikey create")
+	name := fs.String("name", "", "what the key is for, e.g. the partner system using it")
+	scopes := fs.String("scopes", "", "comma separated scopes, e.g. read:students,write:enrollments")
+	rateLimit := fs.Uint("rate-limit", 0, "requests per minute, 0 for the configured default")
+	expiresIn := fs.Duration("expires-in", 0, "lifetime of the key, 0 for a key that does not expire")
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	key := db.APIKey{Name: *name, Scopes: strings.Join(strings.FieldsFunc(*scopes, func(r rune) bool { return r == ',' || r == ' ' }), " "), RateLimit: *rateLimit}
+	if *expiresIn > 0 {
+		expiresAt := time.Now().Add(*expiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+	secret, key, err := auth.New(e.config.Auth).CreateAPIKey(e.ctx, key)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "created API key %d (%s...)\n", key.Id, key.Prefix)
+	fmt.Fprintln(e.stdout, secret)
+	return nil
+}
+
+func listAPIKeys(e *env, args []string) error {
+	fs := newFlagSet(e, "apikey list")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	return e.print(*format, apiKeysTable(db.FindAllAPIKeys(e.ctx)))
+}
+
+// rotateAPIKey prints the key replacing the one with the given ID, which
+// keeps working for the configured grace period.
+func rotateAPIKey(e *env, args []string) error {
+	fs := newFlagSet(e, "apikey rotate")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if key := db.FindAPIKeyById(e.ctx, id); key.Id == 0 {
+		return notFound("API key", id)
+	}
+	secret, key, err := auth.New(e.config.Auth).RotateAPIKey(e.ctx, uint(id))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "API key %d replaced by %d (%s...); %d keeps working for %s\n", id, key.Id, key.Prefix, id, time.Duration(e.config.Auth.APIKeyRotationGrace))
+	fmt.Fprintln(e.stdout, secret)
+	return nil
+}
+
+func revokeAPIKey(e *env, args []string) error {
+	fs := newFlagSet(e, "apikey revoke")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if key := db.FindAPIKeyById(e.ctx, id); key.Id == 0 {
+		return notFound("API key", id)
+	}
+	return auth.New(e.config.Auth).RevokeAPIKey(e.ctx, uint(id))
+}
@@ -0,0 +1,312 @@
+// Package cli implements the administrative command line of the binary:
+//
+//	student|course|department|instructor <action> [flags] [ID]
+//	enroll --student ID --course ID [--drop]
+//	user create|list|delete|reset-token
+//	tenant create|list|delete
+//	encryption rotate|reencrypt|rewrap
+//	report <name>
+//	seed [--seed N] [--students N]
+//	anonymise --target-dsn-file FILE
+//	serve
+package cli
+
+import (
+	"context"
+	"errors"
+	"exercise1/config"
+	"exercise1/db"
+	"exercise1/logging"
+	"exercise1/tracing"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+const usage = `usage: exercise1 [config flags] <command> [arguments]
+
+commands:
+  student     create|list|get|update|delete|export|erase
+  course      create|list|get|update|delete|assign|unassign
+  department  create|list|get|update|delete|move
+  instructor  create|list|get|update|delete
+  enroll      enroll a student for a course, or drop them with --drop
+  user        create|list|delete|reset-token
+  apikey      create|list|rotate|revoke
+  tenant      create|list|delete
+  encryption  rotate|reencrypt|rewrap
+  report      departments|cities|enrollments|empty-courses|workload
+  seed        fill an empty database with generated data
+  anonymise   copy the database with fake personal data for development
+  serve       run the HTTP and gRPC servers
+
+config flags:
+  --config FILE  --db-host  --db-port  --db-user  --db-password-file
+  --db-name  --db-sslmode  --db-timezone  --http-addr  --grpc-addr
+  --db-max-open-conns  --db-max-idle-conns  --db-conn-max-lifetime
+  --db-conn-max-idle-time  --db-connect-attempts  --db-connect-backoff
+  --db-slow-query-threshold  --log-level  --log-levels  --log-format
+  --tracing-exporter  --otlp-endpoint  --otlp-insecure
+  --auth-token-secret-file  --auth-access-token-ttl  --auth-refresh-token-ttl
+  --auth-reset-token-ttl  --auth-max-failed-logins  --auth-lockout-duration
+  --auth-api-key-rate-limit  --auth-api-key-rotation-grace
+  --encryption-master-key-file  --tenant
+
+Run "exercise1 <command> <action> -h" for the flags of a command.
+`
+
+// usageError marks errors caused by wrong arguments, reported with exit
+// code 2 instead of 1.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+type command func(env *env, args []string) error
+
+var commands = map[string]command{
+	"student":    studentCommand,
+	"course":     courseCommand,
+	"department": departmentCommand,
+	"instructor": instructorCommand,
+	"enroll":     enrollCommand,
+	"user":       userCommand,
+	"apikey":     apiKeyCommand,
+	"tenant":     tenantCommand,
+	"encryption": encryptionCommand,
+	"report":     reportCommand,
+	"seed":       seedCommand,
+	"anonymise":  anonymiseCommand,
+	"serve":      serveCommand,
+}
+
+// env holds the context, the configuration and what commands write to.
+type env struct {
+	ctx    context.Context
+	config config.Config
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var tracer = otel.Tracer("exercise1/cli")
+
+// setupTracing installs the tracer provider for the commands; tests replace
+// it.
+var setupTracing = tracing.Setup
+
+// allDepartments runs the commands in a transaction that sees the rows of
+// every department, see db.AllDepartments; tests replace it.
+var allDepartments = db.AllDepartments
+
+// connect opens the database for the commands; tests replace it.
+var connect = func(cfg config.Config) error {
+	options := connectOptions(cfg.Database)
+	key, err := cfg.Encryption.Key()
+	if err != nil {
+		return err
+	}
+	options.MasterKey = key
+	return db.ConnectWithOptions(context.Background(), cfg.Database.DSN(), options)
+}
+
+func connectOptions(d config.Database) db.Options {
+	options := db.DefaultOptions()
+	options.MaxOpenConns = d.MaxOpenConns
+	options.MaxIdleConns = d.MaxIdleConns
+	options.ConnMaxLifetime = time.Duration(d.ConnMaxLifetime)
+	options.ConnMaxIdleTime = time.Duration(d.ConnMaxIdleTime)
+	options.Attempts = d.ConnectAttempts
+	options.InitialBackoff = time.Duration(d.ConnectBackoff)
+	options.SlowQueryThreshold = time.Duration(d.SlowQueryThreshold)
+	return options
+}
+
+// Run executes the command in args and returns the process exit code.
+func Run(cfg config.Config, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	if err := logging.Setup(cfg.Logging, stderr); err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return exitFailure
+	}
+
+	shutdownTracing, err := setupTracing(context.Background(), cfg.Tracing, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return exitFailure
+	}
+	defer shutdownTracing(context.Background())
+
+	if err := connect(cfg); err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return exitFailure
+	}
+	defer db.Close()
+
+	// A command runs as one trace, except serve, which traces every request
+	// on its own.
+	ctx := context.Background()
+	if args[0] != "serve" {
+		var span trace.Span
+		ctx, span = tracer.Start(ctx, "cli "+strings.Join(args[:min(2, len(args))], " "))
+		defer span.End()
+	}
+	// The tenants themselves are managed across all of them.
+	if cfg.Tenant != "" && args[0] != "tenant" {
+		if ctx, err = withTenant(ctx, cfg.Tenant); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+			return exitFailure
+		}
+	}
+
+	run := func(ctx context.Context) error {
+		return cmd(&env{ctx: ctx, config: cfg, stdout: stdout, stderr: stderr}, args[1:])
+	}
+	// Commands see the rows of every department, in one transaction, except
+	// serve, which scopes every request on its own, and the encryption
+	// commands, which re-encrypt batch by batch.
+	switch args[0] {
+	case "serve", "encryption":
+		err = run(ctx)
+	default:
+		err = allDepartments(ctx, run)
+	}
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return exitFailure
+	}
+}
+
+// subcommand dispatches "<entity> <action> ..." to the action's function.
+func subcommand(e *env, args []string, actions map[string]command) error {
+	if len(args) == 0 {
+		return usagef("missing action, expected one of %s", actionNames(actions))
+	}
+	action, ok := actions[args[0]]
+	if !ok {
+		return usagef("unknown action %q, expected one of %s", args[0], actionNames(actions))
+	}
+	return action(e, args[1:])
+}
+
+func actionNames(actions map[string]command) string {
+	names := ""
+	for _, name := range []string{"create", "list", "get", "update", "delete", "assign", "unassign", "move", "reset-token", "rotate", "reencrypt", "rewrap", "revoke", "export", "erase"} {
+		if _, ok := actions[name]; ok {
+			if names != "" {
+				names += "|"
+			}
+			names += name
+		}
+	}
+	return names
+}
+
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// parseFlags parses args, turning bad flags into usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return &usageError{message: err.Error()}
+	}
+	return err
+}
+
+// parseWithId parses flags that may appear before or after the single
+// positional ID argument and returns the ID.
+func parseWithId(fs *flag.FlagSet, args []string) (int, error) {
+	if err := parseFlags(fs, args); err != nil {
+		return 0, err
+	}
+	if fs.NArg() == 0 {
+		return 0, usagef("missing ID")
+	}
+	idArg := fs.Arg(0)
+	if err := parseFlags(fs, fs.Args()[1:]); err != nil {
+		return 0, err
+	}
+	if fs.NArg() > 0 {
+		return 0, usagef("unexpected arguments %v", fs.Args())
+	}
+	id, err := strconv.Atoi(idArg)
+	if err != nil || id <= 0 {
+		return 0, usagef("invalid ID %q", idArg)
+	}
+	return id, nil
+}
+
+func parseNoArgs(fs *flag.FlagSet, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("unexpected arguments %v", fs.Args())
+	}
+	return nil
+}
+
+// isSet reports whether the flag was given on the command line, so updates
+// only touch the fields the user asked to change.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func notFound(entity string, id int) error {
+	return fmt.Errorf("%s %d not found", entity, id)
+}
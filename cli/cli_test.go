@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"exercise1/config"
+	"strings"
+	"testing"
+)
+
+func init() {
+	connect = func(config.Config) error { return nil }
+	allDepartments = func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, exitUsage},
+		{[]string{"help"}, exitOK},
+		{[]string{"unknown"}, exitUsage},
+		{[]string{"student"}, exitUsage},
+		{[]string{"student", "explode"}, exitUsage},
+		{[]string{"student", "get"}, exitUsage},
+		{[]string{"student", "get", "abc"}, exitUsage},
+		{[]string{"student", "list", "--bogus"}, exitUsage},
+		{[]string{"enroll", "--student", "1"}, exitUsage},
+		{[]string{"report", "nonsense"}, exitUsage},
+		{[]string{"anonymise"}, exitUsage},
+		{[]string{"seed", "--departments", "0"}, exitUsage},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(config.Defaults(), test.args, &stdout, &stderr); code != test.code {
+			t.Errorf("Run(%v) expected exit code %d, got %d (stderr: %s)", test.args, test.code, code, stderr.String())
+		}
+	}
+}
+
+func TestRunReportsUsageErrorsPerFlagSet(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	Run(config.Defaults(), []string{"student", "list", "--bogus"}, &stdout, &stderr)
+	if !strings.Contains(stderr.String(), "flag provided but not defined: -bogus") {
+		t.Fatalf("expected flag error on stderr, got %q", stderr.String())
+	}
+}
+
+func TestParseWithIdAcceptsFlagsAroundId(t *testing.T) {
+	e := &env{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	fs := newFlagSet(e, "test")
+	format := formatFlag(fs)
+	age := fs.Int("age", 0, "")
+
+	id, err := parseWithId(fs, []string{"--age", "21", "7", "--format", "json"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id != 7 || *age != 21 || *format != formatJSON {
+		t.Fatalf("expected id 7, age 21 and json, got %d, %d and %s", id, *age, *format)
+	}
+}
+
+func TestPrintFormats(t *testing.T) {
+	value := []struct{ Name string }{{"Almaty"}, {"Turkistan"}}
+	tbl := table{headers: []string{"CITY", "STUDENTS"}, rows: [][]string{{"Almaty", "2"}, {"Turkistan", "1"}}, value: value}
+
+	expected := map[string]string{
+		formatTable: "CITY       STUDENTS\nAlmaty     2\nTurkistan  1\n",
+		formatCSV:   "CITY,STUDENTS\nAlmaty,2\nTurkistan,1\n",
+		formatJSON:  "[\n  {\n    \"Name\": \"Almaty\"\n  },\n  {\n    \"Name\": \"Turkistan\"\n  }\n]\n",
+	}
+	for format, want := range expected {
+		var stdout bytes.Buffer
+		e := &env{stdout: &stdout, stderr: &bytes.Buffer{}}
+		if err := e.print(format, tbl); err != nil {
+			t.Fatalf("print %s failed: %v", format, err)
+		}
+		if got := stdout.String(); got != want {
+			t.Errorf("print %s expected %q, got %q", format, want, got)
+		}
+	}
+}
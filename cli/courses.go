@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"exercise1/db"
+)
+
+func courseCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"create":   createCourse,
+		"list":     listCourses,
+		"get":      getCourse,
+		"update":   updateCourse,
+		"delete":   deleteCourse,
+		"assign":   assignInstructor,
+		"unassign": unassignInstructor,
+	})
+}
+
+func coursesTable(courses []db.Course) table {
+	t := table{
+		headers: []string{"ID", "NAME", "DEPARTMENT", "INSTRUCTOR", "CREDITS", "TERM", "VERSION"},
+		value:   courses,
+	}
+	for _, c := range courses {
+		t.rows = append(t.rows, []string{u(c.Id), c.Name, u(c.DepartmentId), optional(c.InstructorId), u(c.Credits), c.Term, u(c.Version)})
+	}
+	return t
+}
+
+func createCourse(e *env, args []string) error {
+	fs := newFlagSet(e, "course create")
+	name := fs.String("name", "", "course name")
+	department := fs.Uint("department", 0, "department ID")
+	instructor := fs.Uint("instructor", 0, "primary instructor ID")
+	credits := fs.Uint("credits", 0, "credits")
+	term := fs.String("term", "", "term, e.g. 2024-fall")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	course := db.Course{Name: *name, DepartmentId: *department, Credits: *credits, Term: *term}
+	if isSet(fs, "instructor") {
+		course.InstructorId = uintPtr(*instructor)
+	}
+	course, err := db.CreateCourse(e.ctx, course)
+	if err != nil {
+		return err
+	}
+	return e.print(*format, coursesTable([]db.Course{course}))
+}
+
+func listCourses(e *env, args []string) error {
+	fs := newFlagSet(e, "course list")
+	instructor := fs.Uint("instructor", 0, "only courses of this instructor")
+	withoutInstructor := fs.Bool("without-instructor", false, "only courses nobody is assigned to")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	var courses []db.Course
+	switch {
+	case *withoutInstructor:
+		courses = db.FindCoursesWithoutInstructor(e.ctx)
+	case isSet(fs, "instructor"):
+		courses = db.FindAllCoursesByInstructorId(e.ctx, *instructor)
+	default:
+		courses = db.FindAllCourses(e.ctx)
+	}
+	return e.print(*format, coursesTable(courses))
+}
+
+func getCourse(e *env, args []string) error {
+	fs := newFlagSet(e, "course get")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	course := db.FindCourseById(e.ctx, id)
+	if course.Id == 0 {
+		return notFound("course", id)
+	}
+	return e.print(*format, coursesTable([]db.Course{course}))
+}
+
+func updateCourse(e *env, args []string) error {
+	fs := newFlagSet(e, "course update")
+	name := fs.String("name", "", "new name")
+	department := fs.Uint("department", 0, "new department ID")
+	credits := fs.Uint("credits", 0, "new credits")
+	term := fs.String("term", "", "new term")
+	version := fs.Uint("version", 0, "version the change is based on, fails if the course changed since")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	course := db.FindCourseById(e.ctx, id)
+	if course.Id == 0 {
+		return notFound("course", id)
+	}
+	if isSet(fs, "version") {
+		course.Version = *version
+	}
+	courseWithUpdatedFields := db.Course{Name: *name, DepartmentId: *department, Credits: *credits, Term: *term}
+	if err := db.UpdateCourse(e.ctx, course, courseWithUpdatedFields); err != nil {
+		return err
+	}
+	return e.print(*format, coursesTable([]db.Course{db.FindCourseById(e.ctx, id)}))
+}
+
+func deleteCourse(e *env, args []string) error {
+	fs := newFlagSet(e, "course delete")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	course := db.FindCourseById(e.ctx, id)
+	if course.Id == 0 {
+		return notFound("course", id)
+	}
+	return db.DeleteCourse(e.ctx, course)
+}
+
+func assignInstructor(e *env, args []string) error {
+	fs := newFlagSet(e, "course assign")
+	instructor := fs.Uint("instructor", 0, "instructor ID")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+	if !isSet(fs, "instructor") {
+		return usagef("--instructor is required")
+	}
+	return db.AssignInstructor(e.ctx, uint(id), *instructor)
+}
+
+func unassignInstructor(e *env, args []string) error {
+	fs := newFlagSet(e, "course unassign")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+	return db.UnassignInstructor(e.ctx, uint(id))
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"exercise1/db"
+)
+
+func departmentCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"create": createDepartment,
+		"list":   listDepartments,
+		"get":    getDepartment,
+		"update": updateDepartment,
+		"delete": deleteDepartment,
+		"move":   moveDepartment,
+	})
+}
+
+func departmentsTable(departments []db.Department) table {
+	t := table{
+		headers: []string{"ID", "NAME", "KIND", "PARENT", "MAX LOAD", "VERSION"},
+		value:   departments,
+	}
+	for _, d := range departments {
+		t.rows = append(t.rows, []string{u(d.Id), d.Name, d.Kind, optional(d.ParentId), optional(d.MaxTeachingLoad), u(d.Version)})
+	}
+	return t
+}
+
+func createDepartment(e *env, args []string) error {
+	fs := newFlagSet(e, "department create")
+	name := fs.String("name", "", "department name")
+	kind := fs.String("kind", "", "school, faculty or department")
+	parent := fs.Uint("parent", 0, "parent department ID")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	department := db.Department{Name: *name, Kind: *kind}
+	if isSet(fs, "parent") {
+		department.ParentId = uintPtr(*parent)
+	}
+	department, err := db.CreateDepartment(e.ctx, department)
+	if err != nil {
+		return err
+	}
+	return e.print(*format, departmentsTable([]db.Department{department}))
+}
+
+func listDepartments(e *env, args []string) error {
+	fs := newFlagSet(e, "department list")
+	parent := fs.Uint("parent", 0, "only direct children of this department")
+	roots := fs.Bool("roots", false, "only departments without a parent")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	var departments []db.Department
+	switch {
+	case *roots:
+		departments = db.FindRootDepartments(e.ctx)
+	case isSet(fs, "parent"):
+		departments = db.FindChildDepartments(e.ctx, *parent)
+	default:
+		departments = db.FindAllDepartments(e.ctx)
+	}
+	return e.print(*format, departmentsTable(departments))
+}
+
+func getDepartment(e *env, args []string) error {
+	fs := newFlagSet(e, "department get")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	department := db.FindDepartmentById(e.ctx, id)
+	if department.Id == 0 {
+		return notFound("department", id)
+	}
+	return e.print(*format, departmentsTable([]db.Department{department}))
+}
+
+func updateDepartment(e *env, args []string) error {
+	fs := newFlagSet(e, "department update")
+	name := fs.String("name", "", "new name")
+	kind := fs.String("kind", "", "new kind")
+	maxLoad := fs.Uint("max-load", 0, "maximum teaching load of its instructors")
+	version := fs.Uint("version", 0, "version the change is based on, fails if the department changed since")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	department := db.FindDepartmentById(e.ctx, id)
+	if department.Id == 0 {
+		return notFound("department", id)
+	}
+	if isSet(fs, "version") {
+		department.Version = *version
+	}
+	departmentWithUpdatedFields := db.Department{Name: *name, Kind: *kind}
+	if isSet(fs, "max-load") {
+		departmentWithUpdatedFields.MaxTeachingLoad = uintPtr(*maxLoad)
+	}
+	if err := db.UpdateDepartment(e.ctx, department, departmentWithUpdatedFields); err != nil {
+		return err
+	}
+	return e.print(*format, departmentsTable([]db.Department{db.FindDepartmentById(e.ctx, id)}))
+}
+
+func deleteDepartment(e *env, args []string) error {
+	fs := newFlagSet(e, "department delete")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	department := db.FindDepartmentById(e.ctx, id)
+	if department.Id == 0 {
+		return notFound("department", id)
+	}
+	return db.DeleteDepartment(e.ctx, department)
+}
+
+func moveDepartment(e *env, args []string) error {
+	fs := newFlagSet(e, "department move")
+	parent := fs.Uint("parent", 0, "new parent department ID")
+	root := fs.Bool("root", false, "make the department a root")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *root:
+		return db.MoveDepartment(e.ctx, uint(id), nil)
+	case isSet(fs, "parent"):
+		return db.MoveDepartment(e.ctx, uint(id), uintPtr(*parent))
+	default:
+		return usagef("either --parent or --root is required")
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"exercise1/config"
+	"exercise1/db"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func encryptionCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"rotate":    rotateEncryptionKey,
+		"reencrypt": reencrypt,
+		"rewrap":    rewrapEncryptionKeys,
+	})
+}
+
+// batchFlags adds the flags pacing the re-encryption, which runs next to
+// the servers.
+func batchFlags(fs *flag.FlagSet) (batchSize *int, pause *time.Duration) {
+	batchSize = fs.Int("batch-size", 500, "rows re-encrypted per transaction")
+	pause = fs.Duration("pause", 100*time.Millisecond, "wait between batches, to spare the database")
+	return batchSize, pause
+}
+
+// rotateEncryptionKey creates a new data key and re-encrypts all rows with
+// it, once the servers have switched to it a minute later. If it is
+// interrupted, reencrypt finishes the job.
+func rotateEncryptionKey(e *env, args []string) error {
+	fs := newFlagSet(e, "encryption rotate")
+	batchSize, pause := batchFlags(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	key, err := db.RotateEncryptionKey(e.ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "created data key %d\n", key.Id)
+	return reencryptRows(e, *batchSize, *pause)
+}
+
+// reencrypt re-encrypts the rows not encrypted with the newest data key,
+// e.g. after turning encryption on for an existing database. Without a
+// master key it fills in the blind indexes of the rows stored before they
+// existed.
+func reencrypt(e *env, args []string) error {
+	fs := newFlagSet(e, "encryption reencrypt")
+	batchSize, pause := batchFlags(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	return reencryptRows(e, *batchSize, *pause)
+}
+
+func reencryptRows(e *env, batchSize int, pause time.Duration) error {
+	if batchSize < 1 {
+		return usagef("--batch-size must be at least 1")
+	}
+	total := map[string]int{}
+	err := db.ReencryptRows(e.ctx, batchSize, pause, func(table string, rows int) {
+		total[table] += rows
+		fmt.Fprintf(e.stderr, "%s: %d rows re-encrypted\n", table, total[table])
+	})
+	if err != nil {
+		return err
+	}
+	if e.config.Encryption.MasterKey == "" {
+		fmt.Fprintln(e.stderr, "all rows are indexed; they stay in plain text without a master key")
+		return nil
+	}
+	fmt.Fprintln(e.stderr, "all rows are encrypted with the newest key")
+	return nil
+}
+
+// rewrapEncryptionKeys re-encrypts the stored keys for a new master key:
+// configure the new one and pass the old one with --old-master-key-file.
+func rewrapEncryptionKeys(e *env, args []string) error {
+	fs := newFlagSet(e, "encryption rewrap")
+	oldKeyFile := fs.String("old-master-key-file", "", "file containing the master key the keys are encrypted with now")
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	if *oldKeyFile == "" {
+		return usagef("--old-master-key-file is required")
+	}
+
+	data, err := os.ReadFile(*oldKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read old master key: %w", err)
+	}
+	oldKey, err := config.Encryption{MasterKey: strings.TrimSpace(string(data))}.Key()
+	if err != nil {
+		return err
+	}
+	rewrapped, err := db.RewrapEncryptionKeys(e.ctx, oldKey)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "%d keys are now encrypted with the new master key\n", rewrapped)
+	return nil
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"exercise1/db"
+)
+
+func enrollCommand(e *env, args []string) error {
+	fs := newFlagSet(e, "enroll")
+	student := fs.Uint("student", 0, "student ID")
+	course := fs.Uint("course", 0, "course ID")
+	drop := fs.Bool("drop", false, "drop the student from the course instead")
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	if !isSet(fs, "student") || !isSet(fs, "course") {
+		return usagef("--student and --course are required")
+	}
+	if *drop {
+		return db.DropStudentFromCourse(e.ctx, *student, *course)
+	}
+	return db.EnrollStudentForCourse(e.ctx, *student, *course)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"exercise1/db"
+)
+
+func instructorCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"create": createInstructor,
+		"list":   listInstructors,
+		"get":    getInstructor,
+		"update": updateInstructor,
+		"delete": deleteInstructor,
+	})
+}
+
+func instructorsTable(instructors []db.Instructor) table {
+	t := table{
+		headers: []string{"ID", "FULL NAME", "AGE", "DEPARTMENT", "MAX LOAD", "VERSION"},
+		value:   instructors,
+	}
+	for _, i := range instructors {
+		t.rows = append(t.rows, []string{u(i.Id), i.FullName, u(i.Age), u(i.DepartmentId), optional(i.MaxTeachingLoad), u(i.Version)})
+	}
+	return t
+}
+
+func createInstructor(e *env, args []string) error {
+	fs := newFlagSet(e, "instructor create")
+	name := fs.String("name", "", "full name")
+	age := fs.Uint("age", 0, "age")
+	department := fs.Uint("department", 0, "department ID")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	instructor, err := db.CreateInstructor(e.ctx, db.Instructor{FullName: *name, Age: *age, DepartmentId: *department})
+	if err != nil {
+		return err
+	}
+	return e.print(*format, instructorsTable([]db.Instructor{instructor}))
+}
+
+func listInstructors(e *env, args []string) error {
+	fs := newFlagSet(e, "instructor list")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	return e.print(*format, instructorsTable(db.FindAllInstructors(e.ctx)))
+}
+
+func getInstructor(e *env, args []string) error {
+	fs := newFlagSet(e, "instructor get")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	instructor := db.FindInstructorById(e.ctx, id)
+	if instructor.Id == 0 {
+		return notFound("instructor", id)
+	}
+	return e.print(*format, instructorsTable([]db.Instructor{instructor}))
+}
+
+func updateInstructor(e *env, args []string) error {
+	fs := newFlagSet(e, "instructor update")
+	name := fs.String("name", "", "new full name")
+	age := fs.Uint("age", 0, "new age")
+	department := fs.Uint("department", 0, "new department ID")
+	maxLoad := fs.Uint("max-load", 0, "maximum teaching load")
+	version := fs.Uint("version", 0, "version the change is based on, fails if the instructor changed since")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	instructor := db.FindInstructorById(e.ctx, id)
+	if instructor.Id == 0 {
+		return notFound("instructor", id)
+	}
+	if isSet(fs, "version") {
+		instructor.Version = *version
+	}
+	instructorWithUpdatedFields := db.Instructor{FullName: *name, Age: *age, DepartmentId: *department}
+	if isSet(fs, "max-load") {
+		instructorWithUpdatedFields.MaxTeachingLoad = uintPtr(*maxLoad)
+	}
+	if err := db.UpdateInstructor(e.ctx, instructor, instructorWithUpdatedFields); err != nil {
+		return err
+	}
+	return e.print(*format, instructorsTable([]db.Instructor{db.FindInstructorById(e.ctx, id)}))
+}
+
+func deleteInstructor(e *env, args []string) error {
+	fs := newFlagSet(e, "instructor delete")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	instructor := db.FindInstructorById(e.ctx, id)
+	if instructor.Id == 0 {
+		return notFound("instructor", id)
+	}
+	return db.DeleteInstructor(e.ctx, instructor)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// table is the output of a command: the rows for table and CSV output and
+// the original value for JSON.
+type table struct {
+	headers []string
+	rows    [][]string
+	value   interface{}
+}
+
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", formatTable, "output format: table, json or csv")
+}
+
+func (e *env) print(format string, t table) error {
+	switch format {
+	case formatTable:
+		return writeTable(e.stdout, t)
+	case formatJSON:
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t.value)
+	case formatCSV:
+		w := csv.NewWriter(e.stdout)
+		w.Write(t.headers)
+		w.WriteAll(t.rows)
+		return w.Error()
+	default:
+		return usagef("unknown format %q, expected table, json or csv", format)
+	}
+}
+
+func writeTable(out io.Writer, t table) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func u(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func optional(v *uint) string {
+	if v == nil {
+		return ""
+	}
+	return u(*v)
+}
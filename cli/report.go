@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"exercise1/report"
+	"fmt"
+)
+
+func reportCommand(e *env, args []string) error {
+	fs := newFlagSet(e, "report")
+	format := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("missing report, expected departments|cities|enrollments|empty-courses|workload")
+	}
+	name := fs.Arg(0)
+	if err := parseFlags(fs, fs.Args()[1:]); err != nil {
+		return err
+	}
+
+	var t table
+	switch name {
+	case "departments":
+		departments := report.Departments(e.ctx)
+		t = table{headers: []string{"ID", "NAME", "STUDENTS", "COURSES", "INSTRUCTORS", "AVG AGE"}, value: departments}
+		for _, d := range departments {
+			t.rows = append(t.rows, []string{u(d.DepartmentId), d.Name, u(d.StudentCount), u(d.CourseCount), u(d.InstructorCount), fmt.Sprintf("%.1f", d.AverageStudentAge)})
+		}
+	case "cities":
+		cities := report.Cities(e.ctx)
+		t = table{headers: []string{"CITY", "STUDENTS"}, value: cities}
+		for _, c := range cities {
+			t.rows = append(t.rows, []string{c.City, u(c.StudentCount)})
+		}
+	case "enrollments":
+		enrollments := report.EnrollmentsPerCourse(e.ctx)
+		t = table{headers: []string{"ID", "COURSE", "STUDENTS"}, value: enrollments}
+		for _, c := range enrollments {
+			t.rows = append(t.rows, []string{u(c.CourseId), c.Name, u(c.StudentCount)})
+		}
+	case "empty-courses":
+		t = coursesTable(report.CoursesWithoutStudents(e.ctx))
+	case "workload":
+		workloads := report.Workloads(e.ctx)
+		t = table{headers: []string{"ID", "INSTRUCTOR", "TERM", "COURSES", "STUDENTS", "LOAD", "MAX", "STATUS"}, value: workloads}
+		for _, l := range workloads {
+			t.rows = append(t.rows, []string{u(l.InstructorId), l.FullName, l.Term, u(l.CourseCount), u(l.StudentCount), u(l.Load), u(l.MaxLoad), l.Status})
+		}
+	default:
+		return usagef("unknown report %q", name)
+	}
+	return e.print(*format, t)
+}
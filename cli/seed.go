@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"exercise1/seed"
+	"fmt"
+)
+
+// seedCommand fills an empty database with generated data, see the seed
+// package. The counts not given are scaled to the number of students.
+func seedCommand(e *env, args []string) error {
+	fs := newFlagSet(e, "seed")
+	seedValue := fs.Int64("seed", 1, "seed of the random data; the same seed generates the same data")
+	students := fs.Int("students", 1000, "number of students")
+	departments := fs.Int("departments", 0, "number of departments, scaled to the students if not given")
+	instructors := fs.Int("instructors", 0, "number of instructors, scaled to the students if not given")
+	courses := fs.Int("courses", 0, "number of courses, scaled to the students if not given")
+	coursesPerStudent := fs.Int("courses-per-student", 0, "average number of courses a student takes, 4 if not given")
+	batchSize := fs.Int("batch-size", 1000, "rows inserted per statement")
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	cfg := seed.Scaled(*students)
+	cfg.Seed = *seedValue
+	cfg.BatchSize = *batchSize
+	for _, count := range []struct {
+		name  string
+		value int
+		field *int
+	}{
+		{"departments", *departments, &cfg.Departments},
+		{"instructors", *instructors, &cfg.Instructors},
+		{"courses", *courses, &cfg.Courses},
+		{"courses-per-student", *coursesPerStudent, &cfg.CoursesPerStudent},
+	} {
+		if isSet(fs, count.name) {
+			*count.field = count.value
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return usagef("%v", err)
+	}
+
+	total := map[string]int{}
+	err := seed.Run(e.ctx, cfg, func(table string, rows int) {
+		total[table] += rows
+		fmt.Fprintf(e.stderr, "%s: %d rows created\n", table, total[table])
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "seeded with seed %d\n", cfg.Seed)
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"exercise1/api"
+	"exercise1/auth"
+	"exercise1/db"
+	"exercise1/grpcserver"
+	"exercise1/logging"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var logger = logging.For("serve")
+
+// shutdownTimeout bounds how long serve waits for running requests once it
+// has been asked to stop.
+const shutdownTimeout = 15 * time.Second
+
+func serveCommand(e *env, args []string) error {
+	fs := newFlagSet(e, "serve")
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	httpAddr, grpcAddr := e.config.HTTPAddr, e.config.GRPCAddr
+	if len(e.config.Auth.TokenSecret) < auth.MinSecretLength {
+		return fmt.Errorf("the auth token secret must be at least %d bytes: set AUTH_TOKEN_SECRET or AUTH_TOKEN_SECRET_FILE", auth.MinSecretLength)
+	}
+	authService := auth.New(e.config.Auth)
+	if tenantId, ok := db.TenantFromContext(e.ctx); ok {
+		authService.Tenant = &tenantId
+	} else if len(db.FindAllTenants(e.ctx)) > 0 {
+		// Unscoped, the server would let the users of every tenant see the
+		// rows of all of them.
+		return errors.New("the database hosts several tenants: set TENANT or --tenant to the one to serve")
+	}
+	if e.config.Encryption.MasterKey == "" {
+		logger.Warn("personal data is stored in plain text; set ENCRYPTION_MASTER_KEY_FILE to encrypt it")
+	}
+	// Row-level security backs up the checks of department admins; a server
+	// whose database user bypasses it would rely on the checks alone.
+	if bypassed, err := db.RowLevelSecurityBypassed(e.ctx); err != nil {
+		return fmt.Errorf("failed to check row-level security: %w", err)
+	} else if bypassed {
+		return errors.New("the database user bypasses row-level security: connect as a role without SUPERUSER or BYPASSRLS, see .env")
+	}
+
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", grpcAddr, err)
+	}
+	grpcServer := grpcserver.NewServer(authService)
+	httpServer := &http.Server{Addr: httpAddr, Handler: api.NewHandler(authService)}
+
+	errs := make(chan error, 2)
+	go func() {
+		logger.Info("gRPC listening", "addr", grpcAddr)
+		errs <- grpcServer.Serve(listener)
+	}()
+	go func() {
+		logger.Info("HTTP listening", "addr", httpAddr)
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	stop, cancel := signal.NotifyContext(e.ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	select {
+	case err = <-errs:
+	case <-stop.Done():
+		logger.Info("shutting down")
+	}
+
+	// Stop accepting requests and let the running ones finish before Run
+	// closes the database.
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if shutdownErr := httpServer.Shutdown(ctx); shutdownErr != nil {
+		logger.Error("HTTP shutdown failed", "error", shutdownErr)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"encoding/json"
+	"exercise1/db"
+	"fmt"
+	"time"
+)
+
+func studentCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"create": createStudent,
+		"list":   listStudents,
+		"get":    getStudent,
+		"update": updateStudent,
+		"delete": deleteStudent,
+		"export": exportStudent,
+		"erase":  eraseStudent,
+	})
+}
+
+func studentsTable(students []db.Student) table {
+	t := table{
+		headers: []string{"ID", "FULL NAME", "AGE", "CITY", "DEPARTMENT", "VERSION"},
+		value:   students,
+	}
+	for _, s := range students {
+		t.rows = append(t.rows, []string{u(s.Id), s.FullName, u(s.Age), s.City, optional(s.DepartmentId), u(s.Version)})
+	}
+	return t
+}
+
+func createStudent(e *env, args []string) error {
+	fs := newFlagSet(e, "student create")
+	name := fs.String("name", "", "full name")
+	age := fs.Uint("age", 0, "age")
+	city := fs.String("city", "", "city")
+	department := fs.Uint("department", 0, "department ID")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	student := db.Student{FullName: *name, Age: *age, City: *city}
+	if isSet(fs, "department") {
+		student.DepartmentId = uintPtr(*department)
+	}
+	student, err := db.CreateStudent(e.ctx, student)
+	if err != nil {
+		return err
+	}
+	return e.print(*format, studentsTable([]db.Student{student}))
+}
+
+func listStudents(e *env, args []string) error {
+	fs := newFlagSet(e, "student list")
+	department := fs.Uint("department", 0, "only students of this department")
+	age := fs.Int("age", 0, "only students of this age")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	var students []db.Student
+	switch {
+	case isSet(fs, "department") && isSet(fs, "age"):
+		for _, student := range db.FindAllStudentsByDepartmentId(e.ctx, *department) {
+			if int(student.Age) == *age {
+				students = append(students, student)
+			}
+		}
+	case isSet(fs, "department"):
+		students = db.FindAllStudentsByDepartmentId(e.ctx, *department)
+	case isSet(fs, "age"):
+		students = db.FindStudentsByAge(e.ctx, *age)
+	default:
+		students = db.FindAllStudents(e.ctx)
+	}
+	return e.print(*format, studentsTable(students))
+}
+
+func getStudent(e *env, args []string) error {
+	fs := newFlagSet(e, "student get")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	student := db.FindStudentById(e.ctx, id)
+	if student.Id == 0 {
+		return notFound("student", id)
+	}
+	return e.print(*format, studentsTable([]db.Student{student}))
+}
+
+func updateStudent(e *env, args []string) error {
+	fs := newFlagSet(e, "student update")
+	age := fs.Int("age", 0, "new age")
+	version := fs.Uint("version", 0, "version the change is based on, fails if the student changed since")
+	format := formatFlag(fs)
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+	if !isSet(fs, "age") {
+		return usagef("nothing to update, only --age can be changed")
+	}
+
+	student := db.FindStudentById(e.ctx, id)
+	if student.Id == 0 {
+		return notFound("student", id)
+	}
+	if isSet(fs, "version") {
+		student.Version = *version
+	}
+	if err := db.UpdateStudentAge(e.ctx, student, *age); err != nil {
+		return err
+	}
+	return e.print(*format, studentsTable([]db.Student{db.FindStudentById(e.ctx, id)}))
+}
+
+func deleteStudent(e *env, args []string) error {
+	fs := newFlagSet(e, "student delete")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	student := db.FindStudentById(e.ctx, id)
+	if student.Id == 0 {
+		return notFound("student", id)
+	}
+	return db.DeleteStudent(e.ctx, student)
+}
+
+// exportStudent writes everything stored about the student as JSON, to hand
+// to them when they ask for their data.
+func exportStudent(e *env, args []string) error {
+	fs := newFlagSet(e, "student export")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if student := db.FindStudentById(e.ctx, id); student.Id == 0 {
+		return notFound("student", id)
+	}
+	export, err := db.ExportStudent(e.ctx, uint(id))
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(e.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// eraseStudent anonymises the student for good, so it has to be confirmed
+// with --yes.
+func eraseStudent(e *env, args []string) error {
+	fs := newFlagSet(e, "student erase")
+	yes := fs.Bool("yes", false, "confirm erasing the personal data of the student")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		return usagef("erasing student %d cannot be undone; confirm with --yes", id)
+	}
+
+	if student := db.FindStudentById(e.ctx, id); student.Id == 0 {
+		return notFound("student", id)
+	}
+	if err := db.EraseStudent(e.ctx, uint(id), time.Now()); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "erased student %d\n", id)
+	return nil
+}
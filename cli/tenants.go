@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"exercise1/db"
+	"fmt"
+)
+
+func tenantCommand(e *env, args []string) error {
+	return subcommand(e, args, map[string]command{
+		"create": createTenant,
+		"list":   listTenants,
+		"delete": deleteTenant,
+	})
+}
+
+func tenantsTable(tenants []db.Tenant) table {
+	t := table{
+		headers: []string{"ID", "SLUG", "NAME", "CREATED"},
+		value:   tenants,
+	}
+	for _, tenant := range tenants {
+		t.rows = append(t.rows, []string{u(tenant.Id), tenant.Slug, tenant.Name, timestamp(&tenant.CreatedAt)})
+	}
+	return t
+}
+
+// withTenant scopes ctx to the tenant with slug, which must exist.
+func withTenant(ctx context.Context, slug string) (context.Context, error) {
+	tenant := db.FindTenantBySlug(ctx, slug)
+	if tenant.Id == 0 {
+		return nil, fmt.Errorf("unknown tenant %q; create it with \"tenant create\"", slug)
+	}
+	return db.WithTenant(ctx, tenant.Id), nil
+}
+
+// createTenant provisions a university. With --adopt, the rows that belong
+// to no tenant, e.g. those of a database from before multi-tenant mode, are
+// assigned to it.
+func createTenant(e *env, args []string) error {
+	fs := newFlagSet(e, "tenant create")
+	slug := fs.String("slug", "", "short name selecting the tenant with --tenant, e.g. north-campus")
+	name := fs.String("name", "", "name of the university")
+	adopt := fs.Bool("adopt", false, "assign the rows that belong to no tenant to the new one")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+
+	tenant, err := db.CreateTenant(e.ctx, db.Tenant{Slug: *slug, Name: *name})
+	if err != nil {
+		return err
+	}
+	if *adopt {
+		if err := db.AdoptUnassignedRows(e.ctx, tenant.Id); err != nil {
+			return fmt.Errorf("created tenant %d, but failed to assign the existing rows to it: %w", tenant.Id, err)
+		}
+	}
+	return e.print(*format, tenantsTable([]db.Tenant{tenant}))
+}
+
+func listTenants(e *env, args []string) error {
+	fs := newFlagSet(e, "tenant list")
+	format := formatFlag(fs)
+	if err := parseNoArgs(fs, args); err != nil {
+		return err
+	}
+	return e.print(*format, tenantsTable(db.FindAllTenants(e.ctx)))
+}
+
+// deleteTenant deletes a university with all of its students, courses,
+// users and so on, so it has to be confirmed with --yes.
+func deleteTenant(e *env, args []string) error {
+	fs := newFlagSet(e, "tenant delete")
+	yes := fs.Bool("yes", false, "confirm deleting the tenant and all of its rows")
+	id, err := parseWithId(fs, args)
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		return usagef("deleting tenant %d deletes all of its rows; confirm with --yes", id)
+	}
+
+	if tenant := db.FindTenantById(e.ctx, id); tenant.Id == 0 {
+		return notFound("tenant", id)
+	}
+	return db.DeleteTenant(e.ctx, uint(id))
+}
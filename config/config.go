@@ -0,0 +1,490 @@
+// Package config loads the settings of the binary. Sources are applied in
+// this order, later ones overriding earlier ones:
+//
+//  1. built-in defaults
+//  2. the JSON config file named by --config or CONFIG_FILE
+//  3. environment variables, including those from an optional .env file
+//  4. command-line flags
+//
+// Secrets can be read from files (DB_PASSWORD_FILE, AUTH_TOKEN_SECRET_FILE,
+// ENCRYPTION_MASTER_KEY_FILE) instead of being placed in the environment.
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Database struct {
+	Host         string `json:"host"`
+	Port         string `json:"port"`
+	User         string `json:"user"`
+	Password     string `json:"password"`
+	PasswordFile string `json:"passwordFile"`
+	Name         string `json:"name"`
+	SSLMode      string `json:"sslMode"`
+	TimeZone     string `json:"timeZone"`
+
+	MaxOpenConns    int      `json:"maxOpenConns"`
+	MaxIdleConns    int      `json:"maxIdleConns"`
+	ConnMaxLifetime Duration `json:"connMaxLifetime"`
+	ConnMaxIdleTime Duration `json:"connMaxIdleTime"`
+	ConnectAttempts int      `json:"connectAttempts"`
+	ConnectBackoff  Duration `json:"connectBackoff"`
+
+	// SlowQueryThreshold is how long a statement may take before it is
+	// logged as slow; 0 disables the check.
+	SlowQueryThreshold Duration `json:"slowQueryThreshold"`
+}
+
+// Duration is a time.Duration written as e.g. "30s" in the config file.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Tracing selects where spans are exported to.
+type Tracing struct {
+	// Exporter is "none", "stdout" or "otlp".
+	Exporter string `json:"exporter"`
+	// OTLPEndpoint is the host:port of the OTLP gRPC collector. When empty
+	// the exporter falls back to OTEL_EXPORTER_OTLP_ENDPOINT and then to
+	// localhost:4317.
+	OTLPEndpoint string `json:"otlpEndpoint"`
+	OTLPInsecure bool   `json:"otlpInsecure"`
+}
+
+// Logging configures the structured logs written to stderr.
+type Logging struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string `json:"level"`
+	// Levels overrides Level per component, e.g. "db=debug,api=warn".
+	Levels string `json:"levels"`
+	// Format is "text" or "json".
+	Format string `json:"format"`
+}
+
+// ComponentLevels parses Levels into the level of each component.
+func (l Logging) ComponentLevels() (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	for _, entry := range strings.Split(l.Levels, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		component, name, ok := strings.Cut(entry, "=")
+		if !ok || component == "" {
+			return nil, fmt.Errorf("log level %q is not of the form component=level", entry)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return nil, fmt.Errorf("log level of %s: %w", component, err)
+		}
+		levels[strings.TrimSpace(component)] = level
+	}
+	return levels, nil
+}
+
+// Auth configures the sessions of API users.
+type Auth struct {
+	// TokenSecret signs the access tokens; serve requires at least 32 bytes.
+	TokenSecret     string `json:"tokenSecret"`
+	TokenSecretFile string `json:"tokenSecretFile"`
+
+	AccessTokenTTL  Duration `json:"accessTokenTTL"`
+	RefreshTokenTTL Duration `json:"refreshTokenTTL"`
+	ResetTokenTTL   Duration `json:"resetTokenTTL"`
+
+	// MaxFailedLogins in a row lock an account for LockoutDuration.
+	MaxFailedLogins int      `json:"maxFailedLogins"`
+	LockoutDuration Duration `json:"lockoutDuration"`
+
+	// APIKeyRateLimit is the number of requests per minute of API keys
+	// without a limit of their own. A rotated key keeps working for
+	// APIKeyRotationGrace.
+	APIKeyRateLimit     int      `json:"apiKeyRateLimit"`
+	APIKeyRotationGrace Duration `json:"apiKeyRotationGrace"`
+}
+
+// Encryption configures the encryption of personal data in the database.
+type Encryption struct {
+	// MasterKey is the base64 encoded 32-byte key the keys encrypting the
+	// data are encrypted with. Without it personal data is stored in plain
+	// text.
+	MasterKey     string `json:"masterKey"`
+	MasterKeyFile string `json:"masterKeyFile"`
+}
+
+// Key returns the decoded master key, nil when none is set.
+func (e Encryption) Key() ([]byte, error) {
+	if e.MasterKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(e.MasterKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("the encryption master key must be 32 bytes, base64 encoded, e.g. from \"openssl rand -base64 32\"")
+	}
+	return key, nil
+}
+
+type Config struct {
+	Database   Database   `json:"database"`
+	Logging    Logging    `json:"logging"`
+	Tracing    Tracing    `json:"tracing"`
+	Auth       Auth       `json:"auth"`
+	Encryption Encryption `json:"encryption"`
+	HTTPAddr   string     `json:"httpAddr"`
+	GRPCAddr   string     `json:"grpcAddr"`
+	// Tenant is the slug of the university the commands and servers work
+	// on when the database hosts several; empty for a single one.
+	Tenant string `json:"tenant"`
+}
+
+func Defaults() Config {
+	return Config{
+		Database: Database{
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			SSLMode:  "disable",
+			TimeZone: "UTC",
+
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+			ConnectAttempts: 5,
+			ConnectBackoff:  Duration(500 * time.Millisecond),
+
+			SlowQueryThreshold: Duration(200 * time.Millisecond),
+		},
+		Logging: Logging{Level: "info", Format: "text"},
+		Tracing: Tracing{Exporter: "none"},
+		Auth: Auth{
+			AccessTokenTTL:  Duration(15 * time.Minute),
+			RefreshTokenTTL: Duration(30 * 24 * time.Hour),
+			ResetTokenTTL:   Duration(time.Hour),
+			MaxFailedLogins: 5,
+			LockoutDuration: Duration(15 * time.Minute),
+
+			APIKeyRateLimit:     600,
+			APIKeyRotationGrace: Duration(24 * time.Hour),
+		},
+		HTTPAddr: ":8080",
+		GRPCAddr: ":9090",
+	}
+}
+
+// setting ties one field to its environment variable and flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	field func(c *Config) interface{}
+}
+
+var settings = []setting{
+	{"DB_HOST", "db-host", "database host", func(c *Config) interface{} { return &c.Database.Host }},
+	{"DB_PORT", "db-port", "database port", func(c *Config) interface{} { return &c.Database.Port }},
+	{"DB_USER", "db-user", "database user", func(c *Config) interface{} { return &c.Database.User }},
+	{"DB_PASSWORD", "", "", func(c *Config) interface{} { return &c.Database.Password }},
+	{"DB_PASSWORD_FILE", "db-password-file", "file containing the database password", func(c *Config) interface{} { return &c.Database.PasswordFile }},
+	{"DB_NAME", "db-name", "database name", func(c *Config) interface{} { return &c.Database.Name }},
+	{"SSL_MODE", "db-sslmode", "database SSL mode", func(c *Config) interface{} { return &c.Database.SSLMode }},
+	{"TIME_ZONE", "db-timezone", "database session time zone", func(c *Config) interface{} { return &c.Database.TimeZone }},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum number of open database connections", func(c *Config) interface{} { return &c.Database.MaxOpenConns }},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum number of idle database connections", func(c *Config) interface{} { return &c.Database.MaxIdleConns }},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum lifetime of a database connection", func(c *Config) interface{} { return &c.Database.ConnMaxLifetime }},
+	{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum idle time of a database connection", func(c *Config) interface{} { return &c.Database.ConnMaxIdleTime }},
+	{"DB_CONNECT_ATTEMPTS", "db-connect-attempts", "number of attempts to connect to the database at startup", func(c *Config) interface{} { return &c.Database.ConnectAttempts }},
+	{"DB_CONNECT_BACKOFF", "db-connect-backoff", "wait after the first failed connection attempt, doubled after each further one", func(c *Config) interface{} { return &c.Database.ConnectBackoff }},
+	{"DB_SLOW_QUERY_THRESHOLD", "db-slow-query-threshold", "duration after which statements are logged as slow, 0 to disable", func(c *Config) interface{} { return &c.Database.SlowQueryThreshold }},
+	{"LOG_LEVEL", "log-level", "minimum log level: debug, info, warn or error", func(c *Config) interface{} { return &c.Logging.Level }},
+	{"LOG_LEVELS", "log-levels", "log levels per component, e.g. db=debug,api=warn", func(c *Config) interface{} { return &c.Logging.Levels }},
+	{"LOG_FORMAT", "log-format", "log format: text or json", func(c *Config) interface{} { return &c.Logging.Format }},
+	{"TRACING_EXPORTER", "tracing-exporter", "where to export traces: none, stdout or otlp", func(c *Config) interface{} { return &c.Tracing.Exporter }},
+	{"OTLP_ENDPOINT", "otlp-endpoint", "host:port of the OTLP trace collector", func(c *Config) interface{} { return &c.Tracing.OTLPEndpoint }},
+	{"OTLP_INSECURE", "otlp-insecure", "connect to the OTLP collector without TLS", func(c *Config) interface{} { return &c.Tracing.OTLPInsecure }},
+	{"AUTH_TOKEN_SECRET", "", "", func(c *Config) interface{} { return &c.Auth.TokenSecret }},
+	{"AUTH_TOKEN_SECRET_FILE", "auth-token-secret-file", "file containing the secret signing access tokens", func(c *Config) interface{} { return &c.Auth.TokenSecretFile }},
+	{"AUTH_ACCESS_TOKEN_TTL", "auth-access-token-ttl", "lifetime of access tokens", func(c *Config) interface{} { return &c.Auth.AccessTokenTTL }},
+	{"AUTH_REFRESH_TOKEN_TTL", "auth-refresh-token-ttl", "lifetime of refresh tokens", func(c *Config) interface{} { return &c.Auth.RefreshTokenTTL }},
+	{"AUTH_RESET_TOKEN_TTL", "auth-reset-token-ttl", "lifetime of password reset tokens", func(c *Config) interface{} { return &c.Auth.ResetTokenTTL }},
+	{"AUTH_MAX_FAILED_LOGINS", "auth-max-failed-logins", "failed logins in a row after which an account is locked", func(c *Config) interface{} { return &c.Auth.MaxFailedLogins }},
+	{"AUTH_LOCKOUT_DURATION", "auth-lockout-duration", "how long a locked account stays locked", func(c *Config) interface{} { return &c.Auth.LockoutDuration }},
+	{"AUTH_API_KEY_RATE_LIMIT", "auth-api-key-rate-limit", "requests per minute of API keys without a limit of their own", func(c *Config) interface{} { return &c.Auth.APIKeyRateLimit }},
+	{"AUTH_API_KEY_ROTATION_GRACE", "auth-api-key-rotation-grace", "how long a rotated API key keeps working", func(c *Config) interface{} { return &c.Auth.APIKeyRotationGrace }},
+	{"ENCRYPTION_MASTER_KEY", "", "", func(c *Config) interface{} { return &c.Encryption.MasterKey }},
+	{"ENCRYPTION_MASTER_KEY_FILE", "encryption-master-key-file", "file containing the key encrypting personal data", func(c *Config) interface{} { return &c.Encryption.MasterKeyFile }},
+	{"HTTP_ADDR", "http-addr", "address of the HTTP API", func(c *Config) interface{} { return &c.HTTPAddr }},
+	{"GRPC_ADDR", "grpc-addr", "address of the gRPC service", func(c *Config) interface{} { return &c.GRPCAddr }},
+	{"TENANT", "tenant", "slug of the university to work on when the database hosts several", func(c *Config) interface{} { return &c.Tenant }},
+}
+
+// Load builds the configuration from all sources. envPrefix is prepended to
+// every environment variable name, e.g. "TEST_" for the test database.
+// Flags are parsed from args up to the first non-flag argument; the
+// remaining arguments are returned.
+func Load(args []string, envPrefix string) (Config, []string, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf("failed to read .env file: %w", err)
+	}
+
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(envPrefix+"CONFIG_FILE"), "JSON config file")
+	flagValues := map[string]*string{}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		value := new(string)
+		if _, ok := s.field(&Config{}).(*bool); ok {
+			flags.Var(boolFlag{value}, s.flag, s.usage)
+		} else {
+			flags.StringVar(value, s.flag, "", s.usage)
+		}
+		flagValues[s.flag] = value
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	config := Defaults()
+	if *configFile != "" {
+		if err := loadFile(&config, *configFile); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(envPrefix + s.env); ok && value != "" {
+			if err := set(s.field(&config), value); err != nil {
+				return Config{}, nil, fmt.Errorf("invalid %s%s: %w", envPrefix, s.env, err)
+			}
+		}
+	}
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		if value, ok := flagValues[f.Name]; ok {
+			for _, s := range settings {
+				if s.flag == f.Name {
+					if err := set(s.field(&config), *value); err != nil && flagErr == nil {
+						flagErr = fmt.Errorf("invalid --%s: %w", s.flag, err)
+					}
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, nil, flagErr
+	}
+
+	if err := config.resolveSecrets(); err != nil {
+		return Config{}, nil, err
+	}
+	if err := config.Validate(envPrefix); err != nil {
+		return Config{}, nil, err
+	}
+	return config, flags.Args(), nil
+}
+
+// boolFlag lets boolean settings be given as a bare --flag, keeping the
+// value as text for set like the other flags.
+type boolFlag struct {
+	value *string
+}
+
+func (f boolFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return *f.value
+}
+
+func (f boolFlag) Set(value string) error {
+	*f.value = value
+	return nil
+}
+
+func (boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+// set parses value into the field of a setting.
+func set(field interface{}, value string) error {
+	switch field := field.(type) {
+	case *string:
+		*field = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*field = b
+	case *Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s", value)
+		}
+		*field = Duration(d)
+	default:
+		panic(fmt.Sprintf("config: unsupported setting type %T", field))
+	}
+	return nil
+}
+
+func loadFile(config *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// resolveSecrets replaces secrets given as file paths with the contents of
+// those files. A secret file wins over a plain secret.
+func (c *Config) resolveSecrets() error {
+	if c.Database.PasswordFile != "" {
+		data, err := os.ReadFile(c.Database.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read database password file: %w", err)
+		}
+		c.Database.Password = strings.TrimRight(string(data), "\r\n")
+	}
+	if c.Auth.TokenSecretFile != "" {
+		data, err := os.ReadFile(c.Auth.TokenSecretFile)
+		if err != nil {
+			return fmt.Errorf("failed to read auth token secret file: %w", err)
+		}
+		c.Auth.TokenSecret = strings.TrimRight(string(data), "\r\n")
+	}
+	if c.Encryption.MasterKeyFile != "" {
+		data, err := os.ReadFile(c.Encryption.MasterKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read encryption master key file: %w", err)
+		}
+		c.Encryption.MasterKey = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// Validate reports every missing or malformed setting at once, naming where
+// it can be set.
+func (c Config) Validate(envPrefix string) error {
+	var problems []string
+	for _, s := range settings {
+		required := s.env == "DB_HOST" || s.env == "DB_PORT" || s.env == "DB_USER" || s.env == "DB_NAME"
+		if value, ok := s.field(&c).(*string); ok && required && *value == "" {
+			problems = append(problems, fmt.Sprintf("%s is required: set %s%s or --%s", s.usage, envPrefix, s.env, s.flag))
+		}
+	}
+	if c.Database.Port != "" {
+		if port, err := strconv.Atoi(c.Database.Port); err != nil || port <= 0 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("database port %q is not a valid port number", c.Database.Port))
+		}
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		problems = append(problems, "database connection limits must not be negative")
+	}
+	if c.Database.ConnectAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("the database must be tried at least once: set %sDB_CONNECT_ATTEMPTS or --db-connect-attempts to 1 or more", envPrefix))
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 || c.Database.ConnectBackoff < 0 || c.Database.SlowQueryThreshold < 0 {
+		problems = append(problems, "database durations must not be negative")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("log level %q is not one of debug, info, warn or error", c.Logging.Level))
+	}
+	if _, err := c.Logging.ComponentLevels(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.Logging.Format != "text" && c.Logging.Format != "json" {
+		problems = append(problems, fmt.Sprintf("log format %q is not one of text or json", c.Logging.Format))
+	}
+	if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= 0 || c.Auth.ResetTokenTTL <= 0 || c.Auth.LockoutDuration <= 0 {
+		problems = append(problems, "auth token lifetimes and the lockout duration must be positive")
+	}
+	if c.Auth.MaxFailedLogins < 1 {
+		problems = append(problems, fmt.Sprintf("accounts must allow at least one failed login: set %sAUTH_MAX_FAILED_LOGINS or --auth-max-failed-logins to 1 or more", envPrefix))
+	}
+	if c.Auth.APIKeyRateLimit < 1 {
+		problems = append(problems, fmt.Sprintf("API keys must allow at least one request per minute: set %sAUTH_API_KEY_RATE_LIMIT or --auth-api-key-rate-limit to 1 or more", envPrefix))
+	}
+	if c.Auth.APIKeyRotationGrace < 0 {
+		problems = append(problems, "the API key rotation grace period must not be negative")
+	}
+	if _, err := c.Encryption.Key(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, fmt.Sprintf("tracing exporter %q is not one of none, stdout or otlp", c.Tracing.Exporter))
+	}
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// DSN returns the connection string in the key=value format of libpq,
+// quoting values so spaces, quotes and backslashes in e.g. the password
+// cannot break it.
+func (d Database) DSN() string {
+	parts := []string{
+		"host=" + quote(d.Host),
+		"user=" + quote(d.User),
+		"password=" + quote(d.Password),
+		"dbname=" + quote(d.Name),
+		"port=" + quote(d.Port),
+		"sslmode=" + quote(d.SSLMode),
+		"TimeZone=" + quote(d.TimeZone),
+	}
+	return strings.Join(parts, " ")
+}
+
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\\t\n") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.json", `{"database": {"host": "file-host", "user": "file-user", "name": "file-db"}, "httpAddr": ":1000"}`)
+	t.Setenv("APP_CONFIG_FILE", file)
+	t.Setenv("APP_DB_USER", "env-user")
+	t.Setenv("APP_HTTP_ADDR", ":2000")
+	t.Setenv("APP_TENANT", "north-campus")
+
+	config, rest, err := Load([]string{"--http-addr", ":3000", "student", "list"}, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if config.Database.Port != "5432" {
+		t.Errorf("expected default port, got %s", config.Database.Port)
+	}
+	if config.Database.Host != "file-host" {
+		t.Errorf("expected host from file, got %s", config.Database.Host)
+	}
+	if config.Database.User != "env-user" {
+		t.Errorf("expected env to override file, got %s", config.Database.User)
+	}
+	if config.HTTPAddr != ":3000" {
+		t.Errorf("expected flag to override env, got %s", config.HTTPAddr)
+	}
+	if config.Tenant != "north-campus" {
+		t.Errorf("expected tenant from env, got %s", config.Tenant)
+	}
+	if strings.Join(rest, " ") != "student list" {
+		t.Errorf("expected remaining args to be returned, got %v", rest)
+	}
+}
+
+func TestLoadPasswordFile(t *testing.T) {
+	t.Setenv("APP_DB_NAME", "university")
+	t.Setenv("APP_DB_PASSWORD", "plain")
+	t.Setenv("APP_DB_PASSWORD_FILE", writeFile(t, "password", "s3cret\n"))
+
+	config, _, err := Load(nil, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config.Database.Password != "s3cret" {
+		t.Errorf("expected password from file, got %q", config.Database.Password)
+	}
+}
+
+func TestLoadReportsMissingSettings(t *testing.T) {
+	t.Setenv("APP_DB_PORT", "not-a-port")
+
+	_, _, err := Load(nil, "APP_")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, expected := range []string{"APP_DB_NAME", "--db-name", `"not-a-port"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to mention %s, got %v", expected, err)
+		}
+	}
+}
+
+func TestLoadPoolSettings(t *testing.T) {
+	file := writeFile(t, "config.json", `{"database": {"name": "university", "connMaxLifetime": "1h", "maxOpenConns": 50}}`)
+	t.Setenv("APP_CONFIG_FILE", file)
+	t.Setenv("APP_DB_CONNECT_BACKOFF", "2s")
+
+	config, _, err := Load([]string{"--db-max-open-conns", "20"}, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if time.Duration(config.Database.ConnMaxLifetime) != time.Hour {
+		t.Errorf("expected lifetime from file, got %v", config.Database.ConnMaxLifetime)
+	}
+	if time.Duration(config.Database.ConnectBackoff) != 2*time.Second {
+		t.Errorf("expected backoff from env, got %v", config.Database.ConnectBackoff)
+	}
+	if config.Database.MaxOpenConns != 20 {
+		t.Errorf("expected flag to override file, got %d", config.Database.MaxOpenConns)
+	}
+	if config.Database.ConnectAttempts != 5 {
+		t.Errorf("expected default attempts, got %d", config.Database.ConnectAttempts)
+	}
+}
+
+func TestLoadRejectsMalformedPoolSettings(t *testing.T) {
+	t.Setenv("APP_DB_NAME", "university")
+	t.Setenv("APP_DB_CONN_MAX_IDLE_TIME", "5 minutes")
+
+	_, _, err := Load(nil, "APP_")
+	if err == nil || !strings.Contains(err.Error(), "APP_DB_CONN_MAX_IDLE_TIME") {
+		t.Fatalf("expected error naming the variable, got %v", err)
+	}
+}
+
+func TestLoadTracingSettings(t *testing.T) {
+	t.Setenv("APP_DB_NAME", "university")
+	t.Setenv("APP_OTLP_ENDPOINT", "collector:4317")
+
+	config, rest, err := Load([]string{"--tracing-exporter", "otlp", "--otlp-insecure", "serve"}, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config.Tracing != (Tracing{Exporter: "otlp", OTLPEndpoint: "collector:4317", OTLPInsecure: true}) {
+		t.Errorf("unexpected tracing settings %+v", config.Tracing)
+	}
+	if len(rest) != 1 || rest[0] != "serve" {
+		t.Errorf("expected a bare boolean flag not to consume the command, got %v", rest)
+	}
+
+	if _, _, err := Load([]string{"--tracing-exporter", "zipkin"}, "APP_"); err == nil || !strings.Contains(err.Error(), "zipkin") {
+		t.Errorf("expected unknown exporter to be rejected, got %v", err)
+	}
+}
+
+func TestLoadLoggingSettings(t *testing.T) {
+	t.Setenv("APP_DB_NAME", "university")
+	t.Setenv("APP_LOG_LEVELS", "db=debug, api=warn")
+
+	config, _, err := Load([]string{"--log-format", "json"}, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	levels, err := config.Logging.ComponentLevels()
+	if err != nil || len(levels) != 2 || levels["db"] != slog.LevelDebug || levels["api"] != slog.LevelWarn {
+		t.Errorf("unexpected component levels %v, %v", levels, err)
+	}
+
+	t.Setenv("APP_LOG_LEVELS", "db=loud")
+	if _, _, err := Load(nil, "APP_"); err == nil || !strings.Contains(err.Error(), "log level of db") {
+		t.Errorf("expected unknown level to be rejected, got %v", err)
+	}
+}
+
+func TestLoadAuthSettings(t *testing.T) {
+	t.Setenv("APP_DB_NAME", "university")
+	t.Setenv("APP_AUTH_TOKEN_SECRET", "plain")
+	t.Setenv("APP_AUTH_TOKEN_SECRET_FILE", writeFile(t, "secret", "from-file\n"))
+
+	config, _, err := Load([]string{"--auth-access-token-ttl", "5m"}, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config.Auth.TokenSecret != "from-file" {
+		t.Errorf("expected secret from file, got %q", config.Auth.TokenSecret)
+	}
+	if time.Duration(config.Auth.AccessTokenTTL) != 5*time.Minute || config.Auth.MaxFailedLogins != 5 {
+		t.Errorf("unexpected auth settings %+v", config.Auth)
+	}
+
+	if _, _, err := Load([]string{"--auth-max-failed-logins", "0"}, "APP_"); err == nil || !strings.Contains(err.Error(), "AUTH_MAX_FAILED_LOGINS") {
+		t.Errorf("expected zero failed logins to be rejected, got %v", err)
+	}
+	if _, _, err := Load([]string{"--auth-api-key-rate-limit", "0"}, "APP_"); err == nil || !strings.Contains(err.Error(), "AUTH_API_KEY_RATE_LIMIT") {
+		t.Errorf("expected a zero API key rate limit to be rejected, got %v", err)
+	}
+}
+
+func TestLoadEncryptionKey(t *testing.T) {
+	t.Setenv("APP_DB_NAME", "university")
+	t.Setenv("APP_ENCRYPTION_MASTER_KEY_FILE", writeFile(t, "master.key", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"))
+
+	config, _, err := Load(nil, "APP_")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if key, err := config.Encryption.Key(); err != nil || string(key) != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("expected the decoded key from file, got %q, %v", key, err)
+	}
+
+	t.Setenv("APP_ENCRYPTION_MASTER_KEY_FILE", writeFile(t, "short.key", "c2hvcnQ="))
+	if _, _, err := Load(nil, "APP_"); err == nil || !strings.Contains(err.Error(), "encryption master key") {
+		t.Errorf("expected a short key to be rejected, got %v", err)
+	}
+}
+
+func TestDSNQuotesSpecialCharacters(t *testing.T) {
+	d := Database{Host: "localhost", User: "postgres", Password: `it's a \secret`, Name: "university", Port: "5432", SSLMode: "disable", TimeZone: "Asia/Almaty"}
+
+	expected := `host=localhost user=postgres password='it\'s a \\secret' dbname=university port=5432 sslmode=disable TimeZone=Asia/Almaty`
+	if dsn := d.DSN(); dsn != expected {
+		t.Fatalf("expected %s, got %s", expected, dsn)
+	}
+}
+
+func TestDSNQuotesEmptyValues(t *testing.T) {
+	d := Database{Host: "localhost", User: "postgres", Name: "university", Port: "5432", SSLMode: "disable", TimeZone: "UTC"}
+	if dsn := d.DSN(); !strings.Contains(dsn, "password='' ") {
+		t.Fatalf("expected empty password to be quoted, got %s", dsn)
+	}
+}
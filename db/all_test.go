@@ -1,533 +1,1517 @@
-package db
+package db_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"exercise1/config"
+	"exercise1/db"
+	"exercise1/db/dbtest"
+	"exercise1/logging"
+	"exercise1/validation"
 	"fmt"
-	"log"
+	"math"
 	"os"
+	"strings"
 	"testing"
-
-	"github.com/joho/godotenv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
 )
 
-func setupSuite(tb testing.TB) func(tb testing.TB) {
-	log.Println("setup suite")
+// The tests run in the transactions of dbtest fixtures, most of them in
+// parallel. Those changing the state of the process, such as the tracer
+// provider, the logger, the metrics or the encryption keys, do not.
 
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("There's no .env file in directory! %v", err)
-	}
-	host := os.Getenv("TEST_DB_HOST")
-	user := os.Getenv("TEST_DB_USER")
-	password := os.Getenv("TEST_DB_PASSWORD")
-	dbname := os.Getenv("TEST_DB_NAME")
-	port := os.Getenv("TEST_DB_PORT")
-	sslmode := os.Getenv("TEST_SSL_MODE")
-	timeZone := os.Getenv("TEST_TIME_ZONE")
+// missingId is the id of no row.
+const missingId = math.MaxInt32
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s timeZone=%s", host, user, password, dbname, port, sslmode, timeZone)
+func uintPtr(v uint) *uint {
+	return &v
+}
 
-	Connect(dsn)
+var studentsInput = []struct {
+	db.Student
+	department int
+}{
+	{db.Student{FullName: "Askar Bekbergen", Age: 20, City: "Almaty"}, 0},
+	{db.Student{FullName: "Ramazan Mamyrbek", Age: 20, City: "Turkistan"}, 0},
+	{db.Student{FullName: "Nurdaulet Agabek", Age: 19, City: "Kaskelen"}, 1},
+	{db.Student{FullName: "Asset Tagvay", Age: 19, City: "New York"}, 1},
+}
 
-	fmt.Println(dsn)
+var instructorsInput = []struct {
+	db.Instructor
+	department int
+}{
+	{db.Instructor{FullName: "Azamat Serek", Age: 28}, 0},
+	{db.Instructor{FullName: "Nurbol Sabitov", Age: 25}, 0},
+	{db.Instructor{FullName: "Alisher Duzmagambetov", Age: 24}, 1},
+	{db.Instructor{FullName: "Sufyan Mustafa", Age: 30}, 0},
+}
 
-	MigrateAllTables()
+var coursesInput = []struct {
+	db.Course
+	department, instructor int
+}{
+	{db.Course{Name: "The Go programming language"}, 0, 0},
+	{db.Course{Name: "Frontend Development"}, 0, 1},
+	{db.Course{Name: "The Virtualization"}, 0, 0},
+	{db.Course{Name: "The Fundamentals of Programming"}, 0, 2},
+	{db.Course{Name: "Culturology"}, 3, 3},
+}
 
-	return func(tb testing.TB) {
-		log.Println("teardown suite")
+// enrollmentsInput holds the indexes of the students and their courses.
+var enrollmentsInput = []struct {
+	student, course int
+}{
+	{0, 1}, {0, 2}, {2, 1}, {2, 3}, {2, 4}, {1, 0}, {1, 1}, {1, 2},
+}
 
-		var tableNames []string
-		rows, err := db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = 'public'").Rows()
-		if err != nil {
-			log.Fatalf("Error retrieving table names: %v", err)
-		}
-		defer rows.Close()
+// university holds the rows the tests start from, created in the transaction
+// of f from the inputs above.
+type university struct {
+	f           *dbtest.Fixture
+	departments []db.Department
+	students    []db.Student
+	instructors []db.Instructor
+	courses     []db.Course
+}
 
-		for rows.Next() {
-			var tableName string
-			if err := rows.Scan(&tableName); err != nil {
-				log.Fatalf("Error scanning table name: %v", err)
-			}
-			tableNames = append(tableNames, tableName)
-		}
+// createUniversity creates four departments.
+func createUniversity(f *dbtest.Fixture) *university {
+	u := &university{f: f}
+	for i := 0; i < 4; i++ {
+		u.departments = append(u.departments, f.Department())
+	}
+	return u
+}
 
-		for _, tableName := range tableNames {
-			if err := db.Exec(fmt.Sprintf("DROP TABLE %s CASCADE", tableName)).Error; err != nil {
-				log.Printf("Error dropping table %s: %v", tableName, err)
-			} else {
-				log.Printf("Table %s dropped successfully", tableName)
-			}
-		}
+func (u *university) withStudents() *university {
+	for _, input := range studentsInput {
+		student := input.Student
+		student.DepartmentId = &u.departments[input.department].Id
+		u.students = append(u.students, u.f.Student(func(s *db.Student) { *s = student }))
 	}
+	return u
 }
 
-var departmentsInput = []Department{
-	{
-		Name: "Engineering and Natural Sciences",
-	},
-	{
-		Name: "Pedagogical and humanitarian sciences",
-	},
-	{
-		Name: "Business school",
-	},
-	{
-		Name: "Law and social sciences and humanities",
-	},
+func (u *university) withInstructors() *university {
+	for _, input := range instructorsInput {
+		instructor := input.Instructor
+		instructor.DepartmentId = u.departments[input.department].Id
+		u.instructors = append(u.instructors, u.f.Instructor(func(i *db.Instructor) { *i = instructor }))
+	}
+	return u
+}
+
+// withCourses needs the instructors.
+func (u *university) withCourses() *university {
+	for _, input := range coursesInput {
+		course := input.Course
+		course.DepartmentId = u.departments[input.department].Id
+		course.InstructorId = &u.instructors[input.instructor].Id
+		u.courses = append(u.courses, u.f.Course(func(c *db.Course) { *c = course }))
+	}
+	return u
 }
 
-func createDepartments() {
-	for _, department := range departmentsInput {
-		CreateDepartment(department)
+// withEnrollments needs the students and the courses.
+func (u *university) withEnrollments() *university {
+	for _, enrollment := range enrollmentsInput {
+		u.f.Enroll(u.students[enrollment.student], u.courses[enrollment.course])
 	}
+	return u
 }
 
 func TestDepartmentCreate(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createDepartments()
+	names := []string{"Engineering and Natural Sciences", "Pedagogical and humanitarian sciences", "Business school", "Law and social sciences and humanities"}
+	for _, name := range names {
+		if _, err := db.CreateDepartment(f.Ctx, db.Department{Name: name}); err != nil {
+			t.Fatalf("Creating department %s failed: %v", name, err)
+		}
+	}
+
+	if actual := len(db.FindAllDepartments(f.Ctx)); actual != len(names) {
+		t.Fatalf("After insert expected %d departments. Found %d!", len(names), actual)
+	}
+}
 
-	expected := len(departmentsInput)
+func TestDepartmentUpdate(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	actualDepartments := FindAllDepartments()
+	department := f.Department()
+	updatedDepartmentName := "Pedagogy"
+	if err := db.UpdateDepartment(f.Ctx, department, db.Department{Name: updatedDepartmentName}); err != nil {
+		t.Fatalf("Updating department %d failed: %v", department.Id, err)
+	}
 
-	if actual := len(actualDepartments); expected != actual {
-		log.Fatalf("After insert expected %d departments. Found %d!", expected, actual)
+	if department = db.FindDepartmentById(f.Ctx, int(department.Id)); department.Name != updatedDepartmentName {
+		t.Fatalf("After updating department name expected: %s, but actual: %s", updatedDepartmentName, department.Name)
 	}
 }
 
-func TestDepartmentUpdate(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+func TestDepartmentUpdateConflict(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createDepartments()
+	staleDepartment := f.Department()
 
-	departmentIdToUpdate := 1
-	updatedDepartmentName := "Pedagogy"
-	department := FindDepartmentById(departmentIdToUpdate)
-	departmentUpdatedFields := Department{
-		Name: updatedDepartmentName,
+	if err := db.UpdateDepartment(f.Ctx, staleDepartment, db.Department{Name: "Humanities"}); err != nil {
+		t.Fatalf("First update of department %d expected to succeed, but got: %v", staleDepartment.Id, err)
 	}
-	UpdateDepartment(department, departmentUpdatedFields)
 
-	department = FindDepartmentById(departmentIdToUpdate)
+	err := db.UpdateDepartment(f.Ctx, staleDepartment, db.Department{Name: "Social Sciences"})
+	var conflict *db.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Update with stale version expected to return ConflictError, but got: %v", err)
+	}
+	if conflict.ActualVersion != staleDepartment.Version+1 {
+		t.Fatalf("Conflict expected to report version %d, but reported %d", staleDepartment.Version+1, conflict.ActualVersion)
+	}
 
-	if department.Name != departmentUpdatedFields.Name {
-		log.Fatalf("After updating department name expected: %s, but actual: %s", updatedDepartmentName, department.Name)
+	if department := db.FindDepartmentById(f.Ctx, int(staleDepartment.Id)); department.Name != "Humanities" {
+		t.Fatalf("Stale update must not overwrite department name, but it's %s", department.Name)
 	}
 }
 
 func TestDepartmentDelete(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f)
+	if err := db.DeleteDepartment(f.Ctx, u.departments[0]); err != nil {
+		t.Fatalf("Deleting the department failed: %v", err)
+	}
 
-	createDepartments()
+	expected := len(u.departments) - 1
+	if actual := len(db.FindAllDepartments(f.Ctx)); expected != actual {
+		t.Fatalf("After deletion one department expected: %d departments, but found: %d", expected, actual)
+	}
+}
 
-	departmentIdToDelete := 1
-	department := FindDepartmentById(departmentIdToDelete)
+func TestDepartmentHierarchyRollup(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	expected := 3
-	DeleteDepartment(department)
-	actualDepartments := FindAllDepartments()
+	u := createUniversity(f).withStudents()
+	school := f.Department(func(d *db.Department) { d.Kind = db.KindSchool })
+	faculty := f.Department(func(d *db.Department) { d.Kind = db.KindFaculty; d.ParentId = &school.Id })
+
+	// The students are in the first two departments.
+	for _, department := range u.departments[:2] {
+		if err := db.MoveDepartment(f.Ctx, department.Id, &faculty.Id); err != nil {
+			t.Fatalf("Moving department %d below faculty failed: %v", department.Id, err)
+		}
+	}
 
-	if actual := len(actualDepartments); expected != actual {
-		log.Fatalf("After deletion one department expected: %d departments, but found: %d", expected, actual)
+	if err := db.MoveDepartment(f.Ctx, school.Id, &u.departments[0].Id); !errors.Is(err, db.ErrDepartmentCycle) {
+		t.Fatalf("Moving school below its own department expected to fail with ErrDepartmentCycle, but got: %v", err)
+	}
+
+	if subtree := db.FindDepartmentSubtree(f.Ctx, school.Id); len(subtree) != 4 {
+		t.Fatalf("Subtree of school expected to contain 4 departments, but found %d", len(subtree))
+	}
+
+	expected := uint(len(u.students))
+	for _, department := range db.GetStudentCountForEachDepartmentRollup(f.Ctx) {
+		if department.Id == school.Id && department.StudentCount != expected {
+			t.Fatalf("School expected to roll up %d students, but found %d!", expected, department.StudentCount)
+		}
 	}
 }
 
-var studentsInput = []Student{
-	{
-		FullName: "Askar Bekbergen", Age: 20, City: "Almaty", DepartmentId: 1,
-	},
-	{
-		FullName: "Ramazan Mamyrbek", Age: 20, City: "Turkistan", DepartmentId: 1,
-	},
-	{
-		FullName: "Nurdaulet Agabek", Age: 19, City: "Kaskelen", DepartmentId: 2,
-	},
-	{
-		FullName: "Asset Tagvay", Age: 19, City: "New York", DepartmentId: 2,
-	},
+func TestDepartmentUpdateCannotMove(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	department, parent := f.Department(), f.Department()
+	err := db.UpdateDepartment(f.Ctx, department, db.Department{ParentId: &parent.Id})
+	var validationErrors validation.Errors
+	if !errors.As(err, &validationErrors) || validationErrors[0].Field != "ParentId" {
+		t.Fatalf("Changing the parent by an update expected to fail validation, but got: %v", err)
+	}
+	if department = db.FindDepartmentById(f.Ctx, int(department.Id)); department.ParentId != nil {
+		t.Fatalf("Department %d expected to stay a root, but has parent %d", department.Id, *department.ParentId)
+	}
 }
 
-func createStudents() {
-	createDepartments()
+func TestHierarchyQueriesStopAtCycles(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	first, second, third := f.Department(), f.Department(), f.Department()
+
+	// Corrupted data: first and second are each other's parent.
+	err := db.Conn(f.Ctx).Exec("UPDATE departments SET parent_id = CASE id WHEN ? THEN ? ELSE ? END WHERE id IN (?, ?)",
+		first.Id, second.Id, first.Id, first.Id, second.Id).Error
+	if err != nil {
+		t.Fatalf("Creating a cycle failed: %v", err)
+	}
 
-	for _, student := range studentsInput {
-		CreateStudent(student)
+	if subtree := db.FindDepartmentSubtree(f.Ctx, first.Id); len(subtree) != 2 {
+		t.Fatalf("Subtree of department %d expected to contain 2 departments, but found %v", first.Id, subtree)
+	}
+	if ancestors := db.FindDepartmentAncestors(f.Ctx, first.Id); len(ancestors) != 1 || ancestors[0].Id != second.Id {
+		t.Fatalf("Ancestors of department %d expected to be department %d, but found %v", first.Id, second.Id, ancestors)
+	}
+	if err := db.MoveDepartment(f.Ctx, third.Id, &first.Id); !errors.Is(err, db.ErrDepartmentCycle) {
+		t.Fatalf("Moving below a cycle expected to fail with ErrDepartmentCycle, but got: %v", err)
+	}
+
+	var departments []uint
+	err = db.WithDepartment(f.Ctx, first.Id, func(ctx context.Context) error {
+		return db.Conn(ctx).Raw("SELECT session_departments()").Scan(&departments).Error
+	})
+	if err != nil || len(departments) != 2 {
+		t.Fatalf("Session departments expected to be %d and %d, but found %v: %v", first.Id, second.Id, departments, err)
 	}
 }
 
 func TestStudentCreate(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createStudents()
+	createUniversity(f).withStudents()
 
 	expectedStudentsSize := len(studentsInput)
-	actualStudents := FindAllStudents()
+	if actualStudentsSize := len(db.FindAllStudents(f.Ctx)); actualStudentsSize != expectedStudentsSize {
+		t.Fatalf("After insert expected %d students. Found %d!", expectedStudentsSize, actualStudentsSize)
+	}
+}
+
+func TestStudentCreateValidation(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	_, err := db.CreateStudent(f.Ctx, db.Student{FullName: "", Age: 500, City: "Almaty", DepartmentId: uintPtr(missingId)})
 
-	if actualStudentsSize := len(actualStudents); actualStudentsSize != expectedStudentsSize {
-		log.Fatalf("After insert expected %d students. Found %d!", expectedStudentsSize, actualStudentsSize)
+	var validationErrors validation.Errors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Creating invalid student expected to return validation errors, but got: %v", err)
+	}
+	if expected, actual := 3, len(validationErrors); expected != actual {
+		t.Fatalf("Expected %d violated fields, but found %d: %v", expected, actual, validationErrors)
+	}
+	f.AssertCount(0, &db.Student{})
+}
+
+func TestDepartmentNameUnique(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	department := f.Department()
+	if _, err := db.CreateDepartment(f.Ctx, db.Department{Name: department.Name}); err == nil {
+		t.Fatalf("Creating department with duplicate name %s expected to fail", department.Name)
 	}
 }
 
 func TestFindAllStudentsByDepartmentId(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createStudents()
+	u := createUniversity(f).withStudents()
 
-	departmentId := 1
+	department := u.departments[0]
 	expected := 2
-	actual := len(FindAllStudentsByDepartmentId(uint(departmentId)))
-
-	if actual != expected {
-		log.Fatalf("The number of students studying at departmentId: %d is expected to be %d, but found %d!", departmentId, expected, actual)
+	if actual := len(db.FindAllStudentsByDepartmentId(f.Ctx, department.Id)); actual != expected {
+		t.Fatalf("The number of students studying at departmentId: %d is expected to be %d, but found %d!", department.Id, expected, actual)
 	}
 }
 
 func TestFindStudentsByAge(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createStudents()
+	createUniversity(f).withStudents()
 
 	age := 19
-	students := FindStudentsByAge(age)
 	expected := 2
-
-	if actual := len(students); actual != expected {
-		log.Fatalf("The number of students with age %d is expected to be %d, but found %d!", age, expected, actual)
+	if actual := len(db.FindStudentsByAge(f.Ctx, age)); actual != expected {
+		t.Fatalf("The number of students with age %d is expected to be %d, but found %d!", age, expected, actual)
 	}
 }
 
 func TestStudentDelete(t *testing.T) {
-	tearDownSuite := setupSuite(t)
-	defer tearDownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createStudents()
+	u := createUniversity(f).withStudents()
 
-	studentId := 1
-	student := FindStudentById(studentId)
-	DeleteStudent(student)
-
-	expected := 3
-
-	students := FindAllStudents()
-
-	if actual := len(students); actual != expected {
-		log.Fatalf("The number of students after deletion studentId %d is expected to be %d, but found %d!", studentId, expected, actual)
+	student := u.students[0]
+	if err := db.DeleteStudent(f.Ctx, student); err != nil {
+		t.Fatalf("Deleting the student failed: %v", err)
 	}
-}
-
-var instructorsInput = []Instructor{
-	{
-		FullName: "Azamat Serek", Age: 28, DepartmentId: 1,
-	},
-	{
-		FullName: "Nurbol Sabitov", Age: 25, DepartmentId: 1,
-	},
-	{
-		FullName: "Alisher Duzmagambetov", Age: 24, DepartmentId: 2,
-	},
-	{
-		FullName: "Sufyan Mustafa", Age: 30, DepartmentId: 1,
-	},
-}
 
-func createInstructors() {
-	createDepartments()
-
-	for _, instructor := range instructorsInput {
-		CreateInstructor(instructor)
+	expected := len(u.students) - 1
+	if actual := len(db.FindAllStudents(f.Ctx)); actual != expected {
+		t.Fatalf("The number of students after deletion studentId %d is expected to be %d, but found %d!", student.Id, expected, actual)
 	}
 }
 
 func TestCreateInstructor(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
-
-	createInstructors()
+	t.Parallel()
+	f := dbtest.New(t)
 
-	expected := 4
-	actualInstructors := FindAllInstructors()
+	createUniversity(f).withInstructors()
 
-	if actual := len(actualInstructors); actual != expected {
-		log.Fatalf("After insert expected %d instructors. Found %d!", expected, actual)
+	expected := len(instructorsInput)
+	if actual := len(db.FindAllInstructors(f.Ctx)); actual != expected {
+		t.Fatalf("After insert expected %d instructors. Found %d!", expected, actual)
 	}
 }
 
 func TestFindInstructorById(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createInstructors()
+	u := createUniversity(f).withInstructors()
 
-	id := 4
+	id := u.instructors[3].Id
 	expected := "Sufyan Mustafa"
-	instructor := FindInstructorById(id)
-
-	if actual := instructor.FullName; expected != actual {
-		log.Fatalf("The instructors name with id %d is expected to be %s, but found %s!", id, expected, actual)
+	if actual := db.FindInstructorById(f.Ctx, int(id)).FullName; expected != actual {
+		t.Fatalf("The instructors name with id %d is expected to be %s, but found %s!", id, expected, actual)
 	}
 }
 
 func TestUpdateInstructor(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createInstructors()
-
-	id := 3
-	instructor := FindInstructorById(id)
+	u := createUniversity(f).withInstructors()
 
+	instructor := u.instructors[2]
 	expectedFullName := "Alisher"
 	expectedAge := 26
-	instructorWithUpdatedFields := Instructor{
+	instructorWithUpdatedFields := db.Instructor{
 		FullName: expectedFullName, Age: uint(expectedAge),
 	}
 
-	UpdateInstructor(instructor, instructorWithUpdatedFields)
+	if err := db.UpdateInstructor(f.Ctx, instructor, instructorWithUpdatedFields); err != nil {
+		t.Fatalf("Updating instructor %d failed: %v", instructor.Id, err)
+	}
 
-	instructor = FindInstructorById(id)
+	instructor = db.FindInstructorById(f.Ctx, int(instructor.Id))
 	if instructor.FullName != expectedFullName || instructor.Age != uint(expectedAge) {
-		log.Fatalf("The instructor after update is expected to be with name: %s and age: %d, but found name: %s, age: %d", expectedFullName, expectedAge, instructor.FullName, instructor.Age)
+		t.Fatalf("The instructor after update is expected to be with name: %s and age: %d, but found name: %s, age: %d", expectedFullName, expectedAge, instructor.FullName, instructor.Age)
 	}
 }
 
 func TestDeleteInstructor(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createInstructors()
+	u := createUniversity(f).withInstructors()
 
-	id := 2
-	instructor := FindInstructorById(id)
+	if err := db.DeleteInstructor(f.Ctx, u.instructors[1]); err != nil {
+		t.Fatalf("Deleting the instructor failed: %v", err)
+	}
 
-	DeleteInstructor(instructor)
+	expected := len(u.instructors) - 1
+	if actual := len(db.FindAllInstructors(f.Ctx)); actual != expected {
+		t.Fatalf("After deletion one instructor instructor's size expected to be %d, but found %d", expected, actual)
+	}
+}
 
-	expected := 3
-	instructors := FindAllInstructors()
+func TestCreateCourse(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	if actual := len(instructors); actual != expected {
-		log.Fatalf("After deletion one instructor instructor's size expected to be %d, but found %d", expected, actual)
+	createUniversity(f).withInstructors().withCourses()
+
+	expected := len(coursesInput)
+	if actual := len(db.FindAllCourses(f.Ctx)); actual != expected {
+		t.Fatalf("After insert expected %d courses. Found %d!", expected, actual)
 	}
 }
 
-var coursesInput = []Course{
-	{
-		Name: "The Go programming language", DepartmentId: 1, InstructorId: 1,
-	},
-	{
-		Name: "Frontend Development", DepartmentId: 1, InstructorId: 2,
-	},
-	{
-		Name: "The Virtualization", DepartmentId: 1, InstructorId: 1,
-	},
-	{
-		Name: "The Fundamentals of Programming", DepartmentId: 1, InstructorId: 3,
-	},
-	{
-		Name: "Culturology", DepartmentId: 4, InstructorId: 4,
-	},
-}
+func TestFindAllCoursesByInstructorId(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-func createCourses() {
-	createInstructors()
-	for _, course := range coursesInput {
-		CreateCourse(course)
+	u := createUniversity(f).withInstructors().withCourses()
+
+	expected := 2
+	instructor := u.instructors[0]
+	if actual := len(db.FindAllCoursesByInstructorId(f.Ctx, instructor.Id)); expected != actual {
+		t.Fatalf("Expected that %s teaches %d courses, but found %d!", instructor.FullName, expected, actual)
 	}
 }
 
-func TestCreateCourse(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestCourseStaffRoles(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createCourses()
+	u := createUniversity(f).withInstructors().withCourses()
+
+	instructorId := u.instructors[1].Id
+	assisted, coTaught := u.courses[0], u.courses[2]
+	if err := db.AddCourseStaff(f.Ctx, assisted.Id, instructorId, db.RoleTeachingAssistant); err != nil {
+		t.Fatalf("Adding teaching assistant failed: %v", err)
+	}
+	if err := db.AddCourseStaff(f.Ctx, coTaught.Id, instructorId, db.RoleCoInstructor); err != nil {
+		t.Fatalf("Adding co-instructor failed: %v", err)
+	}
+	if err := db.AddCourseStaff(f.Ctx, coTaught.Id, instructorId, "dean"); err == nil {
+		t.Fatalf("Adding staff with unknown role expected to fail")
+	}
 
-	expected := 5
-	actualCourses := FindAllCourses()
+	if actual := len(db.FindAllCoursesByInstructorId(f.Ctx, instructorId)); actual != 1 {
+		t.Fatalf("Without roles instructor %d expected to be primary instructor of 1 course, but found %d", instructorId, actual)
+	}
+	if actual := len(db.FindAllCoursesByInstructorId(f.Ctx, instructorId, db.StaffRoles...)); actual != 3 {
+		t.Fatalf("With every role instructor %d expected to teach 3 courses, but found %d", instructorId, actual)
+	}
+	if actual := len(db.FindAllCoursesByInstructorId(f.Ctx, instructorId, db.RoleTeachingAssistant)); actual != 1 {
+		t.Fatalf("Instructor %d expected to assist in 1 course, but found %d", instructorId, actual)
+	}
 
-	if actual := len(actualCourses); actual != expected {
-		log.Fatalf("After insert expected %d courses. Found %d!", expected, actual)
+	if staff := db.FindCourseStaff(f.Ctx, coTaught.Id); len(staff) != 2 || staff[0].Role != db.RoleLead {
+		t.Fatalf("Course %d expected to have primary instructor and co-instructor, but found %v", coTaught.Id, staff)
 	}
 }
 
-func TestFindAllCoursesByInstructorId(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestFindCourseById(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createCourses()
+	u := createUniversity(f).withInstructors().withCourses()
 
-	expected := 2
+	expected := "The Virtualization"
+	if actual := db.FindCourseById(f.Ctx, int(u.courses[2].Id)).Name; expected != actual {
+		t.Fatalf("The expected name of course is %s, but it's %s", expected, actual)
+	}
+}
+
+func TestUpdateCourse(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withInstructors().withCourses()
 
-	instructorId := 1
-	instructor := FindInstructorById(instructorId)
-	courses := FindAllCoursesByInstructorId(uint(instructorId))
+	course := u.courses[3]
+	expectedCourse := db.Course{
+		Name: "Server Administration", InstructorId: &u.instructors[1].Id,
+	}
+
+	if err := db.UpdateCourse(f.Ctx, course, expectedCourse); err != nil {
+		t.Fatalf("Updating course %d failed: %v", course.Id, err)
+	}
 
-	if actual := len(courses); expected != actual {
-		log.Fatalf("Expected that %s teaches %d courses, but found %d!", instructor.FullName, expected, actual)
+	course = db.FindCourseById(f.Ctx, int(course.Id))
+	if course.Name != expectedCourse.Name || *course.InstructorId != *expectedCourse.InstructorId {
+		t.Fatalf("The expected course name is %s and instructor id is %d, but it's %s and %d", expectedCourse.Name, *expectedCourse.InstructorId, course.Name, *course.InstructorId)
 	}
 }
 
-func TestFindCourseById(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestUpdateCourseWritesOnlyEditableFields(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createCourses()
+	u := createUniversity(f).withInstructors().withCourses()
 
-	expected := "The Virtualization"
-	id := 3
-	course := FindCourseById(id)
+	course := u.courses[3]
+	err := db.UpdateCourse(f.Ctx, course, db.Course{
+		Id:       missingId,
+		Name:     "Server Administration",
+		Students: []db.Student{{FullName: "Smuggled Student", Age: 20}},
+		Version:  50,
+	})
+	if err != nil {
+		t.Fatalf("Updating course %d failed: %v", course.Id, err)
+	}
 
-	if actual := course.Name; expected != actual {
-		log.Fatalf("The expected name of course is %s, but it's %s", expected, actual)
+	updated := db.FindCourseById(f.Ctx, int(course.Id))
+	if updated.Name != "Server Administration" || updated.Version != course.Version+1 {
+		t.Fatalf("Course expected to be renamed at version %d, but found %q at version %d", course.Version+1, updated.Name, updated.Version)
+	}
+	if db.FindCourseById(f.Ctx, missingId).Id != 0 {
+		t.Fatalf("Update must not change the id of the course")
+	}
+	if students := db.GetCourseEnrolledStudentsByCourseId(f.Ctx, course.Id); len(students) != 0 {
+		t.Fatalf("Update must not create or enroll students, but found %v", students)
 	}
 }
 
-func TestUpdateCourse(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestMigrateClearsUnsetInstructors(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createCourses()
+	// The tables are migrated in a schema of the transaction, so dropping
+	// a foreign key does not lock the tables of the other tests.
+	for _, statement := range []string{"CREATE SCHEMA migration", "SET LOCAL search_path TO migration"} {
+		if err := db.Conn(f.Ctx).Exec(statement).Error; err != nil {
+			t.Fatalf("Preparing the schema failed: %v", err)
+		}
+	}
+	if err := db.Migrate(f.Ctx); err != nil {
+		t.Fatalf("Migrating the empty schema failed: %v", err)
+	}
+	u := createUniversity(f).withInstructors().withCourses()
+	course := u.courses[1]
 
-	id := 4
-	course := FindCourseById(id)
+	// Before instructors were optional, courses without one had 0.
+	if err := db.Conn(f.Ctx).Migrator().DropConstraint(&db.Instructor{}, "Courses"); err != nil {
+		t.Fatalf("Dropping the foreign key of courses failed: %v", err)
+	}
+	if err := db.Conn(f.Ctx).Exec("UPDATE courses SET instructor_id = 0 WHERE id = ?", course.Id).Error; err != nil {
+		t.Fatalf("Resetting the instructor of course %d failed: %v", course.Id, err)
+	}
 
-	expectedCourse := Course{
-		Name: "Server Administration", InstructorId: 2,
+	if err := db.Migrate(f.Ctx); err != nil {
+		t.Fatalf("Migrating courses with unset instructors failed: %v", err)
+	}
+	if course := db.FindCourseById(f.Ctx, int(course.Id)); course.InstructorId != nil {
+		t.Fatalf("Course %d expected to have no instructor, but has %d", course.Id, *course.InstructorId)
 	}
+	if !db.Conn(f.Ctx).Migrator().HasConstraint(&db.Instructor{}, "Courses") {
+		t.Fatalf("Migration expected to add the foreign key of courses back")
+	}
+}
 
-	UpdateCourse(course, expectedCourse)
+func TestAssignAndUnassignInstructor(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	course = FindCourseById(id)
+	u := createUniversity(f).withInstructors().withCourses()
 
-	if course.Name != expectedCourse.Name || course.InstructorId != expectedCourse.InstructorId {
-		log.Fatalf("The expected course name is %s and instructor id is %d, but it's %s and %d", expectedCourse.Name, expectedCourse.InstructorId, course.Name, course.InstructorId)
+	courseId := u.courses[1].Id
+	if err := db.UnassignInstructor(f.Ctx, courseId); err != nil {
+		t.Fatalf("Unassigning instructor from course %d failed: %v", courseId, err)
+	}
+
+	withoutInstructor := db.FindCoursesWithoutInstructor(f.Ctx)
+	if len(withoutInstructor) != 1 || withoutInstructor[0].Id != courseId {
+		t.Fatalf("Expected only course %d to have no instructor, but found %v", courseId, withoutInstructor)
+	}
+
+	instructorId := u.instructors[3].Id
+	if err := db.AssignInstructor(f.Ctx, courseId, instructorId); err != nil {
+		t.Fatalf("Assigning instructor %d to course %d failed: %v", instructorId, courseId, err)
+	}
+	if course := db.FindCourseById(f.Ctx, int(courseId)); course.InstructorId == nil || *course.InstructorId != instructorId {
+		t.Fatalf("Course %d expected to be taught by instructor %d, but found %v", courseId, instructorId, course.InstructorId)
+	}
+
+	if err := db.AssignInstructor(f.Ctx, courseId, missingId); err == nil {
+		t.Fatalf("Assigning not existing instructor expected to fail")
 	}
 }
 
-func TestDeleteCourse(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestDeleteInstructorUnassignsCourses(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withInstructors().withCourses()
 
-	createCourses()
+	instructor := u.instructors[0]
+	expected := len(db.FindAllCoursesByInstructorId(f.Ctx, instructor.Id))
 
-	id := 3
-	course := FindCourseById(id)
+	if err := db.DeleteInstructor(f.Ctx, instructor); err != nil {
+		t.Fatalf("Deleting the instructor failed: %v", err)
+	}
+
+	if actual := len(db.FindCoursesWithoutInstructor(f.Ctx)); actual != expected {
+		t.Fatalf("After deleting instructor %d expected %d courses without instructor, but found %d!", instructor.Id, expected, actual)
+	}
+}
+
+func TestTeachingLoadLimit(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withInstructors()
+
+	instructor := u.instructors[0]
+	if err := db.UpdateInstructor(f.Ctx, instructor, db.Instructor{MaxTeachingLoad: uintPtr(10)}); err != nil {
+		t.Fatalf("Limiting the teaching load of instructor %d failed: %v", instructor.Id, err)
+	}
 
-	expected := len(coursesInput) - 1
+	course := db.Course{Name: "Databases", DepartmentId: instructor.DepartmentId, InstructorId: &instructor.Id, Credits: 6, Term: "2024-fall"}
+	if _, err := db.CreateCourse(f.Ctx, course); err != nil {
+		t.Fatalf("Creating course within teaching load failed: %v", err)
+	}
 
-	DeleteCourse(course)
+	course.Name = "Distributed Systems"
+	_, err := db.CreateCourse(f.Ctx, course)
+	var loadError *db.TeachingLoadError
+	if !errors.As(err, &loadError) {
+		t.Fatalf("Creating course over teaching load expected to return TeachingLoadError, but got: %v", err)
+	}
 
-	courses := FindAllCourses()
+	course.Term = "2025-spring"
+	if _, err := db.CreateCourse(f.Ctx, course); err != nil {
+		t.Fatalf("Teaching load of another term must not count, but got: %v", err)
+	}
 
-	if actual := len(courses); actual != expected {
-		log.Fatalf("After deletion expected %d courses, but found %d!", expected, actual)
+	if load := db.GetTeachingLoad(f.Ctx, instructor.Id, "2024-fall"); load.Load != 6 || load.CourseCount != 1 {
+		t.Fatalf("Expected load 6 from 1 course, but found %d from %d courses", load.Load, load.CourseCount)
 	}
 }
 
-var enrollments = []Enrollment{
-	{
-		StudentId: 1, CourseId: 2,
-	},
-	{
-		StudentId: 1, CourseId: 3,
-	},
-	{
-		StudentId: 3, CourseId: 2,
-	},
-	{
-		StudentId: 3, CourseId: 4,
-	},
-	{
-		StudentId: 3, CourseId: 5,
-	},
-	{
-		StudentId: 2, CourseId: 1,
-	},
-	{
-		StudentId: 2, CourseId: 2,
-	},
-	{
-		StudentId: 2, CourseId: 3,
-	},
+func TestTeachingLoadCountsStaffRoles(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	instructor := f.Instructor(func(i *db.Instructor) { i.MaxTeachingLoad = uintPtr(10) })
+	inDepartment := func(c *db.Course) { c.DepartmentId = instructor.DepartmentId }
+	f.Course(inDepartment, func(c *db.Course) { c.InstructorId = &instructor.Id })
+	assisted, graded, coTaught := f.Course(inDepartment), f.Course(inDepartment), f.Course(inDepartment)
+
+	if err := db.AddCourseStaff(f.Ctx, assisted.Id, instructor.Id, db.RoleTeachingAssistant); err != nil {
+		t.Fatalf("Assisting in a course within the teaching load failed: %v", err)
+	}
+	if err := db.AddCourseStaff(f.Ctx, graded.Id, instructor.Id, db.RoleGrader); err != nil {
+		t.Fatalf("Grading must not count towards the teaching load, but got: %v", err)
+	}
+	err := db.AddCourseStaff(f.Ctx, coTaught.Id, instructor.Id, db.RoleCoInstructor)
+	var loadError *db.TeachingLoadError
+	if !errors.As(err, &loadError) {
+		t.Fatalf("Co-teaching a course over the teaching load expected to return TeachingLoadError, but got: %v", err)
+	}
+
+	if load := db.GetTeachingLoad(f.Ctx, instructor.Id, "2024-fall"); load.Load != 10 || load.CourseCount != 2 {
+		t.Fatalf("Expected load 10 from 2 courses, but found %d from %d courses", load.Load, load.CourseCount)
+	}
 }
 
-func createEnrollments() {
-	createCourses()
-	for _, student := range studentsInput {
-		CreateStudent(student)
+func TestEnrollmentRechecksTeachingLoad(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	instructor := f.Instructor(func(i *db.Instructor) { i.MaxTeachingLoad = uintPtr(5) })
+	course := f.Course(func(c *db.Course) { c.DepartmentId = instructor.DepartmentId; c.InstructorId = &instructor.Id })
+	// The 30th student adds a load unit to the 5 credits of the course.
+	for i := 1; i < 30; i++ {
+		f.Enroll(f.Student(), course)
 	}
 
-	for _, enrollment := range enrollments {
-		EnrollStudentForCourse(enrollment.StudentId, enrollment.CourseId)
+	student := f.Student()
+	err := db.EnrollStudentForCourse(f.Ctx, student.Id, course.Id)
+	var loadError *db.TeachingLoadError
+	if !errors.As(err, &loadError) || loadError.Load != 6 {
+		t.Fatalf("Enrolling the 30th student expected to return TeachingLoadError with load 6, but got: %v", err)
+	}
+	if students := db.GetCourseEnrolledStudentsByCourseId(f.Ctx, course.Id); len(students) != 29 {
+		t.Fatalf("The refused enrollment must be rolled back, but found %d students", len(students))
 	}
 }
+
+func TestDeleteCourse(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withInstructors().withCourses()
+
+	if err := db.DeleteCourse(f.Ctx, u.courses[2]); err != nil {
+		t.Fatalf("Deleting the course failed: %v", err)
+	}
+
+	expected := len(u.courses) - 1
+	if actual := len(db.FindAllCourses(f.Ctx)); actual != expected {
+		t.Fatalf("After deletion expected %d courses, but found %d!", expected, actual)
+	}
+}
+
 func TestEnrollStudentForCourse(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+	t.Parallel()
+	f := dbtest.New(t)
 
-	createEnrollments()
+	u := createUniversity(f).withStudents().withInstructors().withCourses().withEnrollments()
 
-	id := 3
+	id := u.students[2].Id
 	expected := 3
+	if actual := len(db.GetStudentEnrolledCoursesByStudentId(f.Ctx, id)); actual != expected {
+		t.Fatalf("The student with id %d should be enrolled for %d courses, but found %d!", id, expected, actual)
+	}
+}
 
-	courses := GetStudentEnrolledCoursesByStudentId(uint(id))
+func TestEnrollingLeavesStudentUnchanged(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
-	if actual := len(courses); actual != expected {
-		log.Fatalf("The student with id %d should be enrolled for %d courses, but found %d!", id, expected, actual)
+	student, course := f.Student(), f.Course()
+	if err := db.EnrollStudentForCourse(f.Ctx, student.Id, course.Id); err != nil {
+		t.Fatalf("Enrolling student %d failed: %v", student.Id, err)
+	}
+	if enrolled := db.FindStudentById(f.Ctx, int(student.Id)); enrolled.Version != student.Version || enrolled.FullName != student.FullName {
+		t.Fatalf("Enrolling expected to leave student %+v unchanged, but found %+v", student, enrolled)
+	}
+	// Enrolling twice keeps the one enrollment.
+	if err := db.EnrollStudentForCourse(f.Ctx, student.Id, course.Id); err != nil {
+		t.Fatalf("Enrolling student %d again failed: %v", student.Id, err)
+	}
+	if courses := db.GetStudentEnrolledCoursesByStudentId(f.Ctx, student.Id); len(courses) != 1 {
+		t.Fatalf("The student should be enrolled for 1 course, but found %d", len(courses))
 	}
 }
 
-func TestStudentCountForEachDepartment(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestDropStudentFromCourse(t *testing.T) {
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withStudents().withInstructors().withCourses().withEnrollments()
 
-	createStudents()
+	student := u.students[2]
+	dropsBefore := testutil.ToFloat64(db.DropsTotal)
+	course := db.GetStudentEnrolledCoursesByStudentId(f.Ctx, student.Id)[0]
+	if err := db.DropStudentFromCourse(f.Ctx, student.Id, course.Id); err != nil {
+		t.Fatalf("Dropping student %d from course %d failed: %v", student.Id, course.Id, err)
+	}
+	if actual := len(db.GetStudentEnrolledCoursesByStudentId(f.Ctx, student.Id)); actual != 2 {
+		t.Fatalf("The student with id %d should be enrolled for 2 courses after dropping one, but found %d!", student.Id, actual)
+	}
+	if err := db.DropStudentFromCourse(f.Ctx, student.Id, course.Id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Dropping a course twice expected to fail with ErrRecordNotFound, but got: %v", err)
+	}
+	if actual := testutil.ToFloat64(db.DropsTotal) - dropsBefore; actual != 1 {
+		t.Fatalf("Expected 1 drop to be counted, got %v", actual)
+	}
+}
 
-	expectedCounts := make([]uint, len(departmentsInput))
+func TestOperationErrorsByType(t *testing.T) {
+	f := dbtest.New(t)
 
-	for _, student := range studentsInput {
-		expectedCounts[student.DepartmentId-1] += 1
+	before := testutil.ToFloat64(db.OperationErrors.WithLabelValues("CreateStudent", "validation"))
+	db.CreateStudent(f.Ctx, db.Student{FullName: "", Age: 3})
+	if actual := testutil.ToFloat64(db.OperationErrors.WithLabelValues("CreateStudent", "validation")) - before; actual != 1 {
+		t.Fatalf("Expected the rejected student to be counted as a validation error, got %v", actual)
 	}
+}
+
+func TestTracingSpans(t *testing.T) {
+	f := dbtest.New(t)
+
+	department := f.Department()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
 
-	actualCounts := GetStudentCountForEachDepartment()
+	ctx, parent := provider.Tracer("test").Start(f.Ctx, "request")
+	db.CreateStudent(ctx, db.Student{FullName: "Aigerim Sultan", Age: 21, City: "Almaty", DepartmentId: &department.Id})
+	parent.End()
 
-	for i := 0; i < len(actualCounts); i++ {
-		idx := actualCounts[i].Id - 1
-		if expectedCounts[idx] != actualCounts[i].StudentCount {
-			log.Fatalf("The %s department should contain %d students, but found %d!", actualCounts[i].Name, expectedCounts[idx], actualCounts[i].StudentCount)
+	var operation sdktrace.ReadOnlySpan
+	statements := 0
+	for _, span := range recorder.Ended() {
+		switch {
+		case span.Name() == "db.CreateStudent":
+			operation = span
+		case strings.HasPrefix(span.Name(), "gorm."):
+			statements++
 		}
 	}
+	if operation == nil || operation.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("Expected a db.CreateStudent span below the request span")
+	}
+	if statements < 2 {
+		t.Fatalf("Expected spans for the validation query and the insert, found %d", statements)
+	}
 }
 
-func TestGetStudentsOfInstructor(t *testing.T) {
-	teardownSuite := setupSuite(t)
-	defer teardownSuite(t)
+func TestSlowQueriesAreLogged(t *testing.T) {
+	dbtest.New(t) // connects and migrates the test database
 
-	createEnrollments()
+	var out bytes.Buffer
+	if err := logging.Setup(config.Logging{Level: "warn", Format: "json"}, &out); err != nil {
+		t.Fatalf("Failed to set up logging: %v", err)
+	}
+	defer logging.Setup(config.Logging{Level: "info", Format: "text"}, os.Stderr)
 
-	instructorId := 1
+	// Every statement is slow with a threshold of a nanosecond. The test has
+	// a connection of its own with it.
+	options := db.DefaultOptions()
+	options.SlowQueryThreshold = time.Nanosecond
+	ctx, rollback, err := db.Dial(context.Background(), dbtest.DSN(t, dbtest.Schema), options)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer rollback()
 
-	courses := FindAllCoursesByInstructorId(uint(instructorId))
+	department, err := db.CreateDepartment(ctx, db.Department{Name: "Department of Slow Queries"})
+	if err != nil {
+		t.Fatalf("Creating a department failed: %v", err)
+	}
+	db.CreateStudent(logging.WithRequestID(ctx, "req-1"), db.Student{FullName: "Aigerim Sultan", Age: 21, City: "Almaty", DepartmentId: &department.Id})
 
-	expectedStudentName := map[string]struct{}{}
+	logged := out.String()
+	for _, expected := range []string{`"msg":"slow query"`, `"caller":"db.CreateStudent"`, `"request_id":"req-1"`} {
+		if !strings.Contains(logged, expected) {
+			t.Fatalf("Expected slow query log to contain %s, got %s", expected, logged)
+		}
+	}
+	if strings.Contains(logged, "Aigerim") {
+		t.Fatalf("Expected the name of the student to be redacted, got %s", logged)
+	}
+}
 
-	for _, course := range courses {
-		students := GetCourseEnrolledStudentsByCourseId(course.Id)
-		for _, student := range students {
-			expectedStudentName[student.FullName] = struct{}{}
+func TestStudentCountForEachDepartment(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withStudents()
+
+	expectedCounts := map[uint]uint{}
+	for _, student := range u.students {
+		expectedCounts[*student.DepartmentId] += 1
+	}
+
+	for _, actual := range db.GetStudentCountForEachDepartment(f.Ctx) {
+		if expectedCounts[actual.Id] != actual.StudentCount {
+			t.Fatalf("The %s department should contain %d students, but found %d!", actual.Name, expectedCounts[actual.Id], actual.StudentCount)
 		}
 	}
+}
+
+func TestStudentCountIncludesEmptyDepartments(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withStudents()
+
+	expected := len(u.departments)
+	if actual := len(db.GetStudentCountForEachDepartment(f.Ctx)); actual != expected {
+		t.Fatalf("Student count expected for all %d departments, but found %d!", expected, actual)
+	}
+}
 
-	actualStudents := GetStudentsOfInstructor(uint(instructorId))
+func TestGetStudentsOfInstructor(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
 
+	u := createUniversity(f).withStudents().withInstructors().withCourses().withEnrollments()
+
+	instructorId := u.instructors[0].Id
+	expectedStudentName := map[string]struct{}{}
+	for _, course := range db.FindAllCoursesByInstructorId(f.Ctx, instructorId) {
+		for _, student := range db.GetCourseEnrolledStudentsByCourseId(f.Ctx, course.Id) {
+			expectedStudentName[student.FullName] = struct{}{}
+		}
+	}
+
+	actualStudents := db.GetStudentsOfInstructor(f.Ctx, instructorId)
+	if len(actualStudents) == 0 {
+		t.Fatalf("Instructor with id %d expected to teach students, but found none", instructorId)
+	}
 	for _, student := range actualStudents {
-		_, exists := expectedStudentName[student.FullName]
-		if !exists {
-			log.Fatalf("Instructor with id %d doesn't teach for %s!", instructorId, student.FullName)
+		if _, exists := expectedStudentName[student.FullName]; !exists {
+			t.Fatalf("Instructor with id %d doesn't teach for %s!", instructorId, student.FullName)
 		}
 	}
 }
+
+func TestCreateUser(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	student := f.Student()
+	user := f.User(func(u *db.User) { u.Role = "student"; u.StudentId = &student.Id })
+	if found := db.FindUserByEmail(f.Ctx, user.Email); found.Id != user.Id || *found.StudentId != student.Id {
+		t.Fatalf("Expected to find user %d linked to student %d, got %+v", user.Id, student.Id, found)
+	}
+
+	_, err := db.CreateUser(f.Ctx, db.User{Email: user.Email, PasswordHash: "hash", Role: "instructor", InstructorId: uintPtr(missingId)})
+	var invalid validation.Errors
+	if !errors.As(err, &invalid) || len(invalid) != 2 {
+		t.Fatalf("Expected duplicate email and unknown instructor to be rejected, got %v", err)
+	}
+
+	_, err = db.CreateUser(f.Ctx, db.User{Email: "admin." + user.Email, PasswordHash: "hash", Role: "department_admin"})
+	if !errors.As(err, &invalid) || invalid[0].Field != "DepartmentId" {
+		t.Fatalf("Expected a department admin without department to be rejected, got %v", err)
+	}
+}
+
+func TestEmailsAreUniqueWithoutTenant(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	user := f.User()
+	// Created directly, as validation would refuse the duplicate first.
+	duplicate := db.User{Email: user.Email, PasswordHash: "hash", Role: "registrar"}
+	if err := db.Conn(f.Ctx).Create(&duplicate).Error; err == nil {
+		t.Fatalf("Expected the database to refuse a second user of no tenant with email %s", user.Email)
+	}
+}
+
+func TestFailedLoginsLockUser(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	user := f.User()
+	lockedUntil := time.Now().Add(time.Hour)
+	for attempt := uint(1); attempt <= 3; attempt++ {
+		failures, err := db.RecordFailedLogin(f.Ctx, user.Id, 3, lockedUntil)
+		if err != nil || failures != attempt {
+			t.Fatalf("Expected failed login %d to be counted, got %d, %v", attempt, failures, err)
+		}
+	}
+	if locked := db.FindUserById(f.Ctx, int(user.Id)); locked.LockedUntil == nil || locked.FailedLogins != 0 {
+		t.Fatalf("Expected user to be locked after 3 failed logins, got %+v", locked)
+	}
+
+	if err := db.SetUserPassword(f.Ctx, user.Id, "new hash"); err != nil {
+		t.Fatalf("Setting the password failed: %v", err)
+	}
+	if unlocked := db.FindUserById(f.Ctx, int(user.Id)); unlocked.LockedUntil != nil || unlocked.PasswordHash != "new hash" {
+		t.Fatalf("Expected a new password to unlock the user, got %+v", unlocked)
+	}
+}
+
+func TestRefreshTokensAreRevokedOnce(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	user := f.User()
+	db.CreateRefreshToken(f.Ctx, db.RefreshToken{UserId: user.Id, TokenHash: "first", ExpiresAt: time.Now().Add(time.Hour)})
+	db.CreateRefreshToken(f.Ctx, db.RefreshToken{UserId: user.Id, TokenHash: "second", ExpiresAt: time.Now().Add(time.Hour)})
+
+	token := db.FindRefreshToken(f.Ctx, "first")
+	if err := db.RevokeRefreshToken(f.Ctx, token.Id, time.Now()); err != nil {
+		t.Fatalf("Revoking a refresh token failed: %v", err)
+	}
+	if err := db.RevokeRefreshToken(f.Ctx, token.Id, time.Now()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Revoking a refresh token twice expected to fail with ErrRecordNotFound, but got: %v", err)
+	}
+
+	db.RevokeUserRefreshTokens(f.Ctx, user.Id, time.Now())
+	if second := db.FindRefreshToken(f.Ctx, "second"); second.RevokedAt == nil {
+		t.Fatalf("Expected all refresh tokens of the user to be revoked")
+	}
+}
+
+func TestPasswordResetTokensAreUsedOnce(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	user := f.User()
+	db.CreatePasswordResetToken(f.Ctx, db.PasswordResetToken{UserId: user.Id, TokenHash: "valid", ExpiresAt: time.Now().Add(time.Hour)})
+	db.CreatePasswordResetToken(f.Ctx, db.PasswordResetToken{UserId: user.Id, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Minute)})
+
+	token, err := db.UsePasswordResetToken(f.Ctx, "valid", time.Now())
+	if err != nil || token.UserId != user.Id {
+		t.Fatalf("Expected the reset token of user %d, got %+v, %v", user.Id, token, err)
+	}
+	for _, hash := range []string{"valid", "expired", "unknown"} {
+		if _, err := db.UsePasswordResetToken(f.Ctx, hash, time.Now()); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("Using reset token %q expected to fail with ErrRecordNotFound, but got: %v", hash, err)
+		}
+	}
+}
+
+func TestExportAndEraseStudent(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withStudents().withInstructors().withCourses().withEnrollments()
+	student := u.students[0]
+	user := f.User(func(user *db.User) { user.Role = "student"; user.StudentId = &student.Id })
+	db.CreateRefreshToken(f.Ctx, db.RefreshToken{UserId: user.Id, TokenHash: "session", ExpiresAt: time.Now().Add(time.Hour)})
+
+	export, err := db.ExportStudent(f.Ctx, student.Id)
+	if err != nil {
+		t.Fatalf("Exporting the student failed: %v", err)
+	}
+	if export.Student.FullName != student.FullName || len(export.Student.Courses) != len(db.GetStudentEnrolledCoursesByStudentId(f.Ctx, student.Id)) {
+		t.Fatalf("Expected the export to hold the student and their courses, got %+v", export.Student)
+	}
+	if export.Department == nil || len(export.Accounts) != 1 || len(export.Accounts[0].Sessions) != 1 {
+		t.Fatalf("Expected the export to hold the department and the account with its session, got %+v", export)
+	}
+	if _, err := db.ExportStudent(f.Ctx, missingId); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Exporting an unknown student expected to fail with ErrRecordNotFound, but got: %v", err)
+	}
+
+	counts := map[uint]uint{}
+	for _, department := range db.GetStudentCountForEachDepartment(f.Ctx) {
+		counts[department.Id] = department.StudentCount
+	}
+	if err := db.EraseStudent(f.Ctx, student.Id, time.Now()); err != nil {
+		t.Fatalf("Erasing the student failed: %v", err)
+	}
+	erased := db.FindStudentById(f.Ctx, int(student.Id))
+	if erased.FullName != "" || erased.City != "" || erased.ErasedAt == nil {
+		t.Fatalf("Expected the personal data of the student to be erased, got %+v", erased)
+	}
+	if found := db.FindUserById(f.Ctx, int(user.Id)); found.Id != 0 {
+		t.Fatalf("Expected the account of the student to be deleted, got %+v", found)
+	}
+	if db.FindRefreshToken(f.Ctx, "session").Id != 0 {
+		t.Fatalf("Expected the sessions of the student to be deleted")
+	}
+	for _, department := range db.GetStudentCountForEachDepartment(f.Ctx) {
+		if department.StudentCount != counts[department.Id] {
+			t.Fatalf("Expected erasing to keep %d students in the %s department, found %d", counts[department.Id], department.Name, department.StudentCount)
+		}
+	}
+	if students := db.FindStudentsByCity(f.Ctx, student.City); len(students) != 0 {
+		t.Fatalf("Expected the erased student not to be found by city, found %+v", students)
+	}
+	if err := db.EraseStudent(f.Ctx, missingId, time.Now()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Erasing an unknown student expected to fail with ErrRecordNotFound, but got: %v", err)
+	}
+}
+
+func TestAPIKeysRotateAndRevoke(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	_, err := db.CreateAPIKey(f.Ctx, db.APIKey{Name: "Enrollment import", Prefix: "uk_a", KeyHash: "a", Scopes: "read:students write:grades"})
+	var invalid validation.Errors
+	if !errors.As(err, &invalid) || invalid[0].Field != "Scopes" {
+		t.Fatalf("Expected an unknown scope to be rejected, got %v", err)
+	}
+
+	key, err := db.CreateAPIKey(f.Ctx, db.APIKey{Name: "Enrollment import", Prefix: "uk_a", KeyHash: "a", Scopes: "read:students write:enrollments", RateLimit: 60})
+	if err != nil {
+		t.Fatalf("Creating an API key failed: %v", err)
+	}
+
+	graceEnd := time.Now().Add(time.Hour)
+	replacement, err := db.RotateAPIKey(f.Ctx, key.Id, db.APIKey{Prefix: "uk_b", KeyHash: "b"}, graceEnd)
+	if err != nil {
+		t.Fatalf("Rotating API key %d failed: %v", key.Id, err)
+	}
+	if replacement.Scopes != key.Scopes || replacement.RateLimit != 60 || db.FindAPIKey(f.Ctx, "b").Id != replacement.Id {
+		t.Fatalf("Expected the replacement to keep scopes and rate limit, got %+v", replacement)
+	}
+	if old := db.FindAPIKey(f.Ctx, "a"); old.ExpiresAt == nil || !old.ExpiresAt.Equal(graceEnd.Truncate(time.Microsecond)) {
+		t.Fatalf("Expected the old key to expire after the grace period, got %v", old.ExpiresAt)
+	}
+
+	now := time.Now()
+	db.TouchAPIKey(f.Ctx, replacement.Id, now)
+	db.TouchAPIKey(f.Ctx, replacement.Id, now.Add(time.Second))
+	if used := db.FindAPIKey(f.Ctx, "b").LastUsedAt; used == nil || !used.Equal(now.Truncate(time.Microsecond)) {
+		t.Fatalf("Expected the first use to be recorded and the next one within a minute skipped, got %v", used)
+	}
+
+	if err := db.RevokeAPIKey(f.Ctx, key.Id, now); err != nil {
+		t.Fatalf("Revoking API key %d failed: %v", key.Id, err)
+	}
+	if err := db.RevokeAPIKey(f.Ctx, key.Id, now); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Revoking API key %d twice expected to fail with ErrRecordNotFound, but got: %v", key.Id, err)
+	}
+	if _, err := db.RotateAPIKey(f.Ctx, key.Id, db.APIKey{Prefix: "uk_c", KeyHash: "c"}, graceEnd); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Rotating revoked API key %d expected to fail with ErrRecordNotFound, but got: %v", key.Id, err)
+	}
+}
+
+// createCampus creates a department with a student enrolled in a course
+// and a registrar in the tenant of ctx, all with the same names and email
+// in every tenant.
+func createCampus(t *testing.T, ctx context.Context) (db.Student, db.Course) {
+	t.Helper()
+	department, err := db.CreateDepartment(ctx, db.Department{Name: "Engineering and Natural Sciences"})
+	if err != nil {
+		t.Fatalf("Creating a department failed: %v", err)
+	}
+	student, err := db.CreateStudent(ctx, db.Student{FullName: "Aigerim Bekova", Age: 20, DepartmentId: &department.Id})
+	if err != nil {
+		t.Fatalf("Creating a student failed: %v", err)
+	}
+	instructor, err := db.CreateInstructor(ctx, db.Instructor{FullName: "Azamat Serek", Age: 28, DepartmentId: department.Id})
+	if err != nil {
+		t.Fatalf("Creating an instructor failed: %v", err)
+	}
+	course, err := db.CreateCourse(ctx, db.Course{Name: "The Go programming language", DepartmentId: department.Id, InstructorId: &instructor.Id})
+	if err != nil {
+		t.Fatalf("Creating a course failed: %v", err)
+	}
+	if err := db.EnrollStudentForCourse(ctx, student.Id, course.Id); err != nil {
+		t.Fatalf("Enrolling student %d failed: %v", student.Id, err)
+	}
+	if _, err := db.CreateUser(ctx, db.User{Email: "registrar@example.com", PasswordHash: "hash", Role: "registrar"}); err != nil {
+		t.Fatalf("Creating a user failed: %v", err)
+	}
+	return student, course
+}
+
+func TestTenantIsolation(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	north, err := db.CreateTenant(f.Ctx, db.Tenant{Slug: "north", Name: "North Campus"})
+	if err != nil {
+		t.Fatalf("Creating tenant north failed: %v", err)
+	}
+	south, err := db.CreateTenant(f.Ctx, db.Tenant{Slug: "south", Name: "South Campus"})
+	if err != nil {
+		t.Fatalf("Creating tenant south failed: %v", err)
+	}
+	northCtx, southCtx := db.WithTenant(f.Ctx, north.Id), db.WithTenant(f.Ctx, south.Id)
+	northStudent, northCourse := createCampus(t, northCtx)
+	southStudent, southCourse := createCampus(t, southCtx)
+
+	if southStudent.TenantId == nil || *southStudent.TenantId != south.Id {
+		t.Fatalf("Created student expected to belong to tenant %d, but got %v", south.Id, southStudent.TenantId)
+	}
+	if students := db.FindAllStudents(southCtx); len(students) != 1 || students[0].Id != southStudent.Id {
+		t.Fatalf("South expected to see only its own student, but found %+v", students)
+	}
+	if courses := db.FindAllCourses(northCtx); len(courses) != 1 || courses[0].Id != northCourse.Id {
+		t.Fatalf("North expected to see only its own course, but found %+v", courses)
+	}
+	if counts := db.GetStudentCountForEachDepartment(southCtx); len(counts) != 1 || counts[0].StudentCount != 1 {
+		t.Fatalf("South expected to count the student of its one department, but got %+v", counts)
+	}
+	if students := db.GetCourseEnrolledStudentsByCourseId(southCtx, northCourse.Id); len(students) != 0 {
+		t.Fatalf("South expected not to see the enrollments of a north course, but found %+v", students)
+	}
+	if departments := db.FindDepartmentSubtree(southCtx, *northStudent.DepartmentId); len(departments) != 0 {
+		t.Fatalf("South expected not to see the subtree of a north department, but found %+v", departments)
+	}
+	if found := db.FindStudentById(northCtx, int(southStudent.Id)); found.Id != 0 {
+		t.Fatalf("North expected not to find south student %d, but found %+v", southStudent.Id, found)
+	}
+	if found := db.FindUserByEmail(southCtx, "registrar@example.com"); found.TenantId == nil || *found.TenantId != south.Id {
+		t.Fatalf("South expected to find its own registrar, but found %+v", found)
+	}
+
+	if err := db.EnrollStudentForCourse(northCtx, northStudent.Id, southCourse.Id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Enrolling for a south course from north expected to fail with ErrRecordNotFound, but got: %v", err)
+	}
+	if err := db.DropStudentFromCourse(southCtx, northStudent.Id, northCourse.Id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Dropping a north enrollment from south expected to fail with ErrRecordNotFound, but got: %v", err)
+	}
+	if err := db.UpdateStudentAge(northCtx, southStudent, 30); err == nil {
+		t.Fatalf("Updating a south student from north expected to fail")
+	}
+	if found := db.FindStudentById(southCtx, int(southStudent.Id)); found.Age != 20 {
+		t.Fatalf("South student must not be updated from north, but is %d years old", found.Age)
+	}
+
+	if err := db.DeleteTenant(f.Ctx, north.Id); err != nil {
+		t.Fatalf("Deleting tenant north failed: %v", err)
+	}
+	if students := db.FindAllStudents(f.Ctx); len(students) != 1 || students[0].Id != southStudent.Id {
+		t.Fatalf("Only the south student expected to remain, but found %+v", students)
+	}
+	if students := db.GetCourseEnrolledStudentsByCourseId(southCtx, southCourse.Id); len(students) != 1 {
+		t.Fatalf("The south enrollment expected to remain, but found %+v", students)
+	}
+	if tenants := db.FindAllTenants(f.Ctx); len(tenants) != 1 || tenants[0].Id != south.Id {
+		t.Fatalf("Only tenant south expected to remain, but found %+v", tenants)
+	}
+}
+
+func TestAdoptUnassignedRows(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withStudents()
+	tenant, err := db.CreateTenant(f.Ctx, db.Tenant{Slug: "main", Name: "Main Campus"})
+	if err != nil {
+		t.Fatalf("Creating a tenant failed: %v", err)
+	}
+	if err := db.AdoptUnassignedRows(f.Ctx, tenant.Id); err != nil {
+		t.Fatalf("Adopting the existing rows failed: %v", err)
+	}
+	if actual := len(db.FindAllStudents(db.WithTenant(f.Ctx, tenant.Id))); actual != len(u.students) {
+		t.Fatalf("The tenant expected to adopt %d students, but has %d", len(u.students), actual)
+	}
+}
+
+// restrictedRole returns a role row-level security applies to, as the test
+// database user may well be a superuser, or "" if it applies to the user.
+// The role is created in the transaction of f and goes with it.
+func restrictedRole(t *testing.T, f *dbtest.Fixture) string {
+	t.Helper()
+	if bypassed, err := db.RowLevelSecurityBypassed(f.Ctx); err != nil || !bypassed {
+		if err != nil {
+			t.Fatalf("Checking the role of the test database user failed: %v", err)
+		}
+		return ""
+	}
+	role := fmt.Sprintf("rls_tenant_%d", os.Getpid())
+	for _, statement := range []string{
+		"CREATE ROLE " + role,
+		"GRANT USAGE ON SCHEMA " + dbtest.Schema + " TO " + role,
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA " + dbtest.Schema + " TO " + role,
+		"GRANT USAGE ON ALL SEQUENCES IN SCHEMA " + dbtest.Schema + " TO " + role,
+	} {
+		if err := db.Conn(f.Ctx).Exec(statement).Error; err != nil {
+			t.Fatalf("Creating a restricted role failed: %v", err)
+		}
+	}
+	return role
+}
+
+// asRole makes the transaction of ctx run as role until the returned
+// function is called.
+func asRole(ctx context.Context, role string) (func(), error) {
+	if role == "" {
+		return func() {}, nil
+	}
+	if err := db.Conn(ctx).Exec("SET LOCAL ROLE " + role).Error; err != nil {
+		return nil, err
+	}
+	return func() { db.Conn(ctx).Exec("RESET ROLE") }, nil
+}
+
+func TestRowLevelSecurityHidesOtherDepartments(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+	role := restrictedRole(t, f)
+
+	faculty := f.Department(func(d *db.Department) { d.Kind = db.KindFaculty })
+	physics := f.Department(func(d *db.Department) { d.ParentId = &faculty.Id })
+	law := f.Department()
+
+	own := f.Student(func(s *db.Student) { s.DepartmentId = &physics.Id })
+	other := f.Student(func(s *db.Student) { s.DepartmentId = &law.Id; s.Age = 21 })
+	f.Instructor(func(i *db.Instructor) { i.DepartmentId = physics.Id })
+	f.Instructor(func(i *db.Instructor) { i.DepartmentId = law.Id })
+	ownCourse := f.Course(func(c *db.Course) { c.DepartmentId = physics.Id })
+	otherCourse := f.Course(func(c *db.Course) { c.DepartmentId = law.Id })
+	f.Enroll(own, ownCourse)
+	f.Enroll(other, otherCourse)
+
+	err := db.WithDepartment(f.Ctx, faculty.Id, func(ctx context.Context) error {
+		reset, err := asRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		defer reset()
+
+		if students := db.FindAllStudents(ctx); len(students) != 1 || students[0].Id != own.Id {
+			t.Errorf("Expected only the student of the faculty, got %v", students)
+		}
+		if found := db.FindStudentById(ctx, int(other.Id)); found.Id != 0 {
+			t.Errorf("Expected the student of another department to be hidden, got %+v", found)
+		}
+		if instructors := db.FindAllInstructors(ctx); len(instructors) != 1 || instructors[0].DepartmentId != physics.Id {
+			t.Errorf("Expected only the instructor of the faculty, got %v", instructors)
+		}
+
+		for _, table := range []string{"students", "courses", "instructors", "enrollments"} {
+			var count int64
+			if err := db.Conn(ctx).Raw(fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&count).Error; err != nil {
+				return err
+			}
+			if count != 1 {
+				t.Errorf("Expected raw SQL to see 1 row of %s, got %d", table, count)
+			}
+		}
+
+		result := db.Conn(ctx).Exec("UPDATE students SET age = 30 WHERE id = ?", other.Id)
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Expected raw SQL not to change the student of another department, got %d rows, %v", result.RowsAffected, result.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Running in the faculty failed: %v", err)
+	}
+
+	err = db.WithDepartment(f.Ctx, faculty.Id, func(ctx context.Context) error {
+		reset, err := asRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		defer reset()
+		_, err = db.CreateStudent(ctx, db.Student{FullName: "Timur Alimov", Age: 19, DepartmentId: &law.Id})
+		return err
+	})
+	if err == nil {
+		t.Fatalf("Expected creating a student in another department to be refused")
+	}
+
+	// Clearing the department does not fall back to every department.
+	err = db.WithDepartment(f.Ctx, faculty.Id, func(ctx context.Context) error {
+		reset, err := asRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		defer reset()
+		if err := db.Conn(ctx).Exec("SELECT set_config(?, '', true)", db.DepartmentSetting).Error; err != nil {
+			return err
+		}
+		if students := db.FindAllStudents(ctx); len(students) != 0 {
+			t.Errorf("Expected no student after clearing the department, got %v", students)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Clearing the department failed: %v", err)
+	}
+
+	if students := db.FindAllStudents(f.Ctx); len(students) != 2 {
+		t.Fatalf("Expected every student outside WithDepartment, got %v", students)
+	}
+	if found := db.FindStudentById(f.Ctx, int(other.Id)); found.Age != 21 {
+		t.Fatalf("Expected the student of another department unchanged, got %+v", found)
+	}
+
+	// Sessions that neither set a department nor bypass the policies see
+	// nothing.
+	reset, err := asRole(f.Ctx, role)
+	if err != nil {
+		t.Fatalf("Switching to the restricted role failed: %v", err)
+	}
+	defer reset()
+	if err := db.Conn(f.Ctx).Exec("SELECT set_config(?, 'off', true)", db.AllDepartmentsSetting).Error; err != nil {
+		t.Fatalf("Turning the bypass off failed: %v", err)
+	}
+	if students := db.FindAllStudents(f.Ctx); len(students) != 0 {
+		t.Fatalf("Expected no student without a department or the bypass, got %v", students)
+	}
+}
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptedFields(t *testing.T) {
+	f := dbtest.New(t)
+
+	// The keys are created outside the transaction of the test and are
+	// deleted with every key another run may have left.
+	deleteKeys := func() {
+		if err := db.Conn(context.Background()).Exec("DELETE FROM encryption_keys").Error; err != nil {
+			t.Errorf("Deleting the encryption keys failed: %v", err)
+		}
+	}
+	deleteKeys()
+	t.Cleanup(deleteKeys)
+
+	// Rows stored before encryption was turned on.
+	u := createUniversity(f).withStudents()
+	if students := db.FindStudentsByCity(f.Ctx, "almaty"); len(students) != 1 {
+		t.Fatalf("Expected the plain text index to find 1 student, found %d", len(students))
+	}
+
+	restore, err := db.UseMasterKey(testMasterKey)
+	if err != nil {
+		t.Fatalf("Creating a keyring failed: %v", err)
+	}
+	defer restore()
+	if err := db.ReencryptRows(f.Ctx, 3, 0, func(string, int) {}); err != nil {
+		t.Fatalf("Encrypting the existing rows failed: %v", err)
+	}
+	stored := func() []string {
+		var names []string
+		db.Conn(f.Ctx).Raw("SELECT full_name FROM students ORDER BY id").Scan(&names)
+		return names
+	}
+	for _, name := range stored() {
+		if !strings.HasPrefix(name, db.CiphertextPrefix) {
+			t.Fatalf("Expected every name to be encrypted, got %q", name)
+		}
+	}
+	first := u.students[0]
+	if student := db.FindStudentById(f.Ctx, int(first.Id)); student.FullName != first.FullName || student.City != first.City {
+		t.Fatalf("Expected the student to be decrypted, got %+v", student)
+	}
+	if students := db.FindStudentsByCity(f.Ctx, "NEW YORK"); len(students) != 1 || students[0].FullName != u.students[3].FullName {
+		t.Fatalf("Expected the blind index to find the student from New York, found %+v", students)
+	}
+
+	key, err := db.RotateEncryptionKey(f.Ctx)
+	if err != nil {
+		t.Fatalf("Rotating the data key failed: %v", err)
+	}
+	student, err := db.CreateStudent(f.Ctx, db.Student{FullName: "Dana Seitkali", Age: 20, City: "Almaty"})
+	if err != nil {
+		t.Fatalf("Creating a student failed: %v", err)
+	}
+	// ReencryptRows waits for the other servers to switch keys; there are none.
+	backdate := "UPDATE encryption_keys SET created_at = created_at - interval '1 hour' WHERE id = ?"
+	if err := db.Conn(context.Background()).Exec(backdate, key.Id).Error; err != nil {
+		t.Fatalf("Backdating key %d failed: %v", key.Id, err)
+	}
+	if err := db.ReencryptRows(f.Ctx, 3, 0, func(string, int) {}); err != nil {
+		t.Fatalf("Re-encrypting the rows failed: %v", err)
+	}
+	for _, name := range stored() {
+		if !strings.HasPrefix(name, fmt.Sprintf("%s%d:", db.CiphertextPrefix, key.Id)) {
+			t.Fatalf("Expected every name to be encrypted with key %d, got %q", key.Id, name)
+		}
+	}
+	if students := db.FindStudentsByCity(f.Ctx, "Almaty"); len(students) != 2 {
+		t.Fatalf("Expected 2 students from Almaty after the rotation, found %d", len(students))
+	}
+
+	newMasterKey := []byte("abcdef0123456789abcdef0123456789")
+	restoreOld, err := db.UseMasterKey(newMasterKey)
+	if err != nil {
+		t.Fatalf("Creating a keyring failed: %v", err)
+	}
+	defer restoreOld()
+	if rewrapped, err := db.RewrapEncryptionKeys(f.Ctx, testMasterKey); err != nil || rewrapped != 3 {
+		t.Fatalf("Expected the 2 data keys and the index key to be rewrapped, got %d, %v", rewrapped, err)
+	}
+	if found := db.FindStudentById(f.Ctx, int(student.Id)); found.FullName != "Dana Seitkali" {
+		t.Fatalf("Expected the student to be decrypted with the new master key, got %+v", found)
+	}
+}
+
+func TestCopyAnonymised(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	u := createUniversity(f).withStudents().withInstructors().withCourses().withEnrollments()
+	f.User(func(user *db.User) { user.Role = "student"; user.StudentId = &u.students[0].Id })
+
+	// The copy goes into a schema of the test database, which the copy
+	// writes to with a connection of its own, outside the transaction.
+	schema := fmt.Sprintf("anonymised_%d", os.Getpid())
+	setup := db.Conn(context.Background())
+	for _, statement := range []string{"DROP SCHEMA IF EXISTS " + schema + " CASCADE", "CREATE SCHEMA " + schema} {
+		if err := setup.Exec(statement).Error; err != nil {
+			t.Fatalf("Preparing the schema of the copy failed: %v", err)
+		}
+	}
+	t.Cleanup(func() { setup.Exec("DROP SCHEMA " + schema + " CASCADE") })
+	targetDSN := dbtest.DSN(t, schema)
+
+	if err := db.CopyAnonymised(f.Ctx, targetDSN, 2, "dev hash", func(string, int) {}); err != nil {
+		t.Fatalf("Copying the database failed: %v", err)
+	}
+	if err := db.CopyAnonymised(f.Ctx, targetDSN, 2, "dev hash", func(string, int) {}); !errors.Is(err, db.ErrTargetNotEmpty) {
+		t.Fatalf("Copying into a database with students expected to fail with ErrTargetNotEmpty, but got: %v", err)
+	}
+
+	var copied []db.Student
+	db.Conn(f.Ctx).Table(schema + ".students").Order("id").Find(&copied)
+	if len(copied) != len(u.students) {
+		t.Fatalf("Expected %d students to be copied, got %d", len(u.students), len(copied))
+	}
+	ages := map[uint]int{}
+	for i, student := range copied {
+		source := u.students[i]
+		if student.FullName == source.FullName || student.City == source.City || student.FullName == "" {
+			t.Fatalf("Expected student %d to get a fake name and city, got %+v", student.Id, student)
+		}
+		if *student.DepartmentId != *source.DepartmentId {
+			t.Fatalf("Expected student %d to stay in department %d, got %d", student.Id, *source.DepartmentId, *student.DepartmentId)
+		}
+		ages[student.Age]++
+		ages[source.Age]--
+	}
+	for age, difference := range ages {
+		if difference != 0 {
+			t.Fatalf("Expected the copy to have as many students aged %d as the source", age)
+		}
+	}
+
+	var enrollments, sourceEnrollments int64
+	db.Conn(f.Ctx).Table(schema + ".enrollments").Count(&enrollments)
+	db.Conn(f.Ctx).Model(&db.Enrollment{}).Count(&sourceEnrollments)
+	if enrollments != sourceEnrollments {
+		t.Fatalf("Expected %d enrollments to be copied, got %d", sourceEnrollments, enrollments)
+	}
+	var user db.User
+	db.Conn(f.Ctx).Table(schema + ".users").First(&user)
+	if user.Email != fmt.Sprintf("user%d@example.com", user.Id) || user.PasswordHash != "dev hash" {
+		t.Fatalf("Expected the user to get a fake email and the given password, got %+v", user)
+	}
+}
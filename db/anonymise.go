@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTargetNotEmpty = errors.New("the target database already has students; copy into an empty database")
+
+// CopyAnonymised copies the database, across every tenant, into the empty
+// database at targetDSN for development, with the personal data replaced:
+//
+//   - names and cities become fake ones, the same real value always becoming
+//     the same fake one, so that cities group and names repeat as before;
+//   - the ages of students and instructors are dealt out again at random
+//     within their department, keeping their distribution;
+//   - emails become user<id>@example.com, and every user gets passwordHash.
+//
+// Ids, departments, courses, enrollments and staff roles are copied as they
+// are. Tokens, API keys and encryption keys are not copied, and the copy is
+// stored in plain text. Rows are read batchSize at a time, and progress is
+// called after each batch is written.
+func CopyAnonymised(ctx context.Context, targetDSN string, batchSize int, passwordHash string, progress func(table string, rows int)) (err error) {
+	ctx, done := track(ctx, "CopyAnonymised")
+	defer done(&err)
+	ctx = withoutTenant(ctx)
+
+	target, err := gorm.Open(postgres.Open(targetDSN), &gorm.Config{Logger: gormLogger{}})
+	if err != nil {
+		return fmt.Errorf("failed to connect target database: %w", err)
+	}
+	if sqlDB, err := target.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	if err := setupJoinTables(target); err != nil {
+		return err
+	}
+	// The copy is written in one transaction that sees every department of
+	// the target, so a failed one leaves it empty.
+	return target.Transaction(func(target *gorm.DB) error {
+		if err := bypassRowLevelSecurity(target); err != nil {
+			return err
+		}
+		return copyAnonymised(ctx, target, batchSize, passwordHash, progress)
+	})
+}
+
+// copyAnonymised is CopyAnonymised into target, a transaction on the target
+// database.
+func copyAnonymised(ctx context.Context, target *gorm.DB, batchSize int, passwordHash string, progress func(table string, rows int)) error {
+	if err := migrate(target); err != nil {
+		return err
+	}
+	var students int64
+	if err := target.Model(&Student{}).Count(&students).Error; err != nil {
+		return err
+	}
+	if students > 0 {
+		return ErrTargetNotEmpty
+	}
+	// Hooks would overwrite the copied timestamps.
+	target = target.Session(&gorm.Session{SkipHooks: true, Context: withPlaintext(ctx)})
+
+	fake := newFakes()
+	// Departments can sit below departments with a higher id, so they are
+	// linked to their parents once all of them are copied.
+	parents := map[uint]uint{}
+	steps := []func() error{
+		func() error { return copyRows(ctx, target, batchSize, progress, func(*Tenant) {}) },
+		func() error {
+			return copyRows(ctx, target, batchSize, progress, func(department *Department) {
+				if department.ParentId != nil {
+					parents[department.Id] = *department.ParentId
+					department.ParentId = nil
+				}
+			})
+		},
+		func() error {
+			for id, parent := range parents {
+				if err := target.Model(&Department{}).Where("id = ?", id).Update("parent_id", parent).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func() error {
+			return copyRows(ctx, target, batchSize, progress, func(instructor *Instructor) {
+				instructor.FullName = fake.name(instructor.FullName)
+				instructor.FullNameIndex = normalise(instructor.FullName)
+			})
+		},
+		func() error { return copyRows(ctx, target, batchSize, progress, func(*Course) {}) },
+		func() error { return copyRows(ctx, target, batchSize, progress, func(*CourseStaff) {}) },
+		func() error {
+			return copyRows(ctx, target, batchSize, progress, func(student *Student) {
+				student.FullName = fake.name(student.FullName)
+				student.FullNameIndex = normalise(student.FullName)
+				student.City = fake.city(student.City)
+				student.CityIndex = normalise(student.City)
+			})
+		},
+		func() error { return copyRows(ctx, target, batchSize, progress, func(*Enrollment) {}) },
+		func() error {
+			return copyRows(ctx, target, batchSize, progress, func(user *User) {
+				user.Email = fmt.Sprintf("user%d@example.com", user.Id)
+				user.PasswordHash = passwordHash
+				user.FailedLogins = 0
+				user.LockedUntil = nil
+			})
+		},
+		func() error { return shuffleAges(target, "students") },
+		func() error { return shuffleAges(target, "instructors") },
+		func() error {
+			return resetSequences(target, "tenants", "departments", "instructors", "courses", "students", "users")
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRows copies the rows of T, soft deleted ones included, to target in
+// primary key order, passing each through anonymise on the way.
+func copyRows[T any](ctx context.Context, target *gorm.DB, batchSize int, progress func(table string, rows int), anonymise func(row *T)) error {
+	stmt := &gorm.Statement{DB: connected().db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return err
+	}
+	var order []clause.OrderByColumn
+	for _, field := range stmt.Schema.PrimaryFields {
+		order = append(order, clause.OrderByColumn{Column: clause.Column{Name: field.DBName}})
+	}
+
+	for offset := 0; ; offset += batchSize {
+		var rows []T
+		err := conn(ctx).Unscoped().Order(clause.OrderBy{Columns: order}).Offset(offset).Limit(batchSize).Find(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", stmt.Schema.Table, err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for i := range rows {
+			anonymise(&rows[i])
+		}
+		if err := target.Omit(clause.Associations).Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to copy %s: %w", stmt.Schema.Table, err)
+		}
+		progress(stmt.Schema.Table, len(rows))
+	}
+}
+
+// shuffleAges deals the ages of the people in table out again at random
+// among those of the same department, so each department keeps its ages but
+// nobody keeps their own.
+func shuffleAges(target *gorm.DB, table string) error {
+	return target.Exec(fmt.Sprintf(`UPDATE %[1]s SET age = shuffled.age FROM (
+	SELECT people.id, ages.age FROM
+		(SELECT id, department_id, ROW_NUMBER() OVER (PARTITION BY department_id ORDER BY id) AS n FROM %[1]s) people
+		INNER JOIN (SELECT age, department_id, ROW_NUMBER() OVER (PARTITION BY department_id ORDER BY random()) AS n FROM %[1]s) ages
+		ON people.department_id IS NOT DISTINCT FROM ages.department_id AND people.n = ages.n
+) shuffled WHERE %[1]s.id = shuffled.id`, table)).Error
+}
+
+// resetSequences moves the id sequences of tables past the copied ids, which
+// were inserted explicitly.
+func resetSequences(target *gorm.DB, tables ...string) error {
+	for _, table := range tables {
+		err := target.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %[1]s", table)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	fakeFirstNames = []string{"Alma", "Boris", "Chen", "Dana", "Emil", "Farida", "Gleb", "Hana", "Ivan", "Jana", "Karim", "Lena", "Marat", "Nina", "Oskar", "Petra", "Rustam", "Sara", "Timur", "Ulla"}
+	fakeLastNames  = []string{"Abbott", "Berg", "Costa", "Dahl", "Eriksen", "Fontaine", "Grant", "Holm", "Ivers", "Jensen", "Kowalski", "Lund", "Moreau", "Novak", "Olsen", "Petrov", "Quist", "Rossi", "Silva", "Torres"}
+	fakeCities     = []string{"Springfield", "Riverton", "Fairview", "Lakeside", "Greenville", "Oakdale", "Millbrook", "Ashford", "Brookfield", "Cedar Falls", "Elmwood", "Hillcrest"}
+)
+
+// fakes hands out fake values, the same for every spelling of a real value
+// and different for different real values. Empty values, e.g. of erased
+// students, stay empty.
+type fakes struct {
+	names, cities map[string]string
+}
+
+func newFakes() *fakes {
+	return &fakes{names: map[string]string{}, cities: map[string]string{}}
+}
+
+func (f *fakes) name(real string) string {
+	return pick(f.names, real, func(n int) string {
+		first, last := n%len(fakeFirstNames), n/len(fakeFirstNames)
+		return fakeFirstNames[first] + " " + numbered(fakeLastNames, last)
+	})
+}
+
+func (f *fakes) city(real string) string {
+	return pick(f.cities, real, func(n int) string { return numbered(fakeCities, n) })
+}
+
+// pick returns the fake value of real, making the next one with fake when
+// real is new.
+func pick(seen map[string]string, real string, fake func(n int) string) string {
+	key := normalise(real)
+	if key == "" {
+		return ""
+	}
+	if value, ok := seen[key]; ok {
+		return value
+	}
+	value := fake(len(seen))
+	seen[key] = value
+	return value
+}
+
+// numbered returns the n-th value of values, numbering them once they run
+// out, e.g. "Riverton 2".
+func numbered(values []string, n int) string {
+	value := values[n%len(values)]
+	if round := n / len(values); round > 0 {
+		value += " " + strconv.Itoa(round+1)
+	}
+	return value
+}
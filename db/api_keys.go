@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"exercise1/validation"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey lets a batch job or partner system call the HTTP API without a
+// user. Like tokens, only the SHA-256 hash of the key is stored; Prefix, the
+// first characters of the key, tells keys apart in listings.
+type APIKey struct {
+	Id      uint   `gorm:"primaryKey"`
+	Name    string `gorm:"not null"`
+	Prefix  string `gorm:"not null"`
+	KeyHash string `gorm:"uniqueIndex;not null" json:"-"`
+	// Scopes are separated by spaces, like those of OAuth, see the access
+	// package.
+	Scopes string `gorm:"not null"`
+	// RateLimit is the number of requests per minute the key may make; 0
+	// means the configured default.
+	RateLimit  uint
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	TenantId   *uint `gorm:"index" json:"-"`
+}
+
+const (
+	scopePattern  = `((read|write):(students|courses|departments|instructors)|write:enrollments|read:reports)`
+	scopesPattern = `^` + scopePattern + `( ` + scopePattern + `)*$`
+)
+
+func apiKeySchema() validation.Schema {
+	return validation.Schema{
+		validation.F("Name", validation.Required(), validation.Length(2, 100)),
+		validation.F("Scopes", validation.Required(), validation.Matches(scopesPattern, "be known scopes such as read:students, separated by spaces")),
+		validation.F("RateLimit", validation.Range(0, 1000000)),
+	}
+}
+
+func CreateAPIKey(ctx context.Context, key APIKey) (_ APIKey, err error) {
+	ctx, done := track(ctx, "CreateAPIKey")
+	defer done(&err)
+	if err := apiKeySchema().Validate(key); err != nil {
+		return APIKey{}, err
+	}
+	err = conn(ctx).Create(&key).Error
+	return key, err
+}
+
+func FindAPIKeyById(ctx context.Context, id int) APIKey {
+	key := APIKey{}
+	var err error
+	ctx, done := track(ctx, "FindAPIKeyById")
+	defer done(&err)
+	err = conn(ctx).First(&key, id).Error
+	return key
+}
+
+func FindAPIKey(ctx context.Context, keyHash string) APIKey {
+	key := APIKey{}
+	var err error
+	ctx, done := track(ctx, "FindAPIKey")
+	defer done(&err)
+	err = conn(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	return key
+}
+
+func FindAllAPIKeys(ctx context.Context) []APIKey {
+	var keys []APIKey
+	var err error
+	ctx, done := track(ctx, "FindAllAPIKeys")
+	defer done(&err)
+	err = conn(ctx).Order("id").Find(&keys).Error
+	return keys
+}
+
+// RevokeAPIKey revokes the key. It returns gorm.ErrRecordNotFound when there
+// is no such key or it was already revoked.
+func RevokeAPIKey(ctx context.Context, id uint, at time.Time) (err error) {
+	ctx, done := track(ctx, "RevokeAPIKey")
+	defer done(&err)
+	result := conn(ctx).Model(&APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RotateAPIKey creates replacement, a key with the name, scopes, rate limit
+// and expiry of the key with id, and lets the old key expire at
+// oldExpiresAt, unless it expires earlier anyway, so clients can switch
+// over in the meantime.
+func RotateAPIKey(ctx context.Context, id uint, replacement APIKey, oldExpiresAt time.Time) (_ APIKey, err error) {
+	ctx, done := track(ctx, "RotateAPIKey")
+	defer done(&err)
+	err = conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var old APIKey
+		if err := tx.Where("revoked_at IS NULL").First(&old, id).Error; err != nil {
+			return err
+		}
+		replacement.Name, replacement.Scopes, replacement.RateLimit, replacement.ExpiresAt = old.Name, old.Scopes, old.RateLimit, old.ExpiresAt
+		if err := tx.Create(&replacement).Error; err != nil {
+			return err
+		}
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(oldExpiresAt) {
+			return nil
+		}
+		return tx.Model(&old).Update("expires_at", oldExpiresAt).Error
+	})
+	if err != nil {
+		return APIKey{}, err
+	}
+	return replacement, nil
+}
+
+// TouchAPIKey records that the key was used at the given time. To spare the
+// database a write per request, it only does so when the last recorded use
+// is older than a minute.
+func TouchAPIKey(ctx context.Context, id uint, at time.Time) (err error) {
+	ctx, done := track(ctx, "TouchAPIKey")
+	defer done(&err)
+	return conn(ctx).Model(&APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-time.Minute)).
+		Update("last_used_at", at).Error
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindInBatches loads the rows of T matching the optional conditions in
+// batches of batchSize, ordered by primary key, and hands each batch to fn so
+// large tables never have to be held in memory at once. Returning an error
+// from fn stops the iteration.
+func FindInBatches[T any](ctx context.Context, batchSize int, fn func(batch []T) error, conds ...interface{}) error {
+	var batch []T
+	query := conn(ctx).Model(new(T))
+	if len(conds) > 0 {
+		query = query.Where(conds[0], conds[1:]...)
+	}
+	result := query.FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(batch)
+	})
+	return result.Error
+}
+
+// FindWhereIn loads the rows of T whose column holds any of values, e.g. all
+// students of several departments in one query.
+func FindWhereIn[T any](ctx context.Context, column string, values []uint) []T {
+	var rows []T
+	if len(values) == 0 {
+		return rows
+	}
+	conn(ctx).Where(column+" IN ?", values).Find(&rows)
+	return rows
+}
+
+// CreateInBatches inserts rows batchSize at a time and fills in their ids.
+// It skips the validation of the Create functions, so the caller has to
+// keep to the rules of the models itself; it is meant for bulk loads like
+// those of the seed package.
+func CreateInBatches[T any](ctx context.Context, rows []T, batchSize int) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return conn(ctx).Omit(clause.Associations).CreateInBatches(rows, batchSize).Error
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// aroundStatements registers before and after as callbacks around every kind
+// of statement GORM runs. before is given the kind of statement, e.g.
+// "query", and the callbacks are named after plugin.
+func aroundStatements(d *gorm.DB, plugin string, before func(kind string) func(*gorm.DB), after func(*gorm.DB)) error {
+	c := d.Callback()
+	return errors.Join(
+		c.Create().Before("gorm:create").Register(plugin+":before_create", before("create")),
+		c.Create().After("gorm:create").Register(plugin+":after_create", after),
+		c.Query().Before("gorm:query").Register(plugin+":before_query", before("query")),
+		c.Query().After("gorm:query").Register(plugin+":after_query", after),
+		c.Update().Before("gorm:update").Register(plugin+":before_update", before("update")),
+		c.Update().After("gorm:update").Register(plugin+":after_update", after),
+		c.Delete().Before("gorm:delete").Register(plugin+":before_delete", before("delete")),
+		c.Delete().After("gorm:delete").Register(plugin+":after_delete", after),
+		c.Row().Before("gorm:row").Register(plugin+":before_row", before("row")),
+		c.Row().After("gorm:row").Register(plugin+":after_row", after),
+		c.Raw().Before("gorm:raw").Register(plugin+":before_raw", before("raw")),
+		c.Raw().After("gorm:raw").Register(plugin+":after_raw", after),
+	)
+}
@@ -1,24 +1,357 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var db *gorm.DB
+// connection is what ConnectWithOptions opens: the pool and the keys of
+// encryption.go. It is replaced as a whole, so that queries running while
+// the process reconnects or closes see either the old one or the new one.
+type connection struct {
+	db *gorm.DB
+	// keys is nil unless a master key is configured.
+	keys *keyring
+}
+
+var current atomic.Pointer[connection]
+
+// connected returns the current connection, with nil fields when there is
+// none.
+func connected() connection {
+	if c := current.Load(); c != nil {
+		return *c
+	}
+	return connection{}
+}
+
+var ErrNotConnected = errors.New("database is not connected")
+
+// Options configure the connection pool and how often Connect tries to reach
+// the database before giving up.
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Attempts is the number of times opening the database is tried. The wait
+	// after a failed attempt starts at InitialBackoff and doubles up to
+	// MaxBackoff.
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// SlowQueryThreshold is how long a statement may take before it is
+	// logged as slow; 0 disables the check.
+	SlowQueryThreshold time.Duration
+
+	// MasterKey encrypts the keys encrypting personal data, see
+	// encryption.go. Without it personal data is stored in plain text.
+	MasterKey []byte
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+		Attempts:        5,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      10 * time.Second,
+
+		SlowQueryThreshold: 200 * time.Millisecond,
+	}
+}
+
+// Connect opens the database with the default options.
+func Connect(dsn string) error {
+	return ConnectWithOptions(context.Background(), dsn, DefaultOptions())
+}
+
+// ConnectWithOptions opens the database, retrying with exponential backoff
+// while it is unreachable, e.g. because it is still starting up next to the
+// application. It gives up after opts.Attempts tries or when ctx is done.
+func ConnectWithOptions(ctx context.Context, dsn string, opts Options) error {
+	attempts := opts.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var keys *keyring
+	if opts.MasterKey != nil {
+		var err error
+		if keys, err = newKeyring(opts.MasterKey); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var d *gorm.DB
+		if d, err = dial(dsn, opts); err == nil {
+			if previous := current.Swap(&connection{db: d, keys: keys}); previous != nil {
+				closePool(previous.db)
+			}
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		wait := backoff(attempt, opts.InitialBackoff, opts.MaxBackoff)
+		logger.WarnContext(ctx, "database not reachable, retrying", "attempt", attempt, "attempts", attempts, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to connect database: %w", ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+	return fmt.Errorf("failed to connect database after %d attempts: %w", attempts, err)
+}
+
+// dial opens the database at dsn with the plugins of the package. If that
+// fails, the pool it may have opened is closed again.
+func dial(dsn string, opts Options) (d *gorm.DB, err error) {
+	d, err = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger{}})
+	defer func() {
+		if err != nil && d != nil {
+			closePool(d)
+		}
+	}()
+	if err != nil {
+		return d, err
+	}
+	if err = d.Use(tracingPlugin{}); err != nil {
+		return d, err
+	}
+	if err = d.Use(queryLogger{threshold: opts.SlowQueryThreshold}); err != nil {
+		return d, err
+	}
+	if err = d.Use(tenantPlugin{}); err != nil {
+		return d, err
+	}
+	if err = d.Use(encryptionPlugin{}); err != nil {
+		return d, err
+	}
+	if err = setupJoinTables(d); err != nil {
+		return d, err
+	}
+	return d, configurePool(d, opts)
+}
+
+// closePool closes the connection pool of d, which gorm.Open opens even when
+// it fails to reach the database.
+func closePool(d *gorm.DB) error {
+	if d.Config == nil || d.ConnPool == nil {
+		return nil
+	}
+	sqlDB, err := d.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// backoff returns the wait after the given failed attempt, counting from 1.
+func backoff(attempt int, initial, max time.Duration) time.Duration {
+	wait := initial
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if max > 0 && wait > max {
+		wait = max
+	}
+	return wait
+}
+
+func configurePool(d *gorm.DB, opts Options) error {
+	sqlDB, err := d.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	return nil
+}
+
+// Conn returns the connection opened by Connect bound to ctx, for packages
+// such as report that build their own queries.
+func Conn(ctx context.Context) *gorm.DB {
+	return conn(ctx)
+}
+
+// conn returns the connection to run the queries of ctx with, so they are
+// cancelled with it and traced as part of it. Inside WithDepartment or
+// AllDepartments that is the transaction it started.
+func conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return connected().db.WithContext(ctx)
+}
+
+// Ping checks that the database answers.
+func Ping(ctx context.Context) error {
+	d := connected().db
+	if d == nil {
+		return ErrNotConnected
+	}
+	sqlDB, err := d.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Live reports whether the process is healthy as far as the database is
+// concerned: it has been connected and not closed. It deliberately does not
+// query the database, so that a database outage makes the process unready
+// instead of getting it restarted.
+func Live() error {
+	if current.Load() == nil {
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// Ready reports whether the process can serve requests, i.e. whether the
+// database answers within ctx.
+func Ready(ctx context.Context) error {
+	if err := Live(); err != nil {
+		return err
+	}
+	return Ping(ctx)
+}
+
+// Stats returns the statistics of the connection pool.
+func Stats() sql.DBStats {
+	d := connected().db
+	if d == nil {
+		return sql.DBStats{}
+	}
+	sqlDB, err := d.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// Close waits for running queries to finish and closes the connection pool.
+// Closing when not connected does nothing.
+func Close() error {
+	previous := current.Swap(nil)
+	if previous == nil {
+		return nil
+	}
+	return closePool(previous.db)
+}
 
-func Connect(dsn string) {
-	d, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// setupJoinTables makes GORM write enrollments through the Enrollment model,
+// so they get a tenant like the students and courses they join.
+func setupJoinTables(d *gorm.DB) error {
+	return errors.Join(
+		d.SetupJoinTable(&Student{}, "Courses", &Enrollment{}),
+		d.SetupJoinTable(&Course{}, "Students", &Enrollment{}),
+	)
+}
+
+// allModels are the models MigrateAllTables creates the tables of.
+var allModels = []interface{}{&Tenant{}, &Department{}, &Student{}, &Course{}, &Instructor{}, &CourseStaff{}, &User{}, &RefreshToken{}, &PasswordResetToken{}, &APIKey{}, &EncryptionKey{}}
+
+// MigrateAllTables creates or updates the tables of every model.
+func MigrateAllTables() error {
+	d := connected().db
+	if d == nil {
+		return ErrNotConnected
+	}
+	err := d.Transaction(func(tx *gorm.DB) error {
+		if err := bypassRowLevelSecurity(tx); err != nil {
+			return err
+		}
+		return migrate(tx)
+	})
 	if err != nil {
-		panic("failed to connect database")
+		return fmt.Errorf("failed to migrate the tables: %w", err)
+	}
+	return nil
+}
+
+// migrationLock is the Postgres advisory lock Migrate holds.
+const migrationLock = 0x65786572
+
+// Migrate is MigrateAllTables for processes that may start at the same
+// time against one database, like test binaries: it migrates in a
+// transaction holding an advisory lock, and returns what fails.
+func Migrate(ctx context.Context) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+			return err
+		}
+		if err := bypassRowLevelSecurity(tx); err != nil {
+			return err
+		}
+		return migrate(tx)
+	})
+}
+
+// migrate creates or updates the tables of every model in d, which has its
+// join tables set up and sees the rows of every department.
+func migrate(d *gorm.DB) error {
+	if err := nullMissingReferences(d); err != nil {
+		return err
+	}
+	if err := d.AutoMigrate(allModels...); err != nil {
+		return err
 	}
-	db = d
+	// Emails used to be unique across the database; now they are unique per
+	// tenant.
+	if d.Migrator().HasIndex(&User{}, "idx_users_email") {
+		if err := d.Migrator().DropIndex(&User{}, "idx_users_email"); err != nil {
+			return fmt.Errorf("failed to drop the index of emails across tenants: %w", err)
+		}
+	}
+	if err := enableRowLevelSecurity(d); err != nil {
+		return fmt.Errorf("failed to enable row-level security: %w", err)
+	}
+	return nil
 }
 
-func MigrateAllTables() {
-	db.AutoMigrate(&Department{}, &Student{}, &Course{}, &Instructor{})
+// optionalReferences lists the foreign keys that were 0 when unset before
+// they became optional.
+var optionalReferences = []struct {
+	table, column string
+}{
+	{"courses", "instructor_id"},
+	{"students", "department_id"},
+}
+
+// nullMissingReferences turns the unset foreign keys of tables from before
+// they were optional from 0 into NULL, as Postgres would otherwise refuse to
+// add their foreign key constraints.
+func nullMissingReferences(d *gorm.DB) error {
+	for _, reference := range optionalReferences {
+		if !d.Migrator().HasColumn(reference.table, reference.column) {
+			continue
+		}
+		err := d.Exec(fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %[2]s = 0", reference.table, reference.column)).Error
+		if err != nil {
+			return fmt.Errorf("failed to clear unset %s.%s: %w", reference.table, reference.column, err)
+		}
+	}
+	return nil
 }
 
 func MigrateTable(table interface{}) {
-	db.AutoMigrate(&table)
+	connected().db.AutoMigrate(&table)
 }
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"exercise1/validation"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func courseStaffSchema(ctx context.Context) validation.Schema {
+	return validation.Schema{
+		validation.F("CourseId", validation.Required(), exists(ctx, &Course{}, "course")),
+		validation.F("InstructorId", validation.Required(), exists(ctx, &Instructor{}, "instructor")),
+		validation.F("Role", validation.Required(), validation.Matches("^(lead|co-instructor|ta|grader)$", "be one of lead, co-instructor, ta or grader")),
+	}
+}
+
+// AddCourseStaff gives the instructor a role in the course, replacing the
+// role they had there before. Roles counting towards the teaching load are
+// checked against the limit of the instructor.
+func AddCourseStaff(ctx context.Context, courseId, instructorId uint, role string) error {
+	staff := CourseStaff{CourseId: courseId, InstructorId: instructorId, Role: role}
+	if err := courseStaffSchema(ctx).Validate(staff); err != nil {
+		return err
+	}
+	return Transaction(ctx, func(ctx context.Context) error {
+		if slices.Contains(loadRoles, role) {
+			course := FindCourseById(ctx, int(courseId))
+			if err := checkTeachingLoad(ctx, instructorId, course, countEnrolledStudents(ctx, courseId)); err != nil {
+				return err
+			}
+		}
+		return conn(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "course_id"}, {Name: "instructor_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role"}),
+		}).Omit("Instructor").Create(&staff).Error
+	})
+}
+
+func RemoveCourseStaff(ctx context.Context, courseId, instructorId uint) error {
+	result := conn(ctx).Where("course_id = ? AND instructor_id = ?", courseId, instructorId).Delete(&CourseStaff{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindCourseStaff returns the staff of the course with their instructors. The
+// primary instructor is listed as RoleLead unless they also have a staff row.
+func FindCourseStaff(ctx context.Context, courseId uint) []CourseStaff {
+	var staff []CourseStaff
+	conn(ctx).Where("course_id = ?", courseId).Preload("Instructor").Order("instructor_id").Find(&staff)
+
+	course := FindCourseById(ctx, int(courseId))
+	if course.InstructorId == nil {
+		return staff
+	}
+	for _, member := range staff {
+		if member.InstructorId == *course.InstructorId {
+			return staff
+		}
+	}
+	primary := CourseStaff{
+		CourseId:     courseId,
+		InstructorId: *course.InstructorId,
+		Instructor:   FindInstructorById(ctx, int(*course.InstructorId)),
+		Role:         RoleLead,
+	}
+	return append([]CourseStaff{primary}, staff...)
+}
+
+// instructorCourseIds is a subquery selecting the ids of the courses where
+// the instructor has one of roles. Without roles only the courses they are
+// the primary instructor of are selected.
+func instructorCourseIds(ctx context.Context, instructorId uint, roles []string) *gorm.DB {
+	if len(roles) == 0 {
+		return conn(ctx).Model(&Course{}).Select("id").Where("instructor_id = ?", instructorId)
+	}
+	staffCourses := conn(ctx).Model(&CourseStaff{}).Select("course_id").Where("instructor_id = ? AND role IN ?", instructorId, roles)
+	if slices.Contains(roles, RoleLead) {
+		return conn(ctx).Model(&Course{}).Select("id").Where("instructor_id = ? OR id IN (?)", instructorId, staffCourses)
+	}
+	return conn(ctx).Model(&Course{}).Select("id").Where("id IN (?)", staffCourses)
+}
@@ -1,35 +1,83 @@
 package db
 
-func CreateStudent(student Student) {
-	db.Create(&student)
+import (
+	"context"
+	"exercise1/validation"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateStudent(ctx context.Context, student Student) (_ Student, err error) {
+	ctx, done := track(ctx, "CreateStudent")
+	defer done(&err)
+	if err := studentSchema(ctx).Validate(student); err != nil {
+		return Student{}, err
+	}
+	if err = conn(ctx).Create(&student).Error; err != nil {
+		return Student{}, err
+	}
+	studentsCreatedTotal.Inc()
+	return student, nil
 }
 
-func FindAllStudents() []Student {
+func FindAllStudents(ctx context.Context) []Student {
 	var students []Student
-	db.Find(&students)
+	var err error
+	ctx, done := track(ctx, "FindAllStudents")
+	defer done(&err)
+	err = conn(ctx).Find(&students).Error
 	return students
 }
 
-func FindStudentById(id int) Student {
+func FindStudentById(ctx context.Context, id int) Student {
 	student := Student{}
-	db.First(&student, id)
+	var err error
+	ctx, done := track(ctx, "FindStudentById")
+	defer done(&err)
+	err = conn(ctx).First(&student, id).Error
 	return student
 }
 
-func FindAllStudentsByDepartmentId(departmentId uint) []Student {
+func FindAllStudentsByDepartmentId(ctx context.Context, departmentId uint) []Student {
 	var students []Student
-	db.Where("department_id = ?", departmentId).Find(&students)
+	var err error
+	ctx, done := track(ctx, "FindAllStudentsByDepartmentId")
+	defer done(&err)
+	err = conn(ctx).Where("department_id = ?", departmentId).Find(&students).Error
 	return students
 }
 
-func FindStudentsByAge(age int) []Student {
+func FindStudentsByAge(ctx context.Context, age int) []Student {
 	var students []Student
-	db.Where("age = ?", age).Find(&students)
+	var err error
+	ctx, done := track(ctx, "FindStudentsByAge")
+	defer done(&err)
+	err = conn(ctx).Where("age = ?", age).Find(&students).Error
+	return students
+}
+
+// FindStudentsByCity returns the students living in city, ignoring case and
+// spacing, looked up by the blind index of their encrypted city.
+func FindStudentsByCity(ctx context.Context, city string) []Student {
+	var students []Student
+	var err error
+	ctx, done := track(ctx, "FindStudentsByCity")
+	defer done(&err)
+	index, err := blindIndex(ctx, "city", city)
+	if err != nil {
+		return students
+	}
+	err = conn(ctx).Where("city_index = ?", index).Find(&students).Error
 	return students
 }
 
-func GetStudentEnrolledCoursesByStudentId(studentId uint) []Course {
+func GetStudentEnrolledCoursesByStudentId(ctx context.Context, studentId uint) []Course {
 	var student Student
+	var err error
+	ctx, done := track(ctx, "GetStudentEnrolledCoursesByStudentId")
+	defer done(&err)
 	//SELECT courses.id, courses.name, courses.department_id, courses.instructor_id
 	//FROM students
 	//INNER JOIN enrollments
@@ -37,130 +85,346 @@ func GetStudentEnrolledCoursesByStudentId(studentId uint) []Course {
 	//INNER JOIN courses
 	//ON courses.id = enrollments.course_id
 	// WHERE students.id = ?
-	db.Model(&Student{}).Where("id = ?", studentId).Preload("Courses").First(&student)
+	err = conn(ctx).Model(&Student{}).Where("id = ?", studentId).Preload("Courses").First(&student).Error
 	return student.Courses
 }
 
-func UpdateStudentAge(student Student, age int) {
-	db.Model(&student).Update("Age", age)
+func UpdateStudentAge(ctx context.Context, student Student, age int) (err error) {
+	ctx, done := track(ctx, "UpdateStudentAge")
+	defer done(&err)
+	ageSchema := validation.Schema{validation.F("Age", validation.Required(), validation.Range(16, 100))}
+	if err := ageSchema.Validate(struct{ Age int }{age}); err != nil {
+		return err
+	}
+	result := conn(ctx).Model(&student).Where("version = ?", student.Version).
+		Updates(map[string]interface{}{"age": age, "version": gorm.Expr("version + 1")})
+	return checkVersionedUpdate(ctx, result, &Student{}, "student", student.Id, student.Version)
 }
 
-func DeleteStudent(student Student) {
-	db.Delete(&student)
+func DeleteStudent(ctx context.Context, student Student) (err error) {
+	ctx, done := track(ctx, "DeleteStudent")
+	defer done(&err)
+	return conn(ctx).Delete(&student).Error
 }
 
 // COURSES
-func CreateCourse(course Course) {
-	db.Create(&course)
+func CreateCourse(ctx context.Context, course Course) (_ Course, err error) {
+	ctx, done := track(ctx, "CreateCourse")
+	defer done(&err)
+	if err := courseSchema(ctx).Validate(course); err != nil {
+		return Course{}, err
+	}
+	err = Transaction(ctx, func(ctx context.Context) error {
+		if course.InstructorId != nil {
+			if err := checkTeachingLoad(ctx, *course.InstructorId, course, 0); err != nil {
+				return err
+			}
+		}
+		return conn(ctx).Create(&course).Error
+	})
+	if err != nil {
+		return Course{}, err
+	}
+	return course, nil
 }
 
-func FindAllCourses() []Course {
+func FindAllCourses(ctx context.Context) []Course {
 	var courses []Course
-	db.Find(&courses)
+	var err error
+	ctx, done := track(ctx, "FindAllCourses")
+	defer done(&err)
+	err = conn(ctx).Find(&courses).Error
 	return courses
 }
 
-func FindAllCoursesByInstructorId(instructorId uint) []Course {
+// FindAllCoursesByInstructorId returns the courses the instructor is the
+// primary instructor of. When roles are given it instead returns the courses
+// where the instructor has any of them, the primary instructor counting as
+// RoleLead; pass StaffRoles to include every role.
+func FindAllCoursesByInstructorId(ctx context.Context, instructorId uint, roles ...string) []Course {
 	var courses []Course
-	db.Where("instructor_id = ?", instructorId).Find(&courses)
+	var err error
+	ctx, done := track(ctx, "FindAllCoursesByInstructorId")
+	defer done(&err)
+	if len(roles) == 0 {
+		err = conn(ctx).Where("instructor_id = ?", instructorId).Find(&courses).Error
+		return courses
+	}
+	err = conn(ctx).Where("id IN (?)", instructorCourseIds(ctx, instructorId, roles)).Find(&courses).Error
 	return courses
 }
 
-func FindCourseById(id int) Course {
+// FindCoursesWithoutInstructor returns the courses nobody has been assigned
+// to teach yet.
+func FindCoursesWithoutInstructor(ctx context.Context) []Course {
+	var courses []Course
+	var err error
+	ctx, done := track(ctx, "FindCoursesWithoutInstructor")
+	defer done(&err)
+	err = conn(ctx).Where("instructor_id IS NULL").Find(&courses).Error
+	return courses
+}
+
+func FindCourseById(ctx context.Context, id int) Course {
 	course := Course{}
-	db.First(&course, id)
+	var err error
+	ctx, done := track(ctx, "FindCourseById")
+	defer done(&err)
+	err = conn(ctx).First(&course, id).Error
 	return course
 }
 
-func GetCourseEnrolledStudentsByCourseId(courseId uint) []Student {
+func GetCourseEnrolledStudentsByCourseId(ctx context.Context, courseId uint) []Student {
 	var course Course
-	db.Model(&Course{}).Where("id = ?", courseId).Preload("Students").First(&course)
+	var err error
+	ctx, done := track(ctx, "GetCourseEnrolledStudentsByCourseId")
+	defer done(&err)
+	err = conn(ctx).Model(&Course{}).Where("id = ?", courseId).Preload("Students").First(&course).Error
 	return course.Students
 }
 
-func UpdateCourse(course Course, courseWithUpdatedFields Course) {
-	db.Model(&course).Updates(&courseWithUpdatedFields)
+func UpdateCourse(ctx context.Context, course Course, courseWithUpdatedFields Course) (err error) {
+	ctx, done := track(ctx, "UpdateCourse")
+	defer done(&err)
+	if err := courseSchema(ctx).ValidatePartial(courseWithUpdatedFields); err != nil {
+		return err
+	}
+	return Transaction(ctx, func(ctx context.Context) error {
+		if err := checkUpdatedCourseLoad(ctx, course, courseWithUpdatedFields); err != nil {
+			return err
+		}
+		columns := updatedColumns(ctx, courseWithUpdatedFields, "Name", "DepartmentId", "InstructorId", "Credits", "Term")
+		result := conn(ctx).Model(&course).Where("version = ?", course.Version).Updates(columns)
+		return checkVersionedUpdate(ctx, result, &Course{}, "course", course.Id, course.Version)
+	})
+}
+
+func AssignInstructor(ctx context.Context, courseId, instructorId uint) (err error) {
+	ctx, done := track(ctx, "AssignInstructor")
+	defer done(&err)
+	if err := courseSchema(ctx).ValidatePartial(Course{InstructorId: &instructorId}); err != nil {
+		return err
+	}
+	course := FindCourseById(ctx, int(courseId))
+	if course.Id == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return Transaction(ctx, func(ctx context.Context) error {
+		if err := checkTeachingLoad(ctx, instructorId, course, countEnrolledStudents(ctx, course.Id)); err != nil {
+			return err
+		}
+		return setCourseInstructor(ctx, courseId, instructorId)
+	})
+}
+
+// checkUpdatedCourseLoad re-checks the teaching load of everyone teaching the
+// course when an update changes its primary instructor, its credits or its
+// term.
+func checkUpdatedCourseLoad(ctx context.Context, course Course, courseWithUpdatedFields Course) error {
+	if courseWithUpdatedFields.InstructorId == nil && courseWithUpdatedFields.Credits == 0 && courseWithUpdatedFields.Term == "" {
+		return nil
+	}
+	updated := FindCourseById(ctx, int(course.Id))
+	if courseWithUpdatedFields.InstructorId != nil {
+		updated.InstructorId = courseWithUpdatedFields.InstructorId
+	}
+	if courseWithUpdatedFields.Credits != 0 {
+		updated.Credits = courseWithUpdatedFields.Credits
+	}
+	if courseWithUpdatedFields.Term != "" {
+		updated.Term = courseWithUpdatedFields.Term
+	}
+	return checkCourseLoads(ctx, updated)
+}
+
+func UnassignInstructor(ctx context.Context, courseId uint) (err error) {
+	ctx, done := track(ctx, "UnassignInstructor")
+	defer done(&err)
+	return setCourseInstructor(ctx, courseId, nil)
+}
+
+func setCourseInstructor(ctx context.Context, courseId uint, instructorId interface{}) error {
+	result := conn(ctx).Model(&Course{}).Where("id = ?", courseId).
+		Updates(map[string]interface{}{"instructor_id": instructorId, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-func DeleteCourse(course Course) {
-	db.Delete(&course)
+func DeleteCourse(ctx context.Context, course Course) (err error) {
+	ctx, done := track(ctx, "DeleteCourse")
+	defer done(&err)
+	return conn(ctx).Delete(&course).Error
 }
 
 // DEPARTMENT
-func CreateDepartment(department Department) {
-	db.Create(&department)
+func CreateDepartment(ctx context.Context, department Department) (_ Department, err error) {
+	ctx, done := track(ctx, "CreateDepartment")
+	defer done(&err)
+	if err := departmentSchema(ctx, 0).Validate(department); err != nil {
+		return Department{}, err
+	}
+	err = conn(ctx).Create(&department).Error
+	return department, err
 }
 
-func FindAllDepartments() []Department {
+func FindAllDepartments(ctx context.Context) []Department {
 	var departments []Department
-	db.Find(&departments)
+	var err error
+	ctx, done := track(ctx, "FindAllDepartments")
+	defer done(&err)
+	err = conn(ctx).Find(&departments).Error
 	return departments
 }
 
-func FindDepartmentById(id int) Department {
+func FindDepartmentById(ctx context.Context, id int) Department {
 	department := Department{}
-	db.First(&department, id)
+	var err error
+	ctx, done := track(ctx, "FindDepartmentById")
+	defer done(&err)
+	err = conn(ctx).First(&department, id).Error
 	return department
 }
 
-func UpdateDepartment(department Department, departmentWithUpdatedFields Department) {
-	db.Model(&department).Updates(&departmentWithUpdatedFields)
+func UpdateDepartment(ctx context.Context, department Department, departmentWithUpdatedFields Department) (err error) {
+	ctx, done := track(ctx, "UpdateDepartment")
+	defer done(&err)
+	if err := departmentSchema(ctx, department.Id).ValidatePartial(departmentWithUpdatedFields); err != nil {
+		return err
+	}
+	// Moves have to be checked for cycles.
+	if departmentWithUpdatedFields.ParentId != nil {
+		return validation.Errors{{Field: "ParentId", Message: "can only be changed by moving the department"}}
+	}
+	columns := updatedColumns(ctx, departmentWithUpdatedFields, "Name", "Kind", "MaxTeachingLoad")
+	result := conn(ctx).Model(&department).Where("version = ?", department.Version).Updates(columns)
+	return checkVersionedUpdate(ctx, result, &Department{}, "department", department.Id, department.Version)
 }
 
-func DeleteDepartment(department Department) {
-	db.Delete(&department)
+func DeleteDepartment(ctx context.Context, department Department) (err error) {
+	ctx, done := track(ctx, "DeleteDepartment")
+	defer done(&err)
+	return conn(ctx).Delete(&department).Error
 }
 
 //Enrollment
 
-func EnrollStudentForCourse(studentId, courseId uint) error {
-	tx := db.Begin()
-
-	var student Student
-	if err := tx.First(&student, studentId).Error; err != nil {
-		tx.Rollback()
+func EnrollStudentForCourse(ctx context.Context, studentId, courseId uint) (err error) {
+	ctx, done := track(ctx, "EnrollStudentForCourse")
+	defer done(&err)
+	// Transaction nests as a savepoint inside WithDepartment, where Begin fails.
+	err = Transaction(ctx, func(ctx context.Context) error {
+		tx := conn(ctx)
+		var student Student
+		if err := tx.Select("id").First(&student, studentId).Error; err != nil {
+			return err
+		}
+
+		var course Course
+		if err := tx.First(&course, courseId).Error; err != nil {
+			return err
+		}
+
+		// Only the enrollment is written: saving the student would overwrite
+		// concurrent changes to it with the row read above.
+		enrollment := Enrollment{StudentId: student.Id, CourseId: course.Id}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&enrollment).Error; err != nil {
+			return err
+		}
+		// Only the student completing a group adds to the load of the course.
+		if countEnrolledStudents(ctx, course.Id)%studentsPerExtraLoadUnit != 0 {
+			return nil
+		}
+		return checkCourseLoads(ctx, course)
+	})
+	if err != nil {
 		return err
 	}
+	enrollmentsTotal.Inc()
+	return nil
+}
 
-	var course Course
-	if err := tx.First(&course, courseId).Error; err != nil {
-		tx.Rollback()
-		return err
+// DropStudentFromCourse removes the student from the course. It returns
+// gorm.ErrRecordNotFound when the student is not enrolled for it.
+func DropStudentFromCourse(ctx context.Context, studentId, courseId uint) (err error) {
+	ctx, done := track(ctx, "DropStudentFromCourse")
+	defer done(&err)
+	result := conn(ctx).Where("student_id = ? AND course_id = ?", studentId, courseId).Delete(&Enrollment{})
+	if result.Error != nil {
+		return result.Error
 	}
-
-	student.Courses = append(student.Courses, course)
-
-	if err := tx.Save(&student).Error; err != nil {
-		tx.Rollback()
-		return err
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
-
-	return tx.Commit().Error
+	dropsTotal.Inc()
+	return nil
 }
 
 // Instructor
-func CreateInstructor(instructor Instructor) {
-	db.Create(&instructor)
+func CreateInstructor(ctx context.Context, instructor Instructor) (_ Instructor, err error) {
+	ctx, done := track(ctx, "CreateInstructor")
+	defer done(&err)
+	if err := instructorSchema(ctx).Validate(instructor); err != nil {
+		return Instructor{}, err
+	}
+	err = conn(ctx).Create(&instructor).Error
+	return instructor, err
 }
 
-func FindAllInstructors() []Instructor {
+func FindAllInstructors(ctx context.Context) []Instructor {
 	var instructors []Instructor
-	db.Find(&instructors)
+	var err error
+	ctx, done := track(ctx, "FindAllInstructors")
+	defer done(&err)
+	err = conn(ctx).Find(&instructors).Error
 	return instructors
 }
 
-func FindInstructorById(id int) Instructor {
+func FindInstructorById(ctx context.Context, id int) Instructor {
 	instructor := Instructor{}
-	db.First(&instructor, id)
+	var err error
+	ctx, done := track(ctx, "FindInstructorById")
+	defer done(&err)
+	err = conn(ctx).First(&instructor, id).Error
 	return instructor
 }
 
-func UpdateInstructor(instructor Instructor, instructorWithUpdatedFields Instructor) {
-	db.Model(&instructor).Updates(&instructorWithUpdatedFields)
+func UpdateInstructor(ctx context.Context, instructor Instructor, instructorWithUpdatedFields Instructor) (err error) {
+	ctx, done := track(ctx, "UpdateInstructor")
+	defer done(&err)
+	if err := instructorSchema(ctx).ValidatePartial(instructorWithUpdatedFields); err != nil {
+		return err
+	}
+	columns := updatedColumns(ctx, instructorWithUpdatedFields, "FullName", "Age", "DepartmentId", "MaxTeachingLoad")
+	result := conn(ctx).Model(&instructor).Where("version = ?", instructor.Version).Updates(columns)
+	return checkVersionedUpdate(ctx, result, &Instructor{}, "instructor", instructor.Id, instructor.Version)
+}
+
+func DeleteInstructor(ctx context.Context, instructor Instructor) (err error) {
+	ctx, done := track(ctx, "DeleteInstructor")
+	defer done(&err)
+	return conn(ctx).Delete(&instructor).Error
 }
 
-func DeleteInstructor(instructor Instructor) {
-	db.Delete(&instructor)
+// updatedColumns returns the columns an Update function writes: those of
+// the given fields that are set in updated, like Updates with a struct, and
+// the version bumped. Associations, ids and the version are never taken from
+// updated, as the fields not listed are left out.
+func updatedColumns(ctx context.Context, updated interface{}, fields ...string) map[string]interface{} {
+	columns := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	value := reflect.ValueOf(updated)
+	for _, name := range fields {
+		field := value.FieldByName(name)
+		if field.IsZero() {
+			continue
+		}
+		columns[conn(ctx).NamingStrategy.ColumnName("", name)] = reflect.Indirect(field).Interface()
+	}
+	return columns
 }
 
 // CUSTOM QUERIES
@@ -171,33 +435,38 @@ type APIDepartment struct {
 	StudentCount uint
 }
 
-func GetStudentCountForEachDepartment() []APIDepartment {
+func GetStudentCountForEachDepartment(ctx context.Context) []APIDepartment {
 	var apiDepartments []APIDepartment
-	//SELECT departments.id, departments.name, COUNT(*) as student_count FROM departments
-	//INNER JOIN students on departments.id = students.department_id
+	var err error
+	ctx, done := track(ctx, "GetStudentCountForEachDepartment")
+	defer done(&err)
+	//SELECT departments.id, departments.name, COUNT(students.id) as student_count FROM departments
+	//LEFT JOIN students on departments.id = students.department_id
 	//GROUP BY departments.id, departments.name
-	db.Model(&Department{}).Select("departments.id, departments.name, COUNT(*) as student_count").
-		Joins("inner join students on departments.id = students.department_id").
+	err = conn(ctx).Model(&Department{}).Select("departments.id, departments.name, COUNT(students.id) as student_count").
+		Joins("left join students on departments.id = students.department_id").
 		Group("departments.id, departments.name").
-		Find(&apiDepartments)
+		Find(&apiDepartments).Error
 	return apiDepartments
 }
 
-func GetStudentsOfInstructor(instructorId uint) []Student {
+// GetStudentsOfInstructor returns the students enrolled in the courses the
+// instructor is the primary instructor of. Passing roles widens this to
+// every course where the instructor has one of them, see
+// FindAllCoursesByInstructorId.
+func GetStudentsOfInstructor(ctx context.Context, instructorId uint, roles ...string) []Student {
 	var students []Student
-	//SELECT students.id, students.full_name, students.age, students.city, students.department_id, students.created_at
-	//FROM instructors
-	//INNER JOIN courses on instructors.id = courses.instructor_id
-	//INNER JOIN enrollments on courses.id = enrollments.course_id
-	//INNER JOIN students on enrollments.student_id = students.id
-	//GROUP BY students.id, students.full_name, students.age, students.city, students.department_id, students.created_at
-	//WHERE instructor_id = ?
-	db.Model(&Instructor{}).Select("students.id, students.full_name, students.age, students.city, students.department_id, students.created_at").
-		Where("instructor_id = ?", instructorId).
-		Joins(`inner join courses on instructors.id = courses.instructor_id 
-	inner join enrollments on courses.id = enrollments.course_id 
-	inner join students on enrollments.student_id = students.id`).
-		Group("students.id, students.full_name, students.age, students.city, students.department_id, students.created_at").
-		Find(&students)
+	var err error
+	ctx, done := track(ctx, "GetStudentsOfInstructor")
+	defer done(&err)
+	//SELECT * FROM students
+	//WHERE students.id IN (
+	//  SELECT enrollments.student_id FROM enrollments
+	//  WHERE enrollments.course_id IN (SELECT id FROM courses WHERE instructor_id = ?)
+	//)
+	err = conn(ctx).Where("students.id IN (?)", conn(ctx).Model(&Enrollment{}).Select("enrollments.student_id").
+		Where("enrollments.course_id IN (?)", instructorCourseIds(ctx, instructorId, roles))).
+		Order("students.id").
+		Find(&students).Error
 	return students
 }
@@ -0,0 +1,179 @@
+// Package dbtest gives tests of the db package and the packages above it a
+// database of their own. Each test gets a Fixture, a transaction that is
+// rolled back when the test ends, so tests do not see each other's rows and
+// can run in parallel:
+//
+//	func TestSomething(t *testing.T) {
+//		t.Parallel()
+//		f := dbtest.New(t)
+//		student := f.Student(func(s *db.Student) { s.Age = 30 })
+//		...
+//		f.AssertCount(1, &db.Student{}, "age = ?", 30)
+//	}
+//
+// The tables live in the schema dbtest of the test database configured with
+// the TEST_ environment variables, which are read from db/.env unless they
+// are set. Tests must not depend on ids, which rolled back transactions
+// still use up; the factories return the rows they create.
+//
+// The db package is connected once per test binary, and stays connected
+// until it exits. Tests using dbtest must not connect it themselves.
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"exercise1/config"
+	"exercise1/db"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+// Schema is where the tables of the fixtures live.
+const Schema = "dbtest"
+
+var (
+	connecting sync.Once
+	connectErr error
+)
+
+// connect connects the db package to the schema of the fixtures the first
+// time a test of the binary needs it, and migrates the schema.
+func connect(t testing.TB) {
+	t.Helper()
+	connecting.Do(func() { connectErr = setUp() })
+	if connectErr != nil {
+		t.Fatal(connectErr)
+	}
+}
+
+func setUp() error {
+	dsn, err := dsn(Schema)
+	if err != nil {
+		return err
+	}
+	if db.Live() == nil {
+		return errors.New("the db package is already connected; leave connecting it to dbtest")
+	}
+	if err := db.Connect(dsn); err != nil {
+		return fmt.Errorf("failed to connect to the test database: %w", err)
+	}
+	// Test binaries of several packages can get here at the same time.
+	ctx := context.Background()
+	err = db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", Schema).Error; err != nil {
+			return err
+		}
+		return tx.Exec("CREATE SCHEMA IF NOT EXISTS " + Schema).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the schema %s: %w", Schema, err)
+	}
+	if err := db.Migrate(ctx); err != nil {
+		return fmt.Errorf("failed to migrate the test database: %w", err)
+	}
+	return nil
+}
+
+// DSN returns the DSN of the test database with schema as the search path,
+// for tests that need a connection of their own.
+func DSN(t testing.TB, schema string) string {
+	t.Helper()
+	dsn, err := dsn(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dsn
+}
+
+func dsn(schema string) (string, error) {
+	// Test binaries run in the directory of their package, which has no .env
+	// of its own outside the db package.
+	_, file, _, _ := runtime.Caller(0)
+	if err := godotenv.Load(filepath.Join(filepath.Dir(file), "..", ".env")); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to read db/.env: %w", err)
+	}
+	cfg, _, err := config.Load(nil, "TEST_")
+	if err != nil {
+		return "", fmt.Errorf("invalid test database configuration: %w", err)
+	}
+	return cfg.Database.DSN() + " search_path=" + schema, nil
+}
+
+// Fixture is the database of one test: a transaction that is rolled back
+// when the test ends. The db functions called with Ctx run in it.
+type Fixture struct {
+	t   testing.TB
+	Ctx context.Context
+}
+
+// New connects to the test database if needed and starts the transaction
+// of the test.
+func New(t testing.TB) *Fixture {
+	t.Helper()
+	connect(t)
+	ctx, rollback, err := db.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("failed to start the transaction of the test: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := rollback(); err != nil {
+			t.Errorf("failed to roll back the transaction of the test: %v", err)
+		}
+	})
+	return &Fixture{t: t, Ctx: ctx}
+}
+
+// Count returns the number of rows of model matching the conditions, e.g.
+// f.Count(&db.Student{}, "department_id = ?", department.Id).
+func (f *Fixture) Count(model interface{}, conds ...interface{}) int64 {
+	f.t.Helper()
+	query := db.Conn(f.Ctx).Model(model)
+	if len(conds) > 0 {
+		query = query.Where(conds[0], conds[1:]...)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		f.t.Fatalf("failed to count %T: %v", model, err)
+	}
+	return count
+}
+
+// AssertCount fails the test unless expected rows of model match the
+// conditions.
+func (f *Fixture) AssertCount(expected int64, model interface{}, conds ...interface{}) {
+	f.t.Helper()
+	if actual := f.Count(model, conds...); actual != expected {
+		f.t.Errorf("expected %d rows of %T matching %s, found %d", expected, model, describe(conds), actual)
+	}
+}
+
+// AssertExists fails the test unless a row of model matches the conditions.
+func (f *Fixture) AssertExists(model interface{}, conds ...interface{}) {
+	f.t.Helper()
+	if f.Count(model, conds...) == 0 {
+		f.t.Errorf("expected a row of %T matching %s, found none", model, describe(conds))
+	}
+}
+
+// AssertMissing fails the test if a row of model matches the conditions.
+func (f *Fixture) AssertMissing(model interface{}, conds ...interface{}) {
+	f.t.Helper()
+	if count := f.Count(model, conds...); count > 0 {
+		f.t.Errorf("expected no row of %T matching %s, found %d", model, describe(conds), count)
+	}
+}
+
+func describe(conds []interface{}) string {
+	if len(conds) == 0 {
+		return "anything"
+	}
+	return fmt.Sprint(conds...)
+}
@@ -0,0 +1,92 @@
+package dbtest
+
+import (
+	"context"
+	"exercise1/db"
+	"regexp"
+	"testing"
+)
+
+func TestUniqueWordsAreLetters(t *testing.T) {
+	letters := regexp.MustCompile(`^[a-z]+$`)
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		word := unique()
+		if !letters.MatchString(word) || seen[word] {
+			t.Fatalf("expected a new word of letters, got %q", word)
+		}
+		seen[word] = true
+	}
+}
+
+func TestFixturesAreRolledBack(t *testing.T) {
+	var name string
+	t.Run("create", func(t *testing.T) {
+		f := New(t)
+		name = f.Department().Name
+		f.AssertExists(&db.Department{}, "name = ?", name)
+	})
+
+	f := New(t)
+	f.AssertMissing(&db.Department{}, "name = ?", name)
+}
+
+func TestFixturesDoNotSeeEachOther(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f := New(t)
+			department := f.Department()
+			f.Student(func(s *db.Student) { s.DepartmentId = &department.Id })
+			f.Student(func(s *db.Student) { s.DepartmentId = &department.Id })
+			f.AssertCount(1, &db.Department{})
+			f.AssertCount(2, &db.Student{}, "department_id = ?", department.Id)
+		})
+	}
+}
+
+func TestUpdateDepartment(t *testing.T) {
+	t.Parallel()
+	f := New(t)
+
+	department := f.Department()
+	if err := db.UpdateDepartment(f.Ctx, department, db.Department{Name: "Renamed"}); err != nil {
+		t.Fatalf("updating the department failed: %v", err)
+	}
+	f.AssertExists(&db.Department{}, "id = ? AND name = ? AND version = ?", department.Id, "Renamed", department.Version+1)
+}
+
+func TestFactoriesCreateWhatIsRequired(t *testing.T) {
+	t.Parallel()
+	f := New(t)
+
+	course := f.Course()
+	f.AssertExists(&db.Department{}, "id = ?", course.DepartmentId)
+	student := f.Student()
+	f.Enroll(student, course)
+	f.AssertExists(&db.Enrollment{}, "student_id = ? AND course_id = ?", student.Id, course.Id)
+
+	user := f.User(func(u *db.User) { u.Role = "student" })
+	if user.StudentId == nil {
+		t.Fatalf("expected a student user to be linked to a student")
+	}
+	f.AssertExists(&db.Student{}, "id = ?", *user.StudentId)
+}
+
+func TestWithDepartmentInsideFixture(t *testing.T) {
+	t.Parallel()
+	f := New(t)
+
+	department := f.Department()
+	other := f.Student()
+	err := db.WithDepartment(f.Ctx, department.Id, func(ctx context.Context) error {
+		_, err := db.CreateStudent(ctx, db.Student{FullName: "Dana Seitkali", Age: 20, DepartmentId: &department.Id})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("creating a student in the department failed: %v", err)
+	}
+	if found := db.FindStudentById(f.Ctx, int(other.Id)); found.Id != other.Id {
+		t.Fatalf("expected the scope of WithDepartment to end with it, but student %d is hidden", other.Id)
+	}
+}
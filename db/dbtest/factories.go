@@ -0,0 +1,125 @@
+package dbtest
+
+import (
+	"exercise1/db"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// The factories create a row with defaults that keep to the rules of its
+// model, changed by the overrides, and fail the test when it cannot be
+// created. Rows a required reference was not given for are created as well,
+// e.g. the department of a course.
+
+var sequence atomic.Uint64
+
+// unique returns a word no other call in the process returns. Names may
+// only contain letters, so it counts in letters: "a", ..., "z", "aa".
+func unique() string {
+	var word []byte
+	for n := sequence.Add(1); n > 0; n = (n - 1) / 26 {
+		word = append([]byte{byte('a' + (n-1)%26)}, word...)
+	}
+	return string(word)
+}
+
+func apply[T any](row *T, overrides []func(*T)) {
+	for _, override := range overrides {
+		override(row)
+	}
+}
+
+// Department creates a department with a unique name at the top of the
+// hierarchy.
+func (f *Fixture) Department(overrides ...func(*db.Department)) db.Department {
+	f.t.Helper()
+	department := db.Department{Name: "Department " + strings.ToUpper(unique())}
+	apply(&department, overrides)
+	department, err := db.CreateDepartment(f.Ctx, department)
+	if err != nil {
+		f.t.Fatalf("failed to create department: %v", err)
+	}
+	return department
+}
+
+// Student creates a 20 year old student from Almaty without a department.
+func (f *Fixture) Student(overrides ...func(*db.Student)) db.Student {
+	f.t.Helper()
+	student := db.Student{FullName: "Student " + strings.ToUpper(unique()), Age: 20, City: "Almaty"}
+	apply(&student, overrides)
+	student, err := db.CreateStudent(f.Ctx, student)
+	if err != nil {
+		f.t.Fatalf("failed to create student: %v", err)
+	}
+	return student
+}
+
+// Instructor creates a 40 year old instructor, in a new department unless
+// one is given.
+func (f *Fixture) Instructor(overrides ...func(*db.Instructor)) db.Instructor {
+	f.t.Helper()
+	instructor := db.Instructor{FullName: "Instructor " + strings.ToUpper(unique()), Age: 40}
+	apply(&instructor, overrides)
+	if instructor.DepartmentId == 0 {
+		instructor.DepartmentId = f.Department().Id
+	}
+	instructor, err := db.CreateInstructor(f.Ctx, instructor)
+	if err != nil {
+		f.t.Fatalf("failed to create instructor: %v", err)
+	}
+	return instructor
+}
+
+// Course creates a course of 5 credits in the fall of 2024 without an
+// instructor, in a new department unless one is given.
+func (f *Fixture) Course(overrides ...func(*db.Course)) db.Course {
+	f.t.Helper()
+	course := db.Course{Name: "Course " + strings.ToUpper(unique()), Credits: 5, Term: "2024-fall"}
+	apply(&course, overrides)
+	if course.DepartmentId == 0 {
+		course.DepartmentId = f.Department().Id
+	}
+	course, err := db.CreateCourse(f.Ctx, course)
+	if err != nil {
+		f.t.Fatalf("failed to create course: %v", err)
+	}
+	return course
+}
+
+// User creates a registrar with a unique email. Users with the role
+// student, instructor or department_admin get a new student, instructor or
+// department unless one is given.
+func (f *Fixture) User(overrides ...func(*db.User)) db.User {
+	f.t.Helper()
+	// Emails are unique in the database, where the rows of parallel tests
+	// and other test binaries wait for each other, so they differ by
+	// process too.
+	user := db.User{Email: fmt.Sprintf("%s.%d@example.com", unique(), os.Getpid()), PasswordHash: "hash", Role: "registrar"}
+	apply(&user, overrides)
+	switch {
+	case user.Role == "student" && user.StudentId == nil:
+		student := f.Student()
+		user.StudentId = &student.Id
+	case user.Role == "instructor" && user.InstructorId == nil:
+		instructor := f.Instructor()
+		user.InstructorId = &instructor.Id
+	case user.Role == "department_admin" && user.DepartmentId == nil:
+		department := f.Department()
+		user.DepartmentId = &department.Id
+	}
+	user, err := db.CreateUser(f.Ctx, user)
+	if err != nil {
+		f.t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// Enroll enrolls the student for the course.
+func (f *Fixture) Enroll(student db.Student, course db.Course) {
+	f.t.Helper()
+	if err := db.EnrollStudentForCourse(f.Ctx, student.Id, course.Id); err != nil {
+		f.t.Fatalf("failed to enroll student %d for course %d: %v", student.Id, course.Id, err)
+	}
+}
@@ -0,0 +1,716 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Personal data is encrypted field by field. Fields tagged
+// `gorm:"serializer:encrypted"` are stored as AES-GCM ciphertext under a data
+// key, and the data keys are stored wrapped with the master key from the
+// configuration, which never touches the database. A field can have a blind
+// index, a field tagged `blindindex:"<field>"` holding a keyed hash of its
+// normalised value, so that equality lookups and grouping still work.
+//
+// Without a master key fields are stored in plain text, and plain text read
+// back is passed through, so encryption can be turned on for an existing
+// database and its rows encrypted with ReencryptRows.
+
+// EncryptionKey is a data key encrypting personal fields, or the key of the
+// blind indexes. Only the newest data key encrypts; the older ones are kept
+// to decrypt the rows not yet re-encrypted.
+type EncryptionKey struct {
+	Id      uint   `gorm:"primaryKey"`
+	Purpose string `gorm:"not null;uniqueIndex:idx_encryption_keys_index,where:purpose = 'index'"`
+	// WrappedKey is the key encrypted with the master key.
+	WrappedKey []byte `gorm:"not null" json:"-"`
+	CreatedAt  time.Time
+}
+
+const (
+	keyPurposeData  = "data"
+	keyPurposeIndex = "index"
+)
+
+// MasterKeySize is the size in bytes of the master key, an AES-256 key.
+const MasterKeySize = 32
+
+// ciphertextPrefix starts every encrypted value, followed by the id of its
+// data key, so that plain text from before encryption was turned on can be
+// told apart.
+const ciphertextPrefix = "enc:"
+
+// activeKeyTTL is how long the active data key is cached, so that the
+// servers switch to a key rotated by another process within this time.
+const activeKeyTTL = time.Minute
+
+var ErrNoMasterKey = errors.New("personal data is encrypted, but no encryption master key is configured")
+
+func init() {
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+}
+
+type keyring struct {
+	master cipher.AEAD
+
+	mu       sync.Mutex
+	keys     map[uint]cipher.AEAD
+	active   uint
+	activeAt time.Time
+	index    []byte
+}
+
+func newKeyring(masterKey []byte) (*keyring, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, fmt.Errorf("the encryption master key must be %d bytes, got %d", MasterKeySize, len(masterKey))
+	}
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &keyring{master: master, keys: map[uint]cipher.AEAD{}}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encrypt(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+// keyConn returns the connection the keys are read and created with. It is
+// not the transaction of ctx, so creating a key is not rolled back with it.
+func keyConn(ctx context.Context) *gorm.DB {
+	return connected().db.WithContext(ctx)
+}
+
+// newKey returns a new key for purpose, wrapped with the master key, and its
+// secret.
+func (k *keyring) newKey(purpose string) (EncryptionKey, []byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return EncryptionKey{}, nil, err
+	}
+	wrapped, err := encrypt(k.master, secret, []byte(purpose))
+	if err != nil {
+		return EncryptionKey{}, nil, err
+	}
+	return EncryptionKey{Purpose: purpose, WrappedKey: wrapped}, secret, nil
+}
+
+// createKey creates the index key. A second one is not created; the existing
+// one is returned instead.
+func (k *keyring) createKey(ctx context.Context, purpose string) (EncryptionKey, []byte, error) {
+	key, secret, err := k.newKey(purpose)
+	if err != nil {
+		return EncryptionKey{}, nil, err
+	}
+	result := keyConn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&key)
+	if result.Error != nil {
+		return EncryptionKey{}, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Another process created the index key first.
+		return k.loadKey(ctx, keyConn(ctx).Where("purpose = ?", purpose))
+	}
+	return key, secret, nil
+}
+
+// dataKeyLock is the Postgres advisory lock held while a data key is
+// created, so that servers starting at the same time create a single first
+// key between them and rotations follow each other.
+const dataKeyLock = 0x6b657973
+
+// createDataKey creates a data key. Unless rotate is set, the newest data key
+// is returned instead when there is one.
+func (k *keyring) createDataKey(ctx context.Context, rotate bool) (key EncryptionKey, secret []byte, err error) {
+	err = keyConn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", dataKeyLock).Error; err != nil {
+			return err
+		}
+		if !rotate {
+			key, secret, err = k.loadKey(ctx, tx.Where("purpose = ?", keyPurposeData).Order("id DESC"))
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if key, secret, err = k.newKey(keyPurposeData); err != nil {
+			return err
+		}
+		return tx.Create(&key).Error
+	})
+	return key, secret, err
+}
+
+// loadKey loads the first key matching query and unwraps it.
+func (k *keyring) loadKey(ctx context.Context, query *gorm.DB) (EncryptionKey, []byte, error) {
+	var key EncryptionKey
+	if err := query.First(&key).Error; err != nil {
+		return EncryptionKey{}, nil, err
+	}
+	secret, err := decrypt(k.master, key.WrappedKey, []byte(key.Purpose))
+	if err != nil {
+		return EncryptionKey{}, nil, fmt.Errorf("failed to unwrap encryption key %d, is it the right master key? %w", key.Id, err)
+	}
+	return key, secret, nil
+}
+
+// dataKey returns the data key with id.
+//
+// The keys are read and created without holding mu, so that encrypting and
+// decrypting do not wait for each other's queries on keyConn, which would
+// hold up callers holding a connection of the pool in their transaction.
+// Callers missing the cache at the same time load the key more than once.
+func (k *keyring) dataKey(ctx context.Context, id uint) (cipher.AEAD, error) {
+	k.mu.Lock()
+	aead, ok := k.keys[id]
+	k.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	_, secret, err := k.loadKey(ctx, keyConn(ctx).Where("purpose = ? AND id = ?", keyPurposeData, id))
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.add(id, secret)
+}
+
+// add caches the key with id, unless it already is. mu must be held.
+func (k *keyring) add(id uint, secret []byte) (cipher.AEAD, error) {
+	if aead, ok := k.keys[id]; ok {
+		return aead, nil
+	}
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	k.keys[id] = aead
+	return aead, nil
+}
+
+// activeKey returns the newest data key, creating the first one. Like
+// dataKey, it queries without holding mu.
+func (k *keyring) activeKey(ctx context.Context) (uint, cipher.AEAD, error) {
+	k.mu.Lock()
+	if k.active != 0 && time.Since(k.activeAt) < activeKeyTTL {
+		id, aead := k.active, k.keys[k.active]
+		k.mu.Unlock()
+		return id, aead, nil
+	}
+	k.mu.Unlock()
+
+	key, secret, err := k.loadKey(ctx, keyConn(ctx).Where("purpose = ?", keyPurposeData).Order("id DESC"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		key, secret, err = k.createDataKey(ctx, false)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.active > key.Id && time.Since(k.activeAt) < activeKeyTTL {
+		// A rotation by this process made a newer key active meanwhile.
+		return k.active, k.keys[k.active], nil
+	}
+	aead, err := k.add(key.Id, secret)
+	if err != nil {
+		return 0, nil, err
+	}
+	k.active, k.activeAt = key.Id, time.Now()
+	return key.Id, aead, nil
+}
+
+// indexKey returns the key of the blind indexes, creating it on first use.
+// It is never rotated, as that would change every index. Like dataKey, it
+// queries without holding mu.
+func (k *keyring) indexKey(ctx context.Context) ([]byte, error) {
+	k.mu.Lock()
+	index := k.index
+	k.mu.Unlock()
+	if index != nil {
+		return index, nil
+	}
+	_, secret, err := k.loadKey(ctx, keyConn(ctx).Where("purpose = ?", keyPurposeIndex))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_, secret, err = k.createKey(ctx, keyPurposeIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.index = secret
+	return secret, nil
+}
+
+// seal encrypts the value of column. The column name is authenticated with
+// it, so ciphertext cannot be moved to another column unnoticed.
+func (k *keyring) seal(ctx context.Context, column, plaintext string) (string, error) {
+	id, aead, err := k.activeKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := encrypt(aead, []byte(plaintext), []byte(column))
+	if err != nil {
+		return "", err
+	}
+	return ciphertextPrefix + strconv.FormatUint(uint64(id), 10) + ":" + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (k *keyring) open(ctx context.Context, column, stored string) (string, error) {
+	idText, encoded, ok := strings.Cut(strings.TrimPrefix(stored, ciphertextPrefix), ":")
+	id, err := strconv.ParseUint(idText, 10, 64)
+	if !ok || err != nil {
+		return "", fmt.Errorf("malformed ciphertext in %s", column)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext in %s: %w", column, err)
+	}
+	aead, err := k.dataKey(ctx, uint(id))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decrypt(aead, ciphertext, []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+	return string(plaintext), nil
+}
+
+// seal encrypts the value of column if a master key is configured. Empty
+// values stay empty.
+func seal(ctx context.Context, column, plaintext string) (string, error) {
+	encryption := connected().keys
+	if encryption == nil || plaintext == "" || inPlaintext(ctx) {
+		return plaintext, nil
+	}
+	return encryption.seal(ctx, column, plaintext)
+}
+
+// open decrypts the stored value of column; plain text is returned as is.
+func open(ctx context.Context, column, stored string) (string, error) {
+	if !strings.HasPrefix(stored, ciphertextPrefix) {
+		return stored, nil
+	}
+	encryption := connected().keys
+	if encryption == nil {
+		return "", ErrNoMasterKey
+	}
+	return encryption.open(ctx, column, stored)
+}
+
+// blindIndex returns the index of the value of column: a keyed hash of the
+// value with case and spacing normalised, or the normalised value itself
+// while no master key is configured.
+func blindIndex(ctx context.Context, column, value string) (string, error) {
+	encryption := connected().keys
+	normalised := normalise(value)
+	if encryption == nil || normalised == "" || inPlaintext(ctx) {
+		return normalised, nil
+	}
+	key, err := encryption.indexKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(column + "\x00" + normalised))
+	// Half of the hash tells values apart just as well and gives away less
+	// about the frequency of values.
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// normalise ignores case and spacing in the values of blind indexes.
+func normalise(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+type plaintextKey struct{}
+
+// withPlaintext makes the statements of ctx store personal data in plain
+// text, with plain text indexes, as if no master key were configured. It is
+// for writing to other databases, which do not have the keys.
+func withPlaintext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, plaintextKey{}, true)
+}
+
+func inPlaintext(ctx context.Context) bool {
+	plaintext, _ := ctx.Value(plaintextKey{}).(bool)
+	return plaintext
+}
+
+// encryptedSerializer encrypts a string field when it is written and
+// decrypts it when it is read.
+type encryptedSerializer struct{}
+
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("cannot decrypt %s from %T", field.DBName, dbValue)
+	}
+	plaintext, err := open(ctx, field.DBName, stored)
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("only strings can be encrypted, %s is %T", field.DBName, fieldValue)
+	}
+	return seal(ctx, field.DBName, plaintext)
+}
+
+// encryptionPlugin keeps the blind indexes up to date with the fields they
+// index. Values updated from a map bypass the serializer, so it encrypts
+// them as well.
+type encryptionPlugin struct{}
+
+func (encryptionPlugin) Name() string {
+	return "encryption"
+}
+
+func (encryptionPlugin) Initialize(d *gorm.DB) error {
+	c := d.Callback()
+	return errors.Join(
+		c.Create().Before("gorm:create").Register("encryption:prepare_create", prepareEncryptedFields),
+		c.Update().Before("gorm:update").Register("encryption:prepare_update", prepareEncryptedFields),
+	)
+}
+
+// encryptedField is an encrypted field together with the field holding its
+// blind index, if it has one.
+type encryptedField struct {
+	field, index *schema.Field
+}
+
+func encryptedFields(s *schema.Schema) []encryptedField {
+	var fields []encryptedField
+	for _, field := range s.Fields {
+		if field.TagSettings["SERIALIZER"] != "encrypted" {
+			continue
+		}
+		encrypted := encryptedField{field: field}
+		for _, index := range s.Fields {
+			if index.Tag.Get("blindindex") == field.Name {
+				encrypted.index = index
+			}
+		}
+		fields = append(fields, encrypted)
+	}
+	return fields
+}
+
+func prepareEncryptedFields(tx *gorm.DB) {
+	s := tx.Statement
+	if tx.Error != nil || s.Schema == nil {
+		return
+	}
+	ctx := s.Context
+	for _, encrypted := range encryptedFields(s.Schema) {
+		field, index := encrypted.field, encrypted.index
+		if values, ok := s.Dest.(map[string]interface{}); ok {
+			for _, name := range []string{field.Name, field.DBName} {
+				value, ok := values[name].(string)
+				if !ok {
+					continue
+				}
+				sealed, err := seal(ctx, field.DBName, value)
+				if err != nil {
+					tx.AddError(err)
+					return
+				}
+				values[name] = sealed
+				if index != nil {
+					if values[index.DBName], err = blindIndex(ctx, field.DBName, value); err != nil {
+						tx.AddError(err)
+						return
+					}
+				}
+			}
+			continue
+		}
+		if index == nil {
+			continue
+		}
+
+		setIndex := func(row reflect.Value) {
+			row = reflect.Indirect(row)
+			if row.Kind() != reflect.Struct || !row.CanAddr() {
+				return
+			}
+			indexed, err := blindIndex(ctx, field.DBName, field.ReflectValueOf(ctx, row).String())
+			if err != nil {
+				tx.AddError(err)
+				return
+			}
+			tx.AddError(index.Set(ctx, row, indexed))
+		}
+		rows := reflect.Indirect(reflect.ValueOf(s.Dest))
+		switch rows.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rows.Len(); i++ {
+				setIndex(rows.Index(i))
+			}
+		default:
+			setIndex(rows)
+		}
+	}
+}
+
+// RotateEncryptionKey creates a new data key, which encrypts everything
+// written from now on. ReencryptRows re-encrypts the existing rows with it.
+func RotateEncryptionKey(ctx context.Context) (_ EncryptionKey, err error) {
+	ctx, done := track(ctx, "RotateEncryptionKey")
+	defer done(&err)
+	encryption := connected().keys
+	if encryption == nil {
+		return EncryptionKey{}, ErrNoMasterKey
+	}
+	key, secret, err := encryption.createDataKey(ctx, true)
+	if err != nil {
+		return EncryptionKey{}, err
+	}
+	encryption.mu.Lock()
+	defer encryption.mu.Unlock()
+	if _, err := encryption.add(key.Id, secret); err != nil {
+		return EncryptionKey{}, err
+	}
+	encryption.active, encryption.activeAt = key.Id, time.Now()
+	return key, nil
+}
+
+// encryptedModels lists the models with encrypted fields.
+var encryptedModels = []interface{}{&Student{}, &Instructor{}}
+
+// ReencryptRows encrypts every row of the models with encrypted fields that
+// is not encrypted with the newest data key, including rows stored before
+// encryption was turned on, and fills in missing blind indexes. Without a
+// master key it only does the latter. It works through the rows in batches
+// of batchSize, each in a transaction of its own and followed by pause, so
+// it can run next to the servers. progress is called after every batch.
+//
+// Right after a rotation it first waits until every server encrypts with
+// the new key, see activeKeyTTL, as rows they wrote with the old one would
+// otherwise remain. A row changed while its batch runs is skipped; running
+// ReencryptRows again picks it up.
+func ReencryptRows(ctx context.Context, batchSize int, pause time.Duration, progress func(table string, rows int)) (err error) {
+	ctx, done := track(ctx, "ReencryptRows")
+	defer done(&err)
+	ctx = withoutTenant(ctx)
+	if err := waitForRotation(ctx); err != nil {
+		return err
+	}
+	for _, model := range encryptedModels {
+		var afterId uint
+		for {
+			var rows int
+			afterId, rows, err = reencryptBatch(ctx, model, afterId, batchSize)
+			if err != nil || afterId == 0 {
+				break
+			}
+			progress(tableName(model), rows)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(pause):
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForRotation waits until the newest data key is activeKeyTTL old,
+// unless it is the first one.
+func waitForRotation(ctx context.Context) error {
+	if connected().keys == nil {
+		return nil
+	}
+	var keys []EncryptionKey
+	if err := keyConn(ctx).Where("purpose = ?", keyPurposeData).Order("id DESC").Limit(2).Find(&keys).Error; err != nil {
+		return err
+	}
+	if len(keys) < 2 {
+		return nil
+	}
+	wait := time.Until(keys[0].CreatedAt.Add(activeKeyTTL))
+	if wait <= 0 {
+		return nil
+	}
+	logger.InfoContext(ctx, "waiting for the servers to switch to the new data key", "key", keys[0].Id, "wait", wait)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+func tableName(model interface{}) string {
+	stmt := &gorm.Statement{DB: connected().db}
+	if err := stmt.Parse(model); err != nil {
+		return fmt.Sprintf("%T", model)
+	}
+	return stmt.Schema.Table
+}
+
+// reencryptBatch re-encrypts the next batch of rows of model after afterId.
+// It returns the id of the last row of the batch, 0 when there are none
+// left, and how many rows it rewrote.
+func reencryptBatch(ctx context.Context, model interface{}, afterId uint, size int) (lastId uint, rewritten int, err error) {
+	stmt := &gorm.Statement{DB: connected().db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, 0, err
+	}
+	var activePrefix string
+	if encryption := connected().keys; encryption != nil {
+		active, _, err := encryption.activeKey(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		activePrefix = ciphertextPrefix + strconv.FormatUint(uint64(active), 10) + ":"
+	}
+
+	// A row is stale when one of its fields is not encrypted with the active
+	// key or lacks its index.
+	var stale, columns []string
+	var args []interface{}
+	for _, encrypted := range encryptedFields(stmt.Schema) {
+		column := encrypted.field.DBName
+		var conditions []string
+		if activePrefix != "" {
+			conditions = append(conditions, column+" NOT LIKE ?")
+			args = append(args, activePrefix+"%")
+		}
+		columns = append(columns, column)
+		if encrypted.index != nil {
+			// Indexes added to existing tables are NULL.
+			conditions = append(conditions, "COALESCE("+encrypted.index.DBName+", '') = ''")
+			columns = append(columns, encrypted.index.DBName)
+		}
+		if len(conditions) > 0 {
+			stale = append(stale, fmt.Sprintf("(%s <> '' AND (%s))", column, strings.Join(conditions, " OR ")))
+		}
+	}
+	if len(stale) == 0 {
+		return 0, 0, nil
+	}
+
+	// Every department is re-encrypted, whoever runs it.
+	err = AllDepartments(ctx, func(ctx context.Context) error {
+		tx := conn(ctx)
+		rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+		err := tx.Model(model).Where("id > ?", afterId).Where("("+strings.Join(stale, " OR ")+")", args...).
+			Order("id").Limit(size).Find(rows.Interface()).Error
+		if err != nil {
+			return err
+		}
+		version := stmt.Schema.LookUpField("Version")
+		for i := 0; i < rows.Elem().Len(); i++ {
+			row := rows.Elem().Index(i).Addr().Interface()
+			update := tx.Session(&gorm.Session{SkipHooks: true}).Model(row).Select(columns)
+			if version != nil {
+				current, _ := version.ValueOf(ctx, rows.Elem().Index(i))
+				update = update.Where("version = ?", current)
+			}
+			result := update.Updates(row)
+			if result.Error != nil {
+				return result.Error
+			}
+			rewritten += int(result.RowsAffected)
+			id, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(ctx, rows.Elem().Index(i))
+			lastId = id.(uint)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return lastId, rewritten, nil
+}
+
+// RewrapEncryptionKeys re-encrypts the stored keys, wrapped with
+// oldMasterKey, with the configured master key. The data itself need not be
+// re-encrypted for a new master key.
+func RewrapEncryptionKeys(ctx context.Context, oldMasterKey []byte) (_ int, err error) {
+	ctx, done := track(ctx, "RewrapEncryptionKeys")
+	defer done(&err)
+	encryption := connected().keys
+	if encryption == nil {
+		return 0, ErrNoMasterKey
+	}
+	old, err := newKeyring(oldMasterKey)
+	if err != nil {
+		return 0, err
+	}
+	var keys []EncryptionKey
+	err = keyConn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id").Find(&keys).Error; err != nil {
+			return err
+		}
+		for _, key := range keys {
+			secret, err := decrypt(old.master, key.WrappedKey, []byte(key.Purpose))
+			if err != nil {
+				return fmt.Errorf("failed to unwrap encryption key %d with the old master key: %w", key.Id, err)
+			}
+			wrapped, err := encrypt(encryption.master, secret, []byte(key.Purpose))
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&key).Update("wrapped_key", wrapped).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
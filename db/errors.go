@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var ErrDepartmentCycle = errors.New("department cannot be moved below itself or one of its descendants")
+
+// ConflictError is returned by the Update functions when the row was changed
+// by someone else after it had been read, i.e. its version has moved on.
+type ConflictError struct {
+	Entity          string
+	Id              uint
+	ExpectedVersion uint
+	ActualVersion   uint
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %d was modified concurrently: expected version %d, but found %d", e.Entity, e.Id, e.ExpectedVersion, e.ActualVersion)
+}
+
+// checkVersionedUpdate turns an update that matched no rows into either
+// gorm.ErrRecordNotFound or a *ConflictError carrying the current version.
+func checkVersionedUpdate(ctx context.Context, result *gorm.DB, model interface{}, entity string, id uint, expectedVersion uint) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var actualVersion uint
+	err := conn(ctx).Model(model).Select("version").Where("id = ?", id).Row().Scan(&actualVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return gorm.ErrRecordNotFound
+		}
+		return err
+	}
+	return &ConflictError{Entity: entity, Id: id, ExpectedVersion: expectedVersion, ActualVersion: actualVersion}
+}
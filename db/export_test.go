@@ -0,0 +1,63 @@
+package db
+
+import "context"
+
+// The tests of package db_test reach the internals they check through these.
+
+var (
+	DropsTotal      = dropsTotal
+	OperationErrors = operationErrors
+)
+
+const (
+	CiphertextPrefix      = ciphertextPrefix
+	DepartmentSetting     = departmentSetting
+	AllDepartmentsSetting = allDepartmentsSetting
+)
+
+// UseMasterKey makes the package encrypt with masterKey until the returned
+// function is called.
+func UseMasterKey(masterKey []byte) (func(), error) {
+	k, err := newKeyring(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return useKeys(k), nil
+}
+
+// useKeys makes the package encrypt with k until the returned function is
+// called.
+func useKeys(k *keyring) func() {
+	previous := current.Load()
+	next := connection{keys: k}
+	if previous != nil {
+		next.db = previous.db
+	}
+	current.Store(&next)
+	return func() { current.Store(previous) }
+}
+
+// Dial opens a connection of its own with opts and returns a context whose
+// db functions run in a transaction on it, as in Begin, together with the
+// function rolling the transaction back and closing the connection.
+func Dial(ctx context.Context, dsn string, opts Options) (context.Context, func() error, error) {
+	d, err := dial(dsn, opts)
+	if err != nil {
+		return ctx, nil, err
+	}
+	tx := d.Begin()
+	if tx.Error == nil {
+		tx.Error = bypassRowLevelSecurity(tx)
+	}
+	if tx.Error != nil {
+		closePool(d)
+		return ctx, nil, tx.Error
+	}
+	return context.WithValue(ctx, txKey{}, tx), func() error {
+		err := tx.Rollback().Error
+		if closeErr := closePool(d); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
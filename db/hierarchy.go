@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func FindRootDepartments(ctx context.Context) []Department {
+	var departments []Department
+	conn(ctx).Where("parent_id IS NULL").Find(&departments)
+	return departments
+}
+
+func FindChildDepartments(ctx context.Context, parentId uint) []Department {
+	var departments []Department
+	conn(ctx).Where("parent_id = ?", parentId).Find(&departments)
+	return departments
+}
+
+// FindDepartmentSubtree returns the department with the given id followed by
+// all of its descendants, at any depth.
+func FindDepartmentSubtree(ctx context.Context, id uint) []Department {
+	var departments []Department
+	// The descendants of a department belong to its tenant, so checking the
+	// department itself scopes the raw query.
+	if FindDepartmentById(ctx, int(id)).Id == 0 {
+		return departments
+	}
+	// The path of every row stops the recursion at a department already
+	// visited, so corrupted data with a cycle cannot loop forever.
+	conn(ctx).Raw(`WITH RECURSIVE subtree(id, depth, path) AS (
+		SELECT id, 0, ARRAY[id] FROM departments WHERE id = ?
+		UNION ALL
+		SELECT departments.id, subtree.depth + 1, subtree.path || departments.id FROM departments
+		INNER JOIN subtree ON departments.parent_id = subtree.id
+		WHERE NOT departments.id = ANY(subtree.path)
+	)
+	SELECT departments.* FROM departments
+	INNER JOIN subtree ON departments.id = subtree.id
+	ORDER BY subtree.depth, departments.id`, id).Scan(&departments)
+	return departments
+}
+
+// FindDepartmentAncestors returns the parent chain of the department, starting
+// with its direct parent and ending with the root.
+func FindDepartmentAncestors(ctx context.Context, id uint) []Department {
+	var ancestors []Department
+	department := FindDepartmentById(ctx, int(id))
+	// Stop at a department seen before, so a cycle cannot loop forever.
+	seen := map[uint]bool{department.Id: true}
+	for department.ParentId != nil && !seen[*department.ParentId] {
+		department = FindDepartmentById(ctx, int(*department.ParentId))
+		if department.Id == 0 {
+			break
+		}
+		seen[department.Id] = true
+		ancestors = append(ancestors, department)
+	}
+	return ancestors
+}
+
+// MoveDepartment re-parents the department together with its whole subtree.
+// A nil newParentId makes it a root. Moving a department below itself or one
+// of its descendants returns ErrDepartmentCycle.
+//
+// The department and the ancestors of its new parent are locked while the
+// move is checked, so that concurrent moves cannot form a cycle between
+// them: the later one waits and sees the earlier, or Postgres aborts one of
+// them as a deadlock.
+func MoveDepartment(ctx context.Context, id uint, newParentId *uint) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		locked := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		var department Department
+		if err := locked.First(&department, id).Error; err != nil {
+			return err
+		}
+
+		if newParentId != nil {
+			seen := map[uint]bool{}
+			for ancestorId := newParentId; ancestorId != nil; {
+				if *ancestorId == id || seen[*ancestorId] {
+					return ErrDepartmentCycle
+				}
+				seen[*ancestorId] = true
+				var ancestor Department
+				if err := locked.First(&ancestor, *ancestorId).Error; err != nil {
+					return err
+				}
+				ancestorId = ancestor.ParentId
+			}
+		}
+
+		return tx.Model(&department).
+			Updates(map[string]interface{}{"parent_id": newParentId, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+// DepartmentRollup holds the counts of a department including everything in
+// the departments below it.
+type DepartmentRollup struct {
+	Id              uint
+	ParentId        *uint
+	Name            string
+	Kind            string
+	StudentCount    uint
+	CourseCount     uint
+	InstructorCount uint
+}
+
+type departmentCount struct {
+	DepartmentId uint
+	Count        uint
+}
+
+func countByDepartment(ctx context.Context, model interface{}) map[uint]uint {
+	var rows []departmentCount
+	conn(ctx).Model(model).Select("department_id, COUNT(*) as count").
+		Where("department_id IS NOT NULL").
+		Group("department_id").
+		Find(&rows)
+
+	counts := make(map[uint]uint, len(rows))
+	for _, row := range rows {
+		counts[row.DepartmentId] = row.Count
+	}
+	return counts
+}
+
+// GetDepartmentRollups sums student, course and instructor counts up the
+// hierarchy, so a faculty reports everything taught and studied in its
+// departments and a school everything in its faculties.
+func GetDepartmentRollups(ctx context.Context) []DepartmentRollup {
+	departments := FindAllDepartments(ctx)
+	students := countByDepartment(ctx, &Student{})
+	courses := countByDepartment(ctx, &Course{})
+	instructors := countByDepartment(ctx, &Instructor{})
+
+	rollups := make([]DepartmentRollup, len(departments))
+	indexById := make(map[uint]int, len(departments))
+	for i, department := range departments {
+		rollups[i] = DepartmentRollup{Id: department.Id, ParentId: department.ParentId, Name: department.Name, Kind: department.Kind}
+		indexById[department.Id] = i
+	}
+
+	for _, department := range departments {
+		// Walk up at most len(departments) levels so corrupted data with a
+		// cycle cannot loop forever.
+		current, depth := department.Id, 0
+		for depth <= len(departments) {
+			i, ok := indexById[current]
+			if !ok {
+				break
+			}
+			rollups[i].StudentCount += students[department.Id]
+			rollups[i].CourseCount += courses[department.Id]
+			rollups[i].InstructorCount += instructors[department.Id]
+			if rollups[i].ParentId == nil {
+				break
+			}
+			current = *rollups[i].ParentId
+			depth++
+		}
+	}
+	return rollups
+}
+
+// GetStudentCountForEachDepartmentRollup is the roll-up version of
+// GetStudentCountForEachDepartment: every department, including faculties and
+// schools without students of their own, with the students of its subtree.
+func GetStudentCountForEachDepartmentRollup(ctx context.Context) []APIDepartment {
+	rollups := GetDepartmentRollups(ctx)
+	apiDepartments := make([]APIDepartment, len(rollups))
+	for i, rollup := range rollups {
+		apiDepartments[i] = APIDepartment{Id: rollup.Id, Name: rollup.Name, StudentCount: rollup.StudentCount}
+	}
+	return apiDepartments
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The tests of this file need no database; those in all_test.go do.
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestRedactKeepsOnlyNonPersonalArgs(t *testing.T) {
+	at := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	redacted := redact([]interface{}{"Askar Bekbergen", uint(3), 20, uintPtr(7), at, nil, []byte("x")})
+
+	expected := []interface{}{"[redacted]", uint(3), 20, uint(7), at, nil, "[redacted]"}
+	if fmt.Sprint(redacted) != fmt.Sprint(expected) {
+		log.Fatalf("Expected %v, got %v", expected, redacted)
+	}
+}
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestSealAndOpen(t *testing.T) {
+	ctx := context.Background()
+	k, err := newKeyring(testMasterKey)
+	if err != nil {
+		log.Fatalf("Creating a keyring failed: %v", err)
+	}
+	if _, err := k.add(1, []byte("fedcba9876543210fedcba9876543210")); err != nil {
+		log.Fatalf("Adding a data key failed: %v", err)
+	}
+	k.active, k.activeAt, k.index = 1, time.Now(), []byte("index key")
+
+	sealed, err := k.seal(ctx, "city", "Almaty")
+	if err != nil || !strings.HasPrefix(sealed, "enc:1:") {
+		log.Fatalf("Expected ciphertext under key 1, got %q, %v", sealed, err)
+	}
+	if again, _ := k.seal(ctx, "city", "Almaty"); again == sealed {
+		log.Fatalf("Expected a fresh nonce for every value, got %q twice", sealed)
+	}
+	if opened, err := k.open(ctx, "city", sealed); err != nil || opened != "Almaty" {
+		log.Fatalf("Expected Almaty, got %q, %v", opened, err)
+	}
+	if _, err := k.open(ctx, "full_name", sealed); err == nil {
+		log.Fatalf("Expected ciphertext moved to another column to be rejected")
+	}
+
+	defer useKeys(k)()
+	if opened, err := open(ctx, "city", "Almaty"); err != nil || opened != "Almaty" {
+		log.Fatalf("Expected plain text to be passed through, got %q, %v", opened, err)
+	}
+	first, _ := blindIndex(ctx, "city", "Almaty")
+	second, _ := blindIndex(ctx, "city", " ALMATY ")
+	other, _ := blindIndex(ctx, "full_name", "Almaty")
+	if first == "" || first != second || first == other || strings.Contains(first, "lmaty") {
+		log.Fatalf("Expected a keyed index ignoring case and spacing, per column, got %q, %q and %q", first, second, other)
+	}
+}
+
+func TestFakesAreConsistent(t *testing.T) {
+	fake := newFakes()
+	almaty := fake.city("Almaty")
+	if again := fake.city(" ALMATY "); again != almaty {
+		t.Fatalf("Expected every spelling of a city to get the same fake one, got %q and %q", almaty, again)
+	}
+	if other := fake.city("Turkistan"); other == almaty {
+		t.Fatalf("Expected different cities to get different fake ones, both got %q", almaty)
+	}
+	if erased := fake.name(""); erased != "" {
+		t.Fatalf("Expected empty names to stay empty, got %q", erased)
+	}
+	names := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		names[fake.name(fmt.Sprintf("Student %d", i))] = true
+	}
+	if len(names) != 1000 {
+		t.Fatalf("Expected 1000 different fake names, got %d", len(names))
+	}
+}
+
+func TestCourseLoad(t *testing.T) {
+	for students, expected := range map[uint]uint{0: 5, 29: 5, 30: 6, 31: 6, 59: 6, 60: 7} {
+		if actual := CourseLoad(5, students); actual != expected {
+			log.Fatalf("Expected a 5 credit course with %d students to have load %d, got %d", students, expected, actual)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}
+	for i, wait := range expected {
+		if actual := backoff(i+1, 100*time.Millisecond, 500*time.Millisecond); actual != wait {
+			log.Fatalf("Expected wait %s after attempt %d, got %s", wait, i+1, actual)
+		}
+	}
+}
+
+func TestConnectGivesUp(t *testing.T) {
+	options := DefaultOptions()
+	options.Attempts = 2
+	options.InitialBackoff = time.Millisecond
+
+	err := ConnectWithOptions(context.Background(), "host=127.0.0.1 port=1 user=nobody dbname=none sslmode=disable connect_timeout=1", options)
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		log.Fatalf("Expected connecting to an unreachable database to fail after 2 attempts, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"exercise1/logging"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+var logger = logging.For("db")
+
+const startKey = "logging:start"
+
+// queryLogger logs failed statements as errors, statements slower than
+// threshold as warnings and all others at debug level, together with the
+// function of the project that ran them.
+type queryLogger struct {
+	threshold time.Duration
+}
+
+func (queryLogger) Name() string {
+	return "logging"
+}
+
+func (l queryLogger) Initialize(d *gorm.DB) error {
+	return aroundStatements(d, l.Name(), l.before, l.after)
+}
+
+func (queryLogger) before(string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		tx.InstanceSet(startKey, time.Now())
+	}
+}
+
+func (l queryLogger) after(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(startKey)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(value.(time.Time))
+	ctx := tx.Statement.Context
+
+	level, message := slog.LevelDebug, "query"
+	switch {
+	case tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound):
+		level, message = slog.LevelError, "query failed"
+	case l.threshold > 0 && elapsed >= l.threshold:
+		level, message = slog.LevelWarn, "slow query"
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", tx.Statement.SQL.String()),
+		slog.Any("args", redact(tx.Statement.Vars)),
+		slog.Duration("duration", elapsed),
+		slog.Int64("rows", tx.Statement.RowsAffected),
+		slog.String("caller", caller()),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", tx.Error.Error()))
+	}
+	logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// redact replaces the arguments of a statement that can hold personal data,
+// such as names and cities, keeping numbers, booleans and times, which are
+// what is needed to understand a slow query.
+func redact(vars []interface{}) []interface{} {
+	redacted := make([]interface{}, len(vars))
+	for i, v := range vars {
+		switch value := v.(type) {
+		case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, time.Time:
+			redacted[i] = v
+		case *uint:
+			if value != nil {
+				redacted[i] = *value
+			}
+		default:
+			redacted[i] = "[redacted]"
+		}
+	}
+	return redacted
+}
+
+// caller returns the function of the project that ran the statement, e.g.
+// "db.CreateStudent", skipping GORM and the callbacks themselves.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "exercise1/") && !strings.Contains(frame.Function, "queryLogger") {
+			return strings.TrimPrefix(frame.Function, "exercise1/")
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// gormLogger routes the messages of GORM itself to the db logger. Statements
+// are logged by queryLogger instead, which has the statement at hand.
+type gormLogger struct{}
+
+func (l gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (gormLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	logger.InfoContext(ctx, fmt.Sprintf(format, args...))
+}
+
+func (gormLogger) Warn(ctx context.Context, format string, args ...interface{}) {
+	logger.WarnContext(ctx, fmt.Sprintf(format, args...))
+}
+
+func (gormLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	logger.ErrorContext(ctx, fmt.Sprintf(format, args...))
+}
+
+func (gormLogger) Trace(context.Context, time.Time, func() (string, int64), error) {}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"errors"
+	"exercise1/validation"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+// The metrics are registered with the default Prometheus registry, which the
+// api package serves at /metrics.
+var (
+	operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "university",
+		Subsystem: "db",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of the operations of the db package, including validation.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"operation"})
+
+	operationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "university",
+		Subsystem: "db",
+		Name:      "operation_errors_total",
+		Help:      "Failed operations of the db package by type of error.",
+	}, []string{"operation", "type"})
+
+	enrollmentsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "university",
+		Name:      "enrollments_total",
+		Help:      "Students enrolled for a course.",
+	})
+
+	dropsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "university",
+		Name:      "enrollment_drops_total",
+		Help:      "Students dropped from a course.",
+	})
+
+	studentsCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "university",
+		Name:      "students_created_total",
+		Help:      "Students created.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(poolCollector{})
+}
+
+// observer starts timing an operation. The returned function records its
+// duration and, when *err is set, its error; track calls it when the
+// operation ends.
+func observer(operation string) func(err *error) {
+	start := time.Now()
+	return func(err *error) {
+		operationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		if err != nil && *err != nil {
+			operationErrors.WithLabelValues(operation, errorType(*err)).Inc()
+		}
+	}
+}
+
+// errorType is the type label of an error, so that rejected input can be told
+// apart from the database failing.
+func errorType(err error) string {
+	var invalid validation.Errors
+	var conflict *ConflictError
+	var overloaded *TeachingLoadError
+	switch {
+	case errors.As(err, &invalid):
+		return "validation"
+	case errors.As(err, &conflict):
+		return "conflict"
+	case errors.As(err, &overloaded):
+		return "teaching_load"
+	case errors.Is(err, ErrDepartmentCycle):
+		return "cycle"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "not_found"
+	default:
+		return "database"
+	}
+}
+
+var (
+	poolOpenDesc    = prometheus.NewDesc("university_db_pool_open_connections", "Open connections to the database, in use or idle.", nil, nil)
+	poolInUseDesc   = prometheus.NewDesc("university_db_pool_in_use_connections", "Connections currently in use.", nil, nil)
+	poolIdleDesc    = prometheus.NewDesc("university_db_pool_idle_connections", "Idle connections.", nil, nil)
+	poolMaxOpenDesc = prometheus.NewDesc("university_db_pool_max_open_connections", "Maximum number of open connections, 0 meaning unlimited.", nil, nil)
+	poolWaitsDesc   = prometheus.NewDesc("university_db_pool_waits_total", "Times a query had to wait for a free connection.", nil, nil)
+	poolWaitDesc    = prometheus.NewDesc("university_db_pool_wait_seconds_total", "Time spent waiting for a free connection.", nil, nil)
+)
+
+// poolCollector reports the statistics of the connection pool at scrape
+// time, so it keeps working when Connect replaces the pool.
+type poolCollector struct{}
+
+func (poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolOpenDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolMaxOpenDesc
+	ch <- poolWaitsDesc
+	ch <- poolWaitDesc
+}
+
+func (poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := Stats()
+	ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(poolMaxOpenDesc, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(poolWaitsDesc, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(poolWaitDesc, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
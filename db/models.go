@@ -6,14 +6,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// Student and Instructor keep personal data encrypted, see encryption.go;
+// the Index fields are blind indexes to look them up by.
 type Student struct {
-	Id           uint `gorm:"primaryKey"`
-	FullName     string
-	Age          uint
-	City         string
-	Courses      []Course `gorm:"many2many:enrollments;constraint:OnDelete:CASCADE;"`
-	DepartmentId uint
-	CreatedAt    time.Time
+	Id            uint   `gorm:"primaryKey"`
+	FullName      string `gorm:"serializer:encrypted"`
+	FullNameIndex string `gorm:"index" json:"-" blindindex:"FullName"`
+	Age           uint
+	City          string   `gorm:"serializer:encrypted"`
+	CityIndex     string   `gorm:"index" json:"-" blindindex:"City"`
+	Courses       []Course `gorm:"many2many:enrollments;constraint:OnDelete:CASCADE;"`
+	DepartmentId  *uint
+	CreatedAt     time.Time
+	// ErasedAt is when the personal data of the student was erased, see
+	// EraseStudent.
+	ErasedAt *time.Time
+	Version  uint  `gorm:"not null;default:1"`
+	TenantId *uint `gorm:"index" json:"-"`
 }
 
 type Course struct {
@@ -21,30 +30,78 @@ type Course struct {
 	Name         string
 	Students     []Student `gorm:"many2many:enrollments;constraint:OnDelete:CASCADE;"`
 	DepartmentId uint
-	InstructorId uint
+	InstructorId *uint
+	Credits      uint
+	Term         string        `gorm:"index"`
+	Staff        []CourseStaff `gorm:"foreignKey:CourseId;constraint:OnDelete:CASCADE;"`
 	DeletedAt    gorm.DeletedAt
+	Version      uint  `gorm:"not null;default:1"`
+	TenantId     *uint `gorm:"index" json:"-"`
 }
 
+// Department is a node of the organisational hierarchy. Schools contain
+// faculties, faculties contain departments; ParentId is nil for the roots.
 type Department struct {
 	Id          uint `gorm:"primaryKey"`
 	Name        string
-	Students    []Student    `gorm:"foreignKey:DepartmentId"`
+	Kind        string       `gorm:"not null;default:department"`
+	ParentId    *uint        `gorm:"index"`
+	Children    []Department `gorm:"foreignKey:ParentId"`
+	Students    []Student    `gorm:"foreignKey:DepartmentId;constraint:OnDelete:SET NULL;"`
 	Courses     []Course     `gorm:"foreignKey:DepartmentId"`
 	Instructors []Instructor `gorm:"foreignKey:DepartmentId"`
+	// MaxTeachingLoad applies to instructors of the department that have no
+	// limit of their own.
+	MaxTeachingLoad *uint
+	Version         uint  `gorm:"not null;default:1"`
+	TenantId        *uint `gorm:"index" json:"-"`
 }
 
+const (
+	KindSchool     = "school"
+	KindFaculty    = "faculty"
+	KindDepartment = "department"
+)
+
 type Instructor struct {
-	Id           uint `gorm:"primaryKey"`
-	FullName     string
-	Age          uint
-	DepartmentId uint
-	Courses      []Course `gorm:"foreignKey:InstructorId;constraint:OnDelete:SET NULL;"`
-	UpdatedAt    time.Time
+	Id              uint   `gorm:"primaryKey"`
+	FullName        string `gorm:"serializer:encrypted"`
+	FullNameIndex   string `gorm:"index" json:"-" blindindex:"FullName"`
+	Age             uint
+	DepartmentId    uint
+	Courses         []Course `gorm:"foreignKey:InstructorId;constraint:OnDelete:SET NULL;"`
+	MaxTeachingLoad *uint
+	UpdatedAt       time.Time
+	Version         uint  `gorm:"not null;default:1"`
+	TenantId        *uint `gorm:"index" json:"-"`
 }
 
+// CourseStaff assigns an instructor a role in a course besides the primary
+// instructor kept in Course.InstructorId.
+type CourseStaff struct {
+	CourseId     uint       `gorm:"primaryKey"`
+	InstructorId uint       `gorm:"primaryKey"`
+	Instructor   Instructor `gorm:"constraint:OnDelete:CASCADE;"`
+	Role         string     `gorm:"not null"`
+	TenantId     *uint      `gorm:"index" json:"-"`
+}
+
+const (
+	RoleLead              = "lead"
+	RoleCoInstructor      = "co-instructor"
+	RoleTeachingAssistant = "ta"
+	RoleGrader            = "grader"
+)
+
+// StaffRoles lists every course staff role.
+var StaffRoles = []string{RoleLead, RoleCoInstructor, RoleTeachingAssistant, RoleGrader}
+
+// Enrollment is the join table of Student.Courses and Course.Students, set
+// up in Connect so that it carries the tenant like every other model.
 type Enrollment struct {
-	StudentId uint
-	CourseId  uint
+	StudentId uint  `gorm:"primaryKey"`
+	CourseId  uint  `gorm:"primaryKey"`
+	TenantId  *uint `gorm:"index" json:"-"`
 }
 
 func (student *Student) BeforeCreate(tx *gorm.DB) error {
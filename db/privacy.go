@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StudentExport is everything stored about a student, handed to them when
+// they ask for their data. There is no audit log; the closest record of what
+// happened are the sign-ins of their accounts.
+type StudentExport struct {
+	ExportedAt time.Time
+	// Student carries the courses the student is enrolled in.
+	Student    Student
+	Department *Department
+	Accounts   []AccountExport
+}
+
+// AccountExport is a user account of the student with the history of its
+// sign-ins. The hashes of its password and tokens are left out.
+type AccountExport struct {
+	User           User
+	Sessions       []SessionExport
+	PasswordResets []PasswordResetExport
+}
+
+// SessionExport is a refresh token, i.e. a sign-in.
+type SessionExport struct {
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+type PasswordResetExport struct {
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// ExportStudent gathers everything linked to the student. It returns
+// gorm.ErrRecordNotFound when there is no such student.
+func ExportStudent(ctx context.Context, id uint) (_ StudentExport, err error) {
+	ctx, done := track(ctx, "ExportStudent")
+	defer done(&err)
+	export := StudentExport{ExportedAt: time.Now().UTC()}
+	err = conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Courses").First(&export.Student, id).Error; err != nil {
+			return err
+		}
+		if export.Student.DepartmentId != nil {
+			var department Department
+			if err := tx.First(&department, *export.Student.DepartmentId).Error; err == nil {
+				export.Department = &department
+			}
+		}
+
+		var users []User
+		if err := tx.Where("student_id = ?", id).Order("id").Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			account := AccountExport{User: user}
+			var sessions []RefreshToken
+			if err := tx.Where("user_id = ?", user.Id).Order("created_at").Find(&sessions).Error; err != nil {
+				return err
+			}
+			for _, session := range sessions {
+				account.Sessions = append(account.Sessions, SessionExport{session.CreatedAt, session.ExpiresAt, session.RevokedAt})
+			}
+			var resets []PasswordResetToken
+			if err := tx.Where("user_id = ?", user.Id).Order("created_at").Find(&resets).Error; err != nil {
+				return err
+			}
+			for _, reset := range resets {
+				account.PasswordResets = append(account.PasswordResets, PasswordResetExport{reset.CreatedAt, reset.ExpiresAt, reset.UsedAt})
+			}
+			export.Accounts = append(export.Accounts, account)
+		}
+		return nil
+	})
+	if err != nil {
+		return StudentExport{}, err
+	}
+	return export, nil
+}
+
+// EraseStudent anonymises the student for good: their name and city are
+// blanked and their user accounts deleted with their tokens. The row itself,
+// its age, department and enrollments stay, so that counts like
+// GetStudentCountForEachDepartment do not change; report.Cities counts
+// erased students without a city. It returns gorm.ErrRecordNotFound when
+// there is no such student.
+//
+// Postgres keeps the old row versions until it vacuums the table, and
+// backups keep them until they expire.
+func EraseStudent(ctx context.Context, id uint, at time.Time) (err error) {
+	ctx, done := track(ctx, "EraseStudent")
+	defer done(&err)
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Student{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"full_name": "",
+			"city":      "",
+			"erased_at": at,
+			"version":   gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("student_id = ?", id).Delete(&User{}).Error
+	})
+}
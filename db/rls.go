@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// departmentSetting is the session variable the row-level security policies
+// read the department of the current transaction from.
+const departmentSetting = "app.department_id"
+
+// allDepartmentsSetting is the session variable that lets transactions which
+// have not set a department see the rows of every department. This package
+// sets it only for the transactions of AllDepartments and Begin; other
+// sessions see none of those rows unless their role sets it, e.g. with
+//
+//	ALTER ROLE reporting SET app.all_departments = 'on';
+const allDepartmentsSetting = "app.all_departments"
+
+// departmentScoped lists the tables under row-level security together with
+// the condition a row has to meet to belong to the departments of the
+// session. Enrollments belong to the department of their course; the
+// subquery on courses is itself subject to the policy of courses.
+var departmentScoped = []struct {
+	table     string
+	condition string
+}{
+	{"students", "department_id IN (SELECT session_departments())"},
+	{"courses", "department_id IN (SELECT session_departments())"},
+	{"instructors", "department_id IN (SELECT session_departments())"},
+	{"enrollments", "course_id IN (SELECT id FROM courses)"},
+}
+
+// sessionDepartments returns the department of the session followed by the
+// departments below it, like FindDepartmentSubtree, with the same guard
+// against cycles.
+const sessionDepartments = `CREATE OR REPLACE FUNCTION session_departments() RETURNS SETOF bigint
+LANGUAGE sql STABLE AS $$
+	WITH RECURSIVE subtree(id, path) AS (
+		SELECT id, ARRAY[id] FROM departments WHERE id = NULLIF(current_setting('` + departmentSetting + `', true), '')::bigint
+		UNION ALL
+		SELECT departments.id, subtree.path || departments.id FROM departments
+		INNER JOIN subtree ON departments.parent_id = subtree.id
+		WHERE NOT departments.id = ANY(subtree.path)
+	)
+	SELECT id FROM subtree
+$$`
+
+// enableRowLevelSecurity creates the policies of the department scoped
+// tables. They fail closed: sessions that have not set the department see no
+// rows, unless they bypass the policies explicitly with allDepartmentsSetting,
+// as AllDepartments does for the registrar, the CLI and migrations. The
+// policies are forced so that they apply to the owner of the tables as well.
+func enableRowLevelSecurity(d *gorm.DB) error {
+	return d.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sessionDepartments).Error; err != nil {
+			return err
+		}
+		for _, scoped := range departmentScoped {
+			statements := []string{
+				fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", scoped.table),
+				fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", scoped.table),
+				fmt.Sprintf("DROP POLICY IF EXISTS department_scope ON %s", scoped.table),
+				fmt.Sprintf(`CREATE POLICY department_scope ON %s USING (CASE
+					WHEN NULLIF(current_setting('%s', true), '') IS NULL THEN COALESCE(current_setting('%s', true), '') = 'on'
+					ELSE %s END)`, scoped.table, departmentSetting, allDepartmentsSetting, scoped.condition),
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return fmt.Errorf("row-level security on %s: %w", scoped.table, err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+type txKey struct{}
+
+// WithDepartment runs fn in a transaction in which the database only lets
+// through the students, courses, instructors and enrollments of the
+// department with the given id and of the departments below it. The db
+// functions called with the context passed to fn run in that transaction, so
+// even raw SQL cannot read or change the rows of other departments. The
+// transaction is committed when fn returns nil and rolled back otherwise.
+//
+// The bypass of AllDepartments is turned off, so that clearing the
+// department does not reveal the rows of the others.
+//
+// Postgres does not apply row-level security to superusers and roles with
+// BYPASSRLS; see RowLevelSecurityBypassed.
+func WithDepartment(ctx context.Context, departmentId uint, fn func(ctx context.Context) error) error {
+	_, nested := ctx.Value(txKey{}).(*gorm.DB)
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Nested in another transaction, this one is a savepoint, and the
+		// settings would outlive it; they are put back when fn succeeds.
+		var previous struct{ Department, AllDepartments string }
+		if nested {
+			err := tx.Raw("SELECT COALESCE(current_setting(?, true), '') AS department, COALESCE(current_setting(?, true), '') AS all_departments",
+				departmentSetting, allDepartmentsSetting).Scan(&previous).Error
+			if err != nil {
+				return err
+			}
+		}
+		// set_config with is_local true is the function form of SET LOCAL,
+		// which takes no placeholders.
+		err := tx.Exec("SELECT set_config(?, ?, true), set_config(?, 'off', true)",
+			departmentSetting, strconv.FormatUint(uint64(departmentId), 10), allDepartmentsSetting).Error
+		if err != nil {
+			return err
+		}
+		if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+			return err
+		}
+		if nested {
+			return tx.Exec("SELECT set_config(?, ?, true), set_config(?, ?, true)",
+				departmentSetting, previous.Department, allDepartmentsSetting, previous.AllDepartments).Error
+		}
+		return nil
+	})
+}
+
+// AllDepartments runs fn in a transaction that sees the rows of every
+// department, like WithDepartment runs it in one that sees those of a
+// department. It is for the registrar, the CLI and migrations: the database
+// hides the rows of every department from sessions that use neither. The
+// transaction is committed when fn returns nil and rolled back otherwise.
+//
+// Nested in another transaction, fn runs in a savepoint that sees what that
+// transaction sees, so AllDepartments never widens WithDepartment.
+func AllDepartments(ctx context.Context, fn func(ctx context.Context) error) error {
+	_, nested := ctx.Value(txKey{}).(*gorm.DB)
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if !nested {
+			if err := bypassRowLevelSecurity(tx); err != nil {
+				return err
+			}
+		}
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// bypassRowLevelSecurity lets tx see the rows of every department until the
+// transaction ends.
+func bypassRowLevelSecurity(tx *gorm.DB) error {
+	return tx.Exec("SELECT set_config(?, 'on', true)", allDepartmentsSetting).Error
+}
+
+// Begin starts a transaction that sees the rows of every department and
+// returns a context whose db functions run in it, as inside AllDepartments,
+// together with the function rolling it back. It lets tests undo everything
+// they did, see the dbtest package.
+func Begin(ctx context.Context) (context.Context, func() error, error) {
+	tx := conn(ctx).Begin()
+	if tx.Error != nil {
+		return ctx, nil, tx.Error
+	}
+	if err := bypassRowLevelSecurity(tx); err != nil {
+		tx.Rollback()
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, txKey{}, tx), func() error { return tx.Rollback().Error }, nil
+}
+
+// Transaction runs fn with a context whose db functions run in one
+// transaction, or in a savepoint of the transaction ctx already has. The
+// transaction is committed when fn returns nil and rolled back otherwise.
+func Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// RowLevelSecurityBypassed reports whether the database user is exempt from
+// row-level security, which makes WithDepartment rely on the checks of the
+// application alone.
+func RowLevelSecurityBypassed(ctx context.Context) (bool, error) {
+	var bypassed bool
+	err := conn(ctx).Raw("SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypassed).Error
+	return bypassed, err
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMaxTeachingLoad is the limit for instructors when neither they nor
+// their department have one configured.
+const DefaultMaxTeachingLoad = 20
+
+// Every full group of this many enrolled students adds one load unit on top
+// of the course credits, for the extra grading and consultations: 29 students
+// add none, 30 add one.
+const studentsPerExtraLoadUnit = 30
+
+// CourseLoad is the teaching load of a single course.
+func CourseLoad(credits, enrolledStudents uint) uint {
+	return credits + enrolledStudents/studentsPerExtraLoadUnit
+}
+
+type TeachingLoad struct {
+	InstructorId uint
+	Term         string
+	CourseCount  uint
+	Credits      uint
+	StudentCount uint
+	Load         uint
+}
+
+// TeachingLoadError is returned when assigning a course would push an
+// instructor over their maximum teaching load for the term.
+type TeachingLoadError struct {
+	InstructorId uint
+	Term         string
+	Load         uint
+	Max          uint
+}
+
+func (e *TeachingLoadError) Error() string {
+	return fmt.Sprintf("instructor %d would teach a load of %d in term %q, but the maximum is %d", e.InstructorId, e.Load, e.Term, e.Max)
+}
+
+// loadRoles are the staff roles that count towards the teaching load of an
+// instructor, as being the primary instructor of a course does. Graders do
+// not teach.
+var loadRoles = []string{RoleLead, RoleCoInstructor, RoleTeachingAssistant}
+
+func countEnrolledStudents(ctx context.Context, courseId uint) uint {
+	var count int64
+	conn(ctx).Model(&Enrollment{}).Where("course_id = ?", courseId).Count(&count)
+	return uint(count)
+}
+
+// teachingLoads sums the load of the instructors per term in one query, over
+// the courses they are the primary instructor of or have one of loadRoles
+// in. The conditions restrict the courses and their instructors, e.g.
+// "teaching.instructor_id = ?".
+func teachingLoads(ctx context.Context, conds ...interface{}) []TeachingLoad {
+	primary := conn(ctx).Model(&Course{}).Select("id AS course_id, instructor_id").Where("instructor_id IS NOT NULL")
+	staff := conn(ctx).Model(&CourseStaff{}).Select("course_id, instructor_id").Where("role IN ?", loadRoles)
+	enrolled := conn(ctx).Model(&Enrollment{}).Select("course_id, COUNT(*) AS students").Group("course_id")
+
+	query := conn(ctx).Model(&Course{}).
+		Select(`teaching.instructor_id, courses.term, COUNT(*) AS course_count, SUM(courses.credits) AS credits,
+			SUM(COALESCE(enrolled.students, 0)) AS student_count,
+			SUM(courses.credits + COALESCE(enrolled.students, 0) / ?) AS load`, studentsPerExtraLoadUnit).
+		// UNION drops the staff row of a primary instructor listed as lead.
+		Joins("INNER JOIN (? UNION ?) AS teaching ON teaching.course_id = courses.id", primary, staff).
+		Joins("LEFT JOIN (?) AS enrolled ON enrolled.course_id = courses.id", enrolled).
+		Group("teaching.instructor_id, courses.term")
+	if len(conds) > 0 {
+		query = query.Where(conds[0], conds[1:]...)
+	}
+	var loads []TeachingLoad
+	query.Find(&loads)
+	return loads
+}
+
+// GetTeachingLoad sums the load of the courses the instructor teaches in the
+// given term.
+func GetTeachingLoad(ctx context.Context, instructorId uint, term string) TeachingLoad {
+	for _, load := range teachingLoads(ctx, "teaching.instructor_id = ? AND courses.term = ?", instructorId, term) {
+		return load
+	}
+	return TeachingLoad{InstructorId: instructorId, Term: term}
+}
+
+// GetTeachingLoads returns the load of every instructor in every term that has
+// courses, including terms in which an instructor teaches nothing.
+func GetTeachingLoads(ctx context.Context) []TeachingLoad {
+	var terms []string
+	conn(ctx).Model(&Course{}).Distinct("term").Order("term").Pluck("term", &terms)
+
+	type key struct {
+		instructorId uint
+		term         string
+	}
+	taught := map[key]TeachingLoad{}
+	for _, load := range teachingLoads(ctx) {
+		taught[key{load.InstructorId, load.Term}] = load
+	}
+
+	var loads []TeachingLoad
+	for _, instructor := range FindAllInstructors(ctx) {
+		for _, term := range terms {
+			load, ok := taught[key{instructor.Id, term}]
+			if !ok {
+				load = TeachingLoad{InstructorId: instructor.Id, Term: term}
+			}
+			loads = append(loads, load)
+		}
+	}
+	return loads
+}
+
+// MaxTeachingLoad returns the limit of the instructor: their own, otherwise
+// their department's, otherwise DefaultMaxTeachingLoad.
+func MaxTeachingLoad(ctx context.Context, instructor Instructor) uint {
+	if instructor.MaxTeachingLoad != nil {
+		return *instructor.MaxTeachingLoad
+	}
+	department := FindDepartmentById(ctx, int(instructor.DepartmentId))
+	if department.MaxTeachingLoad != nil {
+		return *department.MaxTeachingLoad
+	}
+	return DefaultMaxTeachingLoad
+}
+
+// checkTeachingLoad verifies the instructor can teach course with the given
+// number of students on top of what they teach besides it in its term.
+//
+// The instructor is locked until the transaction of ctx ends, so checks for
+// the same instructor wait for each other and see the courses and students
+// the earlier one added; the caller has to change the course in the same
+// transaction.
+func checkTeachingLoad(ctx context.Context, instructorId uint, course Course, students uint) error {
+	var instructor Instructor
+	err := conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&instructor, instructorId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	load := CourseLoad(course.Credits, students)
+	for _, other := range teachingLoads(ctx, "teaching.instructor_id = ? AND courses.term = ? AND courses.id <> ?", instructorId, course.Term, course.Id) {
+		load += other.Load
+	}
+
+	if max := MaxTeachingLoad(ctx, instructor); load > max {
+		return &TeachingLoadError{InstructorId: instructorId, Term: course.Term, Load: load, Max: max}
+	}
+	return nil
+}
+
+// checkCourseLoads verifies that everyone teaching the course can teach it as
+// it is, with the students now enrolled in it.
+func checkCourseLoads(ctx context.Context, course Course) error {
+	var teachers []uint
+	err := conn(ctx).Model(&CourseStaff{}).Where("course_id = ? AND role IN ?", course.Id, loadRoles).Pluck("instructor_id", &teachers).Error
+	if err != nil {
+		return err
+	}
+	if course.InstructorId != nil && !slices.Contains(teachers, *course.InstructorId) {
+		teachers = append(teachers, *course.InstructorId)
+	}
+	// Sorted, so that enrollments in courses sharing instructors lock them in
+	// the same order.
+	slices.Sort(teachers)
+
+	students := countEnrolledStudents(ctx, course.Id)
+	for _, instructorId := range teachers {
+		if err := checkTeachingLoad(ctx, instructorId, course, students); err != nil {
+			return err
+		}
+	}
+	return nil
+}
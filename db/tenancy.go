@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"exercise1/validation"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Tenant is one university hosted in the database. Every model with a
+// TenantId belongs to one; in a context WithTenant, the queries of this
+// package see and create only the rows of that tenant.
+type Tenant struct {
+	Id        uint   `gorm:"primaryKey"`
+	Slug      string `gorm:"uniqueIndex;not null"`
+	Name      string `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+const slugPattern = `^[a-z0-9]+(-[a-z0-9]+)*$`
+
+func tenantSchema(ctx context.Context) validation.Schema {
+	return validation.Schema{
+		validation.F("Slug", validation.Required(), validation.Length(2, 50), validation.Matches(slugPattern, "contain only lowercase letters, digits and single hyphens"), unique(ctx, &Tenant{}, "slug", 0)),
+		validation.F("Name", validation.Required(), validation.Length(2, 100)),
+	}
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose queries are scoped to the tenant with
+// the given id.
+func WithTenant(ctx context.Context, tenantId uint) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantId)
+}
+
+// TenantFromContext returns the tenant the queries of ctx are scoped to.
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	tenantId, ok := ctx.Value(tenantKey{}).(uint)
+	return tenantId, ok
+}
+
+// withoutTenant lifts the scope of ctx, for managing the tenants themselves.
+func withoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, nil)
+}
+
+// tenantPlugin scopes every statement GORM builds for a model with a
+// TenantId to the tenant of its context: queries, updates and deletes get a
+// condition on tenant_id, and created rows get the tenant assigned. Subqueries
+// built from a model are scoped as well, but Raw SQL and tables named with
+// Table are not; they have to filter by tenant themselves or only be joined
+// to scoped tables by id.
+type tenantPlugin struct{}
+
+func (tenantPlugin) Name() string {
+	return "tenant"
+}
+
+func (tenantPlugin) Initialize(d *gorm.DB) error {
+	c := d.Callback()
+	return errors.Join(
+		c.Create().Before("gorm:create").Register("tenant:assign", assignTenant),
+		c.Query().Before("gorm:query").Register("tenant:scope_query", scopeTenant),
+		c.Update().Before("gorm:update").Register("tenant:scope_update", scopeTenant),
+		c.Delete().Before("gorm:delete").Register("tenant:scope_delete", scopeTenant),
+		c.Row().Before("gorm:row").Register("tenant:scope_row", scopeTenant),
+	)
+}
+
+// tenantScope returns the tenant of the statement and the field holding it,
+// when the statement is to be scoped.
+func tenantScope(tx *gorm.DB) (uint, *schema.Field, bool) {
+	tenantId, ok := TenantFromContext(tx.Statement.Context)
+	if !ok || tx.Statement.Schema == nil {
+		return 0, nil, false
+	}
+	field := tx.Statement.Schema.LookUpField("TenantId")
+	return tenantId, field, field != nil
+}
+
+func scopeTenant(tx *gorm.DB) {
+	tenantId, field, ok := tenantScope(tx)
+	if !ok {
+		return
+	}
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantId},
+	}})
+}
+
+func assignTenant(tx *gorm.DB) {
+	tenantId, field, ok := tenantScope(tx)
+	if !ok {
+		return
+	}
+	set := func(row reflect.Value) {
+		id := tenantId
+		tx.AddError(field.Set(tx.Statement.Context, row, &id))
+	}
+	rows := tx.Statement.ReflectValue
+	switch rows.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rows.Len(); i++ {
+			set(reflect.Indirect(rows.Index(i)))
+		}
+	case reflect.Struct:
+		set(rows)
+	}
+}
+
+func CreateTenant(ctx context.Context, tenant Tenant) (_ Tenant, err error) {
+	ctx, done := track(ctx, "CreateTenant")
+	defer done(&err)
+	if err := tenantSchema(ctx).Validate(tenant); err != nil {
+		return Tenant{}, err
+	}
+	err = conn(ctx).Create(&tenant).Error
+	return tenant, err
+}
+
+func FindTenantById(ctx context.Context, id int) Tenant {
+	tenant := Tenant{}
+	var err error
+	ctx, done := track(ctx, "FindTenantById")
+	defer done(&err)
+	err = conn(ctx).First(&tenant, id).Error
+	return tenant
+}
+
+func FindTenantBySlug(ctx context.Context, slug string) Tenant {
+	tenant := Tenant{}
+	var err error
+	ctx, done := track(ctx, "FindTenantBySlug")
+	defer done(&err)
+	err = conn(ctx).Where("slug = ?", slug).First(&tenant).Error
+	return tenant
+}
+
+func FindAllTenants(ctx context.Context) []Tenant {
+	var tenants []Tenant
+	var err error
+	ctx, done := track(ctx, "FindAllTenants")
+	defer done(&err)
+	err = conn(ctx).Order("id").Find(&tenants).Error
+	return tenants
+}
+
+// tenantTables lists the tables of the tenant scoped models, in an order in
+// which their rows can be deleted without violating foreign keys.
+var tenantTables = []interface{}{
+	&APIKey{}, &User{}, &Enrollment{}, &CourseStaff{}, &Course{}, &Student{}, &Instructor{}, &Department{},
+}
+
+// AdoptUnassignedRows assigns the rows that belong to no tenant, e.g. those
+// of a database from before multi-tenant mode, to the tenant with id.
+func AdoptUnassignedRows(ctx context.Context, tenantId uint) (err error) {
+	ctx, done := track(ctx, "AdoptUnassignedRows")
+	defer done(&err)
+	return conn(withoutTenant(ctx)).Transaction(func(tx *gorm.DB) error {
+		for _, model := range tenantTables {
+			if err := tx.Unscoped().Model(model).Where("tenant_id IS NULL").Update("tenant_id", tenantId).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTenant deletes the tenant with id together with all of its rows.
+func DeleteTenant(ctx context.Context, id uint) (err error) {
+	ctx, done := track(ctx, "DeleteTenant")
+	defer done(&err)
+	return conn(withoutTenant(ctx)).Transaction(func(tx *gorm.DB) error {
+		for _, model := range tenantTables {
+			if err := tx.Unscoped().Where("tenant_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Delete(&Tenant{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
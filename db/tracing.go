@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracer returns the tracer of the provider installed globally, see the
+// tracing package; without one the spans cost next to nothing. It is looked
+// up on every use so that a provider installed later, e.g. by a test, takes
+// effect.
+func tracer() trace.Tracer {
+	return otel.Tracer("exercise1/db")
+}
+
+// track starts the span of an operation and returns the context to run its
+// queries with, so their spans become children of it, and a function ending
+// the span and recording the metrics of the operation:
+//
+//	ctx, done := track(ctx, "CreateStudent")
+//	defer done(&err)
+func track(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	ctx, span := tracer().Start(ctx, "db."+operation, trace.WithAttributes(attribute.String("db.operation.name", operation)))
+	done := observer(operation)
+	return ctx, func(err *error) {
+		done(err)
+		if err != nil && *err != nil {
+			span.SetAttributes(attribute.String("error.type", errorType(*err)))
+			// A missing row is an answer, not a failure of the operation.
+			if !errors.Is(*err, gorm.ErrRecordNotFound) {
+				span.RecordError(*err)
+				span.SetStatus(codes.Error, (*err).Error())
+			}
+		}
+		span.End()
+	}
+}
+
+const spanKey = "tracing:span"
+
+// tracingPlugin adds a span for every SQL statement GORM runs, carrying the
+// statement with placeholders instead of values, so no personal data ends up
+// in the traces.
+type tracingPlugin struct{}
+
+func (tracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (p tracingPlugin) Initialize(d *gorm.DB) error {
+	return aroundStatements(d, p.Name(), p.before, p.after)
+}
+
+func (tracingPlugin) before(kind string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, span := tracer().Start(tx.Statement.Context, "gorm."+kind,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "postgresql")))
+		tx.Statement.Context = ctx
+		tx.InstanceSet(spanKey, span)
+	}
+}
+
+func (tracingPlugin) after(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.statement", tx.Statement.SQL.String()),
+		attribute.String("db.sql.table", tx.Statement.Table),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(tx.Error)
+		span.SetStatus(codes.Error, tx.Error.Error())
+	}
+	span.End()
+}
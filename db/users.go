@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"exercise1/validation"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User is an account of someone using the API, optionally linked to their
+// Student or Instructor record. Passwords are only stored as bcrypt hashes.
+// Emails are unique per tenant; Postgres treats NULLs as distinct, so the
+// emails of users of no tenant have an index of their own.
+type User struct {
+	Id           uint   `gorm:"primaryKey"`
+	Email        string `gorm:"uniqueIndex:idx_users_tenant_email;uniqueIndex:idx_users_email_without_tenant,where:tenant_id IS NULL;not null"`
+	PasswordHash string `gorm:"not null" json:"-"`
+	StudentId    *uint
+	Student      *Student `gorm:"constraint:OnDelete:SET NULL;" json:"-"`
+	InstructorId *uint
+	Instructor   *Instructor `gorm:"constraint:OnDelete:SET NULL;" json:"-"`
+	// Role is what the user may do, see the access package. Department
+	// admins manage the department DepartmentId.
+	Role         string `gorm:"not null"`
+	DepartmentId *uint
+	Department   *Department `gorm:"constraint:OnDelete:SET NULL;" json:"-"`
+	// FailedLogins counts the failed logins since the last successful one;
+	// reaching the limit locks the account until LockedUntil.
+	FailedLogins uint `gorm:"not null;default:0"`
+	LockedUntil  *time.Time
+	CreatedAt    time.Time
+	Version      uint  `gorm:"not null;default:1"`
+	TenantId     *uint `gorm:"uniqueIndex:idx_users_tenant_email" json:"-"`
+}
+
+// RefreshToken is a long-lived token exchanged for new access tokens. Only
+// the SHA-256 hash of the token is stored, and every token is used once:
+// refreshing revokes it and issues a new one.
+type RefreshToken struct {
+	Id        uint   `gorm:"primaryKey"`
+	UserId    uint   `gorm:"not null;index"`
+	User      User   `gorm:"constraint:OnDelete:CASCADE;"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordResetToken lets a user who forgot their password set a new one.
+// Like refresh tokens only its hash is stored, and it can be used once.
+type PasswordResetToken struct {
+	Id        uint   `gorm:"primaryKey"`
+	UserId    uint   `gorm:"not null;index"`
+	User      User   `gorm:"constraint:OnDelete:CASCADE;"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+const (
+	emailPattern = `^[^@\s]+@[^@\s]+\.[^@\s]+$`
+	rolePattern  = `^(registrar|department_admin|instructor|student)$`
+)
+
+func userSchema(ctx context.Context, id uint) validation.Schema {
+	return validation.Schema{
+		validation.F("Email", validation.Required(), validation.Length(3, 254), validation.Matches(emailPattern, "be an email address"), unique(ctx, &User{}, "email", id)),
+		validation.F("PasswordHash", validation.Required()),
+		validation.F("StudentId", exists(ctx, &Student{}, "student")),
+		validation.F("InstructorId", exists(ctx, &Instructor{}, "instructor")),
+		validation.F("Role", validation.Required(), validation.Matches(rolePattern, "be one of registrar, department_admin, instructor or student")),
+		validation.F("DepartmentId", exists(ctx, &Department{}, "department")),
+	}
+}
+
+// roleLinks checks that the user is linked to the record their role is
+// about, which the rules of single fields cannot see.
+func roleLinks(user User) error {
+	var missing string
+	switch {
+	case user.Role == "student" && user.StudentId == nil:
+		missing = "StudentId"
+	case user.Role == "instructor" && user.InstructorId == nil:
+		missing = "InstructorId"
+	case user.Role == "department_admin" && user.DepartmentId == nil:
+		missing = "DepartmentId"
+	default:
+		return nil
+	}
+	return validation.Errors{{Field: missing, Message: "is required for the role " + user.Role}}
+}
+
+func CreateUser(ctx context.Context, user User) (_ User, err error) {
+	ctx, done := track(ctx, "CreateUser")
+	defer done(&err)
+	user.Email = normalizeEmail(user.Email)
+	if err := userSchema(ctx, 0).Validate(user); err != nil {
+		return User{}, err
+	}
+	if err := roleLinks(user); err != nil {
+		return User{}, err
+	}
+	err = conn(ctx).Create(&user).Error
+	return user, err
+}
+
+func FindUserById(ctx context.Context, id int) User {
+	user := User{}
+	var err error
+	ctx, done := track(ctx, "FindUserById")
+	defer done(&err)
+	err = conn(ctx).First(&user, id).Error
+	return user
+}
+
+func FindUserByEmail(ctx context.Context, email string) User {
+	user := User{}
+	var err error
+	ctx, done := track(ctx, "FindUserByEmail")
+	defer done(&err)
+	err = conn(ctx).Where("email = ?", normalizeEmail(email)).First(&user).Error
+	return user
+}
+
+// normalizeEmail makes emails match regardless of case and surrounding
+// spaces.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func FindAllUsers(ctx context.Context) []User {
+	var users []User
+	var err error
+	ctx, done := track(ctx, "FindAllUsers")
+	defer done(&err)
+	err = conn(ctx).Order("id").Find(&users).Error
+	return users
+}
+
+func DeleteUser(ctx context.Context, user User) (err error) {
+	ctx, done := track(ctx, "DeleteUser")
+	defer done(&err)
+	return conn(ctx).Delete(&user).Error
+}
+
+// RecordFailedLogin counts a failed login of the user. When it is the
+// maxFailures-th in a row, the account is locked until lockedUntil. It
+// returns the number of failures so far.
+func RecordFailedLogin(ctx context.Context, userId uint, maxFailures uint, lockedUntil time.Time) (failures uint, err error) {
+	ctx, done := track(ctx, "RecordFailedLogin")
+	defer done(&err)
+	err = conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Incrementing in SQL keeps concurrent attempts from losing counts.
+		result := tx.Model(&User{}).Where("id = ?", userId).Update("failed_logins", gorm.Expr("failed_logins + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Model(&User{}).Select("failed_logins").Where("id = ?", userId).Scan(&failures).Error; err != nil {
+			return err
+		}
+		if failures < maxFailures {
+			return nil
+		}
+		return tx.Model(&User{}).Where("id = ?", userId).
+			Updates(map[string]interface{}{"failed_logins": 0, "locked_until": lockedUntil}).Error
+	})
+	return failures, err
+}
+
+// RecordSuccessfulLogin clears the failed logins and any lock of the user.
+func RecordSuccessfulLogin(ctx context.Context, userId uint) (err error) {
+	ctx, done := track(ctx, "RecordSuccessfulLogin")
+	defer done(&err)
+	return conn(ctx).Model(&User{}).Where("id = ?", userId).
+		Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+// SetUserPassword replaces the password hash of the user and unlocks the
+// account.
+func SetUserPassword(ctx context.Context, userId uint, passwordHash string) (err error) {
+	ctx, done := track(ctx, "SetUserPassword")
+	defer done(&err)
+	result := conn(ctx).Model(&User{}).Where("id = ?", userId).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"failed_logins": 0,
+		"locked_until":  nil,
+		"version":       gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func CreateRefreshToken(ctx context.Context, token RefreshToken) (err error) {
+	ctx, done := track(ctx, "CreateRefreshToken")
+	defer done(&err)
+	return conn(ctx).Omit("User").Create(&token).Error
+}
+
+func FindRefreshToken(ctx context.Context, tokenHash string) RefreshToken {
+	token := RefreshToken{}
+	var err error
+	ctx, done := track(ctx, "FindRefreshToken")
+	defer done(&err)
+	err = conn(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	return token
+}
+
+// RevokeRefreshToken revokes the token. It returns gorm.ErrRecordNotFound
+// when the token was already revoked, so of two concurrent refreshes with the
+// same token only one succeeds.
+func RevokeRefreshToken(ctx context.Context, id uint, at time.Time) (err error) {
+	ctx, done := track(ctx, "RevokeRefreshToken")
+	defer done(&err)
+	result := conn(ctx).Model(&RefreshToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens signs the user out everywhere.
+func RevokeUserRefreshTokens(ctx context.Context, userId uint, at time.Time) (err error) {
+	ctx, done := track(ctx, "RevokeUserRefreshTokens")
+	defer done(&err)
+	return conn(ctx).Model(&RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userId).Update("revoked_at", at).Error
+}
+
+func CreatePasswordResetToken(ctx context.Context, token PasswordResetToken) (err error) {
+	ctx, done := track(ctx, "CreatePasswordResetToken")
+	defer done(&err)
+	return conn(ctx).Omit("User").Create(&token).Error
+}
+
+// UsePasswordResetToken marks the token as used and returns it. Tokens that
+// are unknown, expired or already used return gorm.ErrRecordNotFound.
+func UsePasswordResetToken(ctx context.Context, tokenHash string, at time.Time) (_ PasswordResetToken, err error) {
+	ctx, done := track(ctx, "UsePasswordResetToken")
+	defer done(&err)
+	var token PasswordResetToken
+	err = conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, at).First(&token).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&token).Where("used_at IS NULL").Update("used_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	return token, err
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"exercise1/validation"
+	"fmt"
+)
+
+const personNamePattern = `^[\p{L}][\p{L} .'-]*$`
+
+// exists checks that a non-zero foreign key points to an existing row.
+func exists(ctx context.Context, model interface{}, entity string) validation.Rule {
+	return validation.RuleFunc(func(value interface{}) string {
+		if value == nil {
+			return ""
+		}
+		var count int64
+		conn(ctx).Model(model).Where("id = ?", value).Count(&count)
+		if count == 0 {
+			return fmt.Sprintf("refers to a %s that does not exist", entity)
+		}
+		return ""
+	})
+}
+
+// unique checks that no other row than the one with excludeId already has
+// the value in column.
+func unique(ctx context.Context, model interface{}, column string, excludeId uint) validation.Rule {
+	return validation.RuleFunc(func(value interface{}) string {
+		var count int64
+		conn(ctx).Model(model).Where(column+" = ? AND id <> ?", value, excludeId).Count(&count)
+		if count > 0 {
+			return "must be unique"
+		}
+		return ""
+	})
+}
+
+func studentSchema(ctx context.Context) validation.Schema {
+	return validation.Schema{
+		validation.F("FullName", validation.Required(), validation.Length(2, 100), validation.Matches(personNamePattern, "contain only letters, spaces, dots, apostrophes and hyphens")),
+		validation.F("Age", validation.Required(), validation.Range(16, 100)),
+		validation.F("City", validation.Length(0, 100)),
+		validation.F("DepartmentId", exists(ctx, &Department{}, "department")),
+	}
+}
+
+func courseSchema(ctx context.Context) validation.Schema {
+	return validation.Schema{
+		validation.F("Name", validation.Required(), validation.Length(2, 150)),
+		validation.F("DepartmentId", validation.Required(), exists(ctx, &Department{}, "department")),
+		validation.F("InstructorId", exists(ctx, &Instructor{}, "instructor")),
+		validation.F("Credits", validation.Range(0, 30)),
+		validation.F("Term", validation.Length(0, 20)),
+	}
+}
+
+func departmentSchema(ctx context.Context, id uint) validation.Schema {
+	return validation.Schema{
+		validation.F("Name", validation.Required(), validation.Length(2, 100), unique(ctx, &Department{}, "name", id)),
+		validation.F("Kind", validation.Matches("^(school|faculty|department)$", "be one of school, faculty or department")),
+		validation.F("ParentId", exists(ctx, &Department{}, "department")),
+	}
+}
+
+func instructorSchema(ctx context.Context) validation.Schema {
+	return validation.Schema{
+		validation.F("FullName", validation.Required(), validation.Length(2, 100), validation.Matches(personNamePattern, "contain only letters, spaces, dots, apostrophes and hyphens")),
+		validation.F("Age", validation.Required(), validation.Range(18, 100)),
+		validation.F("DepartmentId", validation.Required(), exists(ctx, &Department{}, "department")),
+	}
+}
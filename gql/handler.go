@@ -0,0 +1,102 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"exercise1/service"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type handler struct {
+	schema graphql.Schema
+	limits Limits
+}
+
+// NewHandler serves GraphQL operations sent as POST JSON bodies, and queries
+// sent as the query parameter of a GET request. Mutations are refused over
+// GET, so that links and cross-site GET requests cannot change anything.
+func NewHandler(limits Limits) (http.Handler, error) {
+	schema, err := NewSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &handler{schema: schema, limits: limits}, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if operationType(req.Query, req.OperationName) != ast.OperationTypeQuery {
+			w.Header().Set("Allow", "POST")
+			writeResult(w, http.StatusMethodNotAllowed, errorResult(errors.New("only queries can be sent with GET; use POST")))
+			return
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeResult(w, http.StatusBadRequest, errorResult(err))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeResult(w, http.StatusMethodNotAllowed, errorResult(http.ErrNotSupported))
+		return
+	}
+
+	if err := checkLimits(req.Query, req.OperationName, h.limits); err != nil {
+		writeResult(w, http.StatusBadRequest, errorResult(err))
+		return
+	}
+
+	ctx := service.WithPolicy(r.Context())
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        context.WithValue(ctx, loadersKey{}, newLoaders(ctx)),
+	})
+	writeResult(w, http.StatusOK, result)
+}
+
+// operationType returns the type of the operation of the query that would
+// be executed: the one named operationName, or the only one. Queries that do
+// not parse are reported as queries, so the executor reports their errors.
+func operationType(query string, operationName string) string {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return ast.OperationTypeQuery
+	}
+	for _, definition := range doc.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+				return operation.Operation
+			}
+		}
+	}
+	return ast.OperationTypeQuery
+}
+
+func errorResult(err error) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
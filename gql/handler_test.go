@@ -0,0 +1,134 @@
+package gql
+
+import (
+	"encoding/json"
+	"exercise1/access"
+	"exercise1/db"
+	"exercise1/db/dbtest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestGetAllowsOnlyQueries(t *testing.T) {
+	h, err := NewHandler(DefaultLimits)
+	if err != nil {
+		t.Fatalf("expected handler to build, got %v", err)
+	}
+	tests := []struct {
+		query, operationName string
+		status               int
+	}{
+		{`{ __typename }`, "", http.StatusOK},
+		{`query Name { __typename }`, "Name", http.StatusOK},
+		{`mutation { deleteStudent(id: 1) }`, "", http.StatusMethodNotAllowed},
+		{`query Read { __typename } mutation Write { deleteStudent(id: 1) }`, "Write", http.StatusMethodNotAllowed},
+		{`{ unparsable`, "", http.StatusOK},
+	}
+	for _, test := range tests {
+		target := "/graphql?" + url.Values{"query": {test.query}, "operationName": {test.operationName}}.Encode()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != test.status {
+			t.Errorf("GET %q expected status %d, got %d: %s", test.query, test.status, w.Code, w.Body.String())
+		}
+		if test.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "POST" {
+			t.Errorf("GET %q expected to allow POST, got %q", test.query, w.Header().Get("Allow"))
+		}
+	}
+}
+
+// createDepartments creates departments with two courses of an instructor
+// each and three students enrolled in both.
+func createDepartments(f *dbtest.Fixture, count int) {
+	for i := 0; i < count; i++ {
+		department := f.Department()
+		instructor := f.Instructor(func(i *db.Instructor) { i.DepartmentId = department.Id })
+		var courses []db.Course
+		for j := 0; j < 2; j++ {
+			courses = append(courses, f.Course(func(c *db.Course) { c.DepartmentId = department.Id; c.InstructorId = &instructor.Id }))
+		}
+		for j := 0; j < 3; j++ {
+			student := f.Student(func(s *db.Student) { s.DepartmentId = &department.Id })
+			for _, course := range courses {
+				f.Enroll(student, course)
+			}
+		}
+	}
+}
+
+// The statements are counted by their spans, so the test does not run in
+// parallel with others setting the tracer provider.
+func TestNestedQueriesRunConstantStatements(t *testing.T) {
+	f := dbtest.New(t)
+	h, err := NewHandler(DefaultLimits)
+	if err != nil {
+		t.Fatalf("expected handler to build, got %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	// run returns the departments the query found and the statements it ran.
+	run := func() (int, int) {
+		before := len(recorder.Ended())
+		body := `{"query": "{ departments { name students { fullName courses { name instructor { fullName } } } } }"}`
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		r = r.WithContext(access.WithPrincipal(f.Ctx, access.Principal{Role: access.Registrar}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		var result struct {
+			Data struct {
+				Departments []struct {
+					Students []struct {
+						Courses []struct {
+							Instructor *struct{ FullName string }
+						}
+					}
+				}
+			}
+			Errors []interface{}
+		}
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil || len(result.Errors) > 0 {
+			t.Fatalf("expected the query to succeed, got %v, %v", result.Errors, err)
+		}
+		for _, department := range result.Data.Departments {
+			for _, student := range department.Students {
+				if len(student.Courses) != 2 || student.Courses[0].Instructor == nil {
+					t.Fatalf("expected every student to have 2 courses with their instructor, got %+v", student)
+				}
+			}
+		}
+
+		statements := 0
+		for _, span := range recorder.Ended()[before:] {
+			if strings.HasPrefix(span.Name(), "gorm.") {
+				statements++
+			}
+		}
+		return len(result.Data.Departments), statements
+	}
+
+	createDepartments(f, 1)
+	departments, statements := run()
+	if departments != 1 {
+		t.Fatalf("expected 1 department, got %d", departments)
+	}
+	createDepartments(f, 3)
+	departments, moreStatements := run()
+	if departments != 4 {
+		t.Fatalf("expected 4 departments, got %d", departments)
+	}
+	if moreStatements != statements {
+		t.Fatalf("expected the query to run %d statements however many rows it returns, but it ran %d for 4 departments", statements, moreStatements)
+	}
+}
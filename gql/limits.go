@@ -0,0 +1,106 @@
+package gql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Limits bound how expensive a single query may be. Depth counts nested
+// selection sets; complexity counts fields, with everything below a list
+// field multiplied by ListMultiplier since it is resolved once per element.
+type Limits struct {
+	MaxDepth       int
+	MaxComplexity  int
+	ListMultiplier int
+}
+
+var DefaultLimits = Limits{MaxDepth: 6, MaxComplexity: 5000, ListMultiplier: 10}
+
+// listFields are the fields of the schema returning lists.
+var listFields = map[string]bool{
+	"students":    true,
+	"courses":     true,
+	"departments": true,
+	"instructors": true,
+	"children":    true,
+}
+
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	limits    Limits
+}
+
+// checkLimits parses the query and rejects it when the selected operation
+// exceeds the limits. Introspection fields are not counted.
+func checkLimits(query string, operationName string, limits Limits) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		// Let the executor report syntax errors in its usual format.
+		return nil
+	}
+
+	cost := queryCost{fragments: map[string]*ast.FragmentDefinition{}, limits: limits}
+	var operations []*ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			cost.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operations = append(operations, d)
+			}
+		}
+	}
+
+	for _, operation := range operations {
+		depth, complexity := cost.selectionSet(operation.SelectionSet, map[string]bool{})
+		if depth > limits.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, limits.MaxDepth)
+		}
+		if complexity > limits.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, limits.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+// selectionSet returns the depth and complexity of a selection set. visiting
+// holds the fragments being expanded, guarding against fragment cycles.
+func (c queryCost) selectionSet(set *ast.SelectionSet, visiting map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	maxDepth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var depth, cost int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if len(s.Name.Value) >= 2 && s.Name.Value[:2] == "__" {
+				continue
+			}
+			childDepth, childCost := c.selectionSet(s.SelectionSet, visiting)
+			if listFields[s.Name.Value] {
+				childCost *= c.limits.ListMultiplier
+			}
+			depth, cost = childDepth+1, childCost+1
+		case *ast.InlineFragment:
+			depth, cost = c.selectionSet(s.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			depth, cost = c.selectionSet(fragment.SelectionSet, visiting)
+			delete(visiting, name)
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		complexity += cost
+	}
+	return maxDepth, complexity
+}
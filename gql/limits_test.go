@@ -0,0 +1,48 @@
+package gql
+
+import (
+	"strings"
+	"testing"
+)
+
+var testLimits = Limits{MaxDepth: 4, MaxComplexity: 100, ListMultiplier: 10}
+
+func TestCheckLimitsAcceptsShallowQuery(t *testing.T) {
+	query := `{ department(id: 1) { name students { fullName } } }`
+	if err := checkLimits(query, "", testLimits); err != nil {
+		t.Fatalf("expected query to be accepted, got %v", err)
+	}
+}
+
+func TestCheckLimitsRejectsDeepQuery(t *testing.T) {
+	query := `{ department(id: 1) { students { courses { instructor { department { name } } } } } }`
+	err := checkLimits(query, "", testLimits)
+	if err == nil || !strings.Contains(err.Error(), "depth") {
+		t.Fatalf("expected depth error, got %v", err)
+	}
+}
+
+func TestCheckLimitsRejectsComplexQuery(t *testing.T) {
+	// 1 + 10 * (1 + 10 * (1 + 1)) = 211
+	query := `{ departments { students { courses { name } } } }`
+	err := checkLimits(query, "", testLimits)
+	if err == nil || !strings.Contains(err.Error(), "complexity") {
+		t.Fatalf("expected complexity error, got %v", err)
+	}
+}
+
+func TestCheckLimitsExpandsFragments(t *testing.T) {
+	query := `
+		query { department(id: 1) { ...deep } }
+		fragment deep on Department { students { courses { instructor { name } } } }`
+	if err := checkLimits(query, "", testLimits); err == nil {
+		t.Fatalf("expected fragment depth to count")
+	}
+}
+
+func TestCheckLimitsIgnoresIntrospection(t *testing.T) {
+	query := `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`
+	if err := checkLimits(query, "", testLimits); err != nil {
+		t.Fatalf("expected introspection to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package gql
+
+import "sync"
+
+// loader batches the lookups of one request. Resolvers call load for every
+// key they need and return the thunk; graphql-go resolves thunks breadth
+// first, so by the time the first thunk runs every sibling has registered
+// its key and a single fetch serves the whole level of the query.
+type loader[V any] struct {
+	fetch   func(keys []uint) map[uint]V
+	mu      sync.Mutex
+	pending []uint
+	results map[uint]V
+}
+
+func newLoader[V any](fetch func(keys []uint) map[uint]V) *loader[V] {
+	return &loader[V]{fetch: fetch, results: map[uint]V{}}
+}
+
+func (l *loader[V]) load(key uint) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, done := l.results[key]; !done {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+			fetched := l.fetch(keys)
+			for _, k := range keys {
+				l.results[k] = fetched[k]
+			}
+		}
+		return l.results[key], nil
+	}
+}
@@ -0,0 +1,41 @@
+package gql
+
+import "testing"
+
+func TestLoaderBatchesPendingKeys(t *testing.T) {
+	var calls [][]uint
+	l := newLoader(func(keys []uint) map[uint]string {
+		calls = append(calls, keys)
+		result := map[uint]string{}
+		for _, key := range keys {
+			if key != 3 {
+				result[key] = "value"
+			}
+		}
+		return result
+	})
+
+	thunks := []func() (interface{}, error){l.load(1), l.load(2), l.load(3)}
+	for _, thunk := range thunks {
+		thunk()
+	}
+	if len(calls) != 1 || len(calls[0]) != 3 {
+		t.Fatalf("expected one fetch of 3 keys, got %v", calls)
+	}
+
+	if v, _ := l.load(1)(); v != "value" {
+		t.Fatalf("expected cached value, got %v", v)
+	}
+	if v, _ := l.load(3)(); v != "" {
+		t.Fatalf("expected zero value for missing key, got %v", v)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected cached keys not to be fetched again, got %v", calls)
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	if _, err := NewSchema(); err != nil {
+		t.Fatalf("expected schema to build, got %v", err)
+	}
+}
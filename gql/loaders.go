@@ -0,0 +1,106 @@
+package gql
+
+import (
+	"context"
+	"exercise1/db"
+	"exercise1/service"
+)
+
+type loaders struct {
+	departments             *loader[*db.Department]
+	instructors             *loader[*db.Instructor]
+	childDepartments        *loader[[]db.Department]
+	studentsByDepartment    *loader[[]db.Student]
+	coursesByDepartment     *loader[[]db.Course]
+	instructorsByDepartment *loader[[]db.Instructor]
+	coursesByInstructor     *loader[[]db.Course]
+	coursesByStudent        *loader[[]db.Course]
+	studentsByCourse        *loader[[]db.Student]
+}
+
+type loadersKey struct{}
+
+// newLoaders creates the loaders of the request with context ctx. They cache
+// what they fetched, so they must not outlive the request.
+func newLoaders(ctx context.Context) *loaders {
+	return &loaders{
+		departments: newLoader(func(ids []uint) map[uint]*db.Department {
+			return byId(db.FindWhereIn[db.Department](ctx, "id", ids), func(d db.Department) uint { return d.Id })
+		}),
+		instructors: newLoader(func(ids []uint) map[uint]*db.Instructor {
+			return byId(db.FindWhereIn[db.Instructor](ctx, "id", ids), func(i db.Instructor) uint { return i.Id })
+		}),
+		childDepartments: newLoader(func(ids []uint) map[uint][]db.Department {
+			return groupBy(db.FindWhereIn[db.Department](ctx, "parent_id", ids), func(d db.Department) uint { return *d.ParentId })
+		}),
+		studentsByDepartment: newLoader(func(ids []uint) map[uint][]db.Student {
+			return groupBy(visibleStudents(ctx, db.FindWhereIn[db.Student](ctx, "department_id", ids)), func(s db.Student) uint { return *s.DepartmentId })
+		}),
+		coursesByDepartment: newLoader(func(ids []uint) map[uint][]db.Course {
+			return groupBy(db.FindWhereIn[db.Course](ctx, "department_id", ids), func(c db.Course) uint { return c.DepartmentId })
+		}),
+		instructorsByDepartment: newLoader(func(ids []uint) map[uint][]db.Instructor {
+			return groupBy(db.FindWhereIn[db.Instructor](ctx, "department_id", ids), func(i db.Instructor) uint { return i.DepartmentId })
+		}),
+		coursesByInstructor: newLoader(func(ids []uint) map[uint][]db.Course {
+			return groupBy(db.FindWhereIn[db.Course](ctx, "instructor_id", ids), func(c db.Course) uint { return *c.InstructorId })
+		}),
+		coursesByStudent: newLoader(func(ids []uint) map[uint][]db.Course {
+			enrollments := db.FindWhereIn[db.Enrollment](ctx, "student_id", ids)
+			courses := byId(db.FindWhereIn[db.Course](ctx, "id", enrollmentIds(enrollments, func(e db.Enrollment) uint { return e.CourseId })), func(c db.Course) uint { return c.Id })
+			result := map[uint][]db.Course{}
+			for _, enrollment := range enrollments {
+				if course, ok := courses[enrollment.CourseId]; ok {
+					result[enrollment.StudentId] = append(result[enrollment.StudentId], *course)
+				}
+			}
+			return result
+		}),
+		studentsByCourse: newLoader(func(ids []uint) map[uint][]db.Student {
+			enrollments := db.FindWhereIn[db.Enrollment](ctx, "course_id", ids)
+			students := byId(visibleStudents(ctx, db.FindWhereIn[db.Student](ctx, "id", enrollmentIds(enrollments, func(e db.Enrollment) uint { return e.StudentId }))), func(s db.Student) uint { return s.Id })
+			result := map[uint][]db.Student{}
+			for _, enrollment := range enrollments {
+				if student, ok := students[enrollment.StudentId]; ok {
+					result[enrollment.CourseId] = append(result[enrollment.CourseId], *student)
+				}
+			}
+			return result
+		}),
+	}
+}
+
+// visibleStudents drops the students the principal of ctx may not see.
+func visibleStudents(ctx context.Context, students []db.Student) []db.Student {
+	visible, _ := service.VisibleStudents(ctx, students)
+	return visible
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func byId[T any](rows []T, id func(T) uint) map[uint]*T {
+	result := make(map[uint]*T, len(rows))
+	for i := range rows {
+		result[id(rows[i])] = &rows[i]
+	}
+	return result
+}
+
+func groupBy[T any](rows []T, key func(T) uint) map[uint][]T {
+	result := map[uint][]T{}
+	for _, row := range rows {
+		k := key(row)
+		result[k] = append(result[k], row)
+	}
+	return result
+}
+
+func enrollmentIds(enrollments []db.Enrollment, id func(db.Enrollment) uint) []uint {
+	ids := make([]uint, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		ids = append(ids, id(enrollment))
+	}
+	return ids
+}
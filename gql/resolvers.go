@@ -0,0 +1,374 @@
+package gql
+
+import (
+	"exercise1/db"
+	"exercise1/service"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+)
+
+var idArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+}
+
+func intArg(p graphql.ResolveParams, name string) (int, bool) {
+	v, ok := p.Args[name].(int)
+	return v, ok
+}
+
+func uintArg(p graphql.ResolveParams, name string) uint {
+	v, _ := intArg(p, name)
+	return uint(v)
+}
+
+func uintPtrArg(p graphql.ResolveParams, name string) *uint {
+	v, ok := intArg(p, name)
+	if !ok {
+		return nil
+	}
+	u := uint(v)
+	return &u
+}
+
+func stringArg(p graphql.ResolveParams, name string) string {
+	v, _ := p.Args[name].(string)
+	return v
+}
+
+func queryType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"students": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(studentType)),
+				Args: graphql.FieldConfigArgument{
+					"departmentId": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if departmentId, ok := intArg(p, "departmentId"); ok {
+						return service.FindAllStudentsByDepartmentId(p.Context, uint(departmentId))
+					}
+					return service.FindAllStudents(p.Context)
+				},
+			},
+			"student": &graphql.Field{
+				Type: studentType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					student, err := service.FindStudentById(p.Context, int(uintArg(p, "id")))
+					if err != nil || student.Id == 0 {
+						return nil, err
+					}
+					return student, nil
+				},
+			},
+			"courses": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(courseType)),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return db.FindAllCourses(p.Context), nil
+				},
+			},
+			"course": &graphql.Field{
+				Type: courseType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if course := db.FindCourseById(p.Context, int(uintArg(p, "id"))); course.Id != 0 {
+						return course, nil
+					}
+					return nil, nil
+				},
+			},
+			"departments": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(departmentType)),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return db.FindAllDepartments(p.Context), nil
+				},
+			},
+			"department": &graphql.Field{
+				Type: departmentType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if department := db.FindDepartmentById(p.Context, int(uintArg(p, "id"))); department.Id != 0 {
+						return department, nil
+					}
+					return nil, nil
+				},
+			},
+			"instructors": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(instructorType)),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return db.FindAllInstructors(p.Context), nil
+				},
+			},
+			"instructor": &graphql.Field{
+				Type: instructorType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if instructor := db.FindInstructorById(p.Context, int(uintArg(p, "id"))); instructor.Id != 0 {
+						return instructor, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+}
+
+func versionedArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args["id"] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}
+	args["version"] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}
+	return args
+}
+
+func mutationType() *graphql.Object {
+	nonNullInt := graphql.NewNonNull(graphql.Int)
+	nonNullString := graphql.NewNonNull(graphql.String)
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createStudent": &graphql.Field{
+				Type: studentType,
+				Args: graphql.FieldConfigArgument{
+					"fullName":     &graphql.ArgumentConfig{Type: nonNullString},
+					"age":          &graphql.ArgumentConfig{Type: nonNullInt},
+					"city":         &graphql.ArgumentConfig{Type: graphql.String},
+					"departmentId": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return service.CreateStudent(p.Context, db.Student{
+						FullName:     stringArg(p, "fullName"),
+						Age:          uintArg(p, "age"),
+						City:         stringArg(p, "city"),
+						DepartmentId: uintPtrArg(p, "departmentId"),
+					})
+				},
+			},
+			"updateStudentAge": &graphql.Field{
+				Type: studentType,
+				Args: versionedArgs(graphql.FieldConfigArgument{
+					"age": &graphql.ArgumentConfig{Type: nonNullInt},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					student, err := findStudent(p)
+					if err != nil {
+						return nil, err
+					}
+					student.Version = uintArg(p, "version")
+					if err := service.UpdateStudentAge(p.Context, student, int(uintArg(p, "age"))); err != nil {
+						return nil, err
+					}
+					return db.FindStudentById(p.Context, int(student.Id)), nil
+				},
+			},
+			"deleteStudent": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					student, err := findStudent(p)
+					if err != nil {
+						return false, err
+					}
+					if err := service.DeleteStudent(p.Context, student); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+
+			"createCourse": &graphql.Field{
+				Type: courseType,
+				Args: graphql.FieldConfigArgument{
+					"name":         &graphql.ArgumentConfig{Type: nonNullString},
+					"departmentId": &graphql.ArgumentConfig{Type: nonNullInt},
+					"instructorId": &graphql.ArgumentConfig{Type: graphql.Int},
+					"credits":      &graphql.ArgumentConfig{Type: graphql.Int},
+					"term":         &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return service.CreateCourse(p.Context, db.Course{
+						Name:         stringArg(p, "name"),
+						DepartmentId: uintArg(p, "departmentId"),
+						InstructorId: uintPtrArg(p, "instructorId"),
+						Credits:      uintArg(p, "credits"),
+						Term:         stringArg(p, "term"),
+					})
+				},
+			},
+			"updateCourse": &graphql.Field{
+				Type: courseType,
+				Args: versionedArgs(graphql.FieldConfigArgument{
+					"name":         &graphql.ArgumentConfig{Type: graphql.String},
+					"departmentId": &graphql.ArgumentConfig{Type: graphql.Int},
+					"instructorId": &graphql.ArgumentConfig{Type: graphql.Int},
+					"credits":      &graphql.ArgumentConfig{Type: graphql.Int},
+					"term":         &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					course := db.FindCourseById(p.Context, int(uintArg(p, "id")))
+					if course.Id == 0 {
+						return nil, fmt.Errorf("course %d not found", uintArg(p, "id"))
+					}
+					course.Version = uintArg(p, "version")
+					err := service.UpdateCourse(p.Context, course, db.Course{
+						Name:         stringArg(p, "name"),
+						DepartmentId: uintArg(p, "departmentId"),
+						InstructorId: uintPtrArg(p, "instructorId"),
+						Credits:      uintArg(p, "credits"),
+						Term:         stringArg(p, "term"),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return db.FindCourseById(p.Context, int(course.Id)), nil
+				},
+			},
+			"deleteCourse": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					course := db.FindCourseById(p.Context, int(uintArg(p, "id")))
+					if course.Id == 0 {
+						return false, fmt.Errorf("course %d not found", uintArg(p, "id"))
+					}
+					if err := service.DeleteCourse(p.Context, course); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+
+			"createDepartment": &graphql.Field{
+				Type: departmentType,
+				Args: graphql.FieldConfigArgument{
+					"name":     &graphql.ArgumentConfig{Type: nonNullString},
+					"kind":     &graphql.ArgumentConfig{Type: graphql.String},
+					"parentId": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return service.CreateDepartment(p.Context, db.Department{
+						Name:     stringArg(p, "name"),
+						Kind:     stringArg(p, "kind"),
+						ParentId: uintPtrArg(p, "parentId"),
+					})
+				},
+			},
+			"updateDepartment": &graphql.Field{
+				Type: departmentType,
+				Args: versionedArgs(graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.String},
+					"kind": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					department := db.FindDepartmentById(p.Context, int(uintArg(p, "id")))
+					if department.Id == 0 {
+						return nil, fmt.Errorf("department %d not found", uintArg(p, "id"))
+					}
+					department.Version = uintArg(p, "version")
+					err := service.UpdateDepartment(p.Context, department, db.Department{
+						Name: stringArg(p, "name"),
+						Kind: stringArg(p, "kind"),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return db.FindDepartmentById(p.Context, int(department.Id)), nil
+				},
+			},
+			"deleteDepartment": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					department := db.FindDepartmentById(p.Context, int(uintArg(p, "id")))
+					if department.Id == 0 {
+						return false, fmt.Errorf("department %d not found", uintArg(p, "id"))
+					}
+					if err := service.DeleteDepartment(p.Context, department); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+
+			"createInstructor": &graphql.Field{
+				Type: instructorType,
+				Args: graphql.FieldConfigArgument{
+					"fullName":     &graphql.ArgumentConfig{Type: nonNullString},
+					"age":          &graphql.ArgumentConfig{Type: nonNullInt},
+					"departmentId": &graphql.ArgumentConfig{Type: nonNullInt},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return service.CreateInstructor(p.Context, db.Instructor{
+						FullName:     stringArg(p, "fullName"),
+						Age:          uintArg(p, "age"),
+						DepartmentId: uintArg(p, "departmentId"),
+					})
+				},
+			},
+			"updateInstructor": &graphql.Field{
+				Type: instructorType,
+				Args: versionedArgs(graphql.FieldConfigArgument{
+					"fullName":     &graphql.ArgumentConfig{Type: graphql.String},
+					"age":          &graphql.ArgumentConfig{Type: graphql.Int},
+					"departmentId": &graphql.ArgumentConfig{Type: graphql.Int},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					instructor := db.FindInstructorById(p.Context, int(uintArg(p, "id")))
+					if instructor.Id == 0 {
+						return nil, fmt.Errorf("instructor %d not found", uintArg(p, "id"))
+					}
+					instructor.Version = uintArg(p, "version")
+					err := service.UpdateInstructor(p.Context, instructor, db.Instructor{
+						FullName:     stringArg(p, "fullName"),
+						Age:          uintArg(p, "age"),
+						DepartmentId: uintArg(p, "departmentId"),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return db.FindInstructorById(p.Context, int(instructor.Id)), nil
+				},
+			},
+			"deleteInstructor": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					instructor := db.FindInstructorById(p.Context, int(uintArg(p, "id")))
+					if instructor.Id == 0 {
+						return false, fmt.Errorf("instructor %d not found", uintArg(p, "id"))
+					}
+					if err := service.DeleteInstructor(p.Context, instructor); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+
+			"enrollStudent": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"studentId": &graphql.ArgumentConfig{Type: nonNullInt},
+					"courseId":  &graphql.ArgumentConfig{Type: nonNullInt},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := service.EnrollStudentForCourse(p.Context, uintArg(p, "studentId"), uintArg(p, "courseId")); err != nil {
+						return false, err
+					}
+					return true, nil
+				},
+			},
+		},
+	})
+}
+
+func findStudent(p graphql.ResolveParams) (db.Student, error) {
+	student, err := service.FindStudentById(p.Context, int(uintArg(p, "id")))
+	if err != nil {
+		return student, err
+	}
+	if student.Id == 0 {
+		return student, fmt.Errorf("student %d not found", uintArg(p, "id"))
+	}
+	return student, nil
+}
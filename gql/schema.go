@@ -0,0 +1,171 @@
+// Package gql serves the university data model over GraphQL. Relationship
+// fields are resolved through per-request loaders, so a nested query costs one
+// database query per level instead of one per object.
+package gql
+
+import (
+	"exercise1/db"
+
+	"github.com/graphql-go/graphql"
+)
+
+// source returns the object a field is resolved on, whether the parent
+// resolver produced a value or a pointer.
+func source[T any](p graphql.ResolveParams) T {
+	switch s := p.Source.(type) {
+	case *T:
+		return *s
+	case T:
+		return s
+	}
+	var zero T
+	return zero
+}
+
+var departmentType, studentType, courseType, instructorType *graphql.Object
+
+func init() {
+	departmentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Department",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"name":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"kind":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"maxTeachingLoad": &graphql.Field{Type: graphql.Int},
+				"version":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"parent": &graphql.Field{
+					Type: departmentType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						department := source[db.Department](p)
+						if department.ParentId == nil {
+							return nil, nil
+						}
+						return loadersFrom(p.Context).departments.load(*department.ParentId), nil
+					},
+				},
+				"children": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(departmentType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).childDepartments.load(source[db.Department](p).Id), nil
+					},
+				},
+				"students": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(studentType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).studentsByDepartment.load(source[db.Department](p).Id), nil
+					},
+				},
+				"courses": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(courseType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).coursesByDepartment.load(source[db.Department](p).Id), nil
+					},
+				},
+				"instructors": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(instructorType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).instructorsByDepartment.load(source[db.Department](p).Id), nil
+					},
+				},
+			}
+		}),
+	})
+
+	studentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Student",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"fullName":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"age":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"city":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"createdAt": &graphql.Field{Type: graphql.DateTime},
+				"version":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"department": &graphql.Field{
+					Type: departmentType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						student := source[db.Student](p)
+						if student.DepartmentId == nil {
+							return nil, nil
+						}
+						return loadersFrom(p.Context).departments.load(*student.DepartmentId), nil
+					},
+				},
+				"courses": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(courseType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).coursesByStudent.load(source[db.Student](p).Id), nil
+					},
+				},
+			}
+		}),
+	})
+
+	courseType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Course",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"name":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"credits": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"term":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"version": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"department": &graphql.Field{
+					Type: departmentType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).departments.load(source[db.Course](p).DepartmentId), nil
+					},
+				},
+				"instructor": &graphql.Field{
+					Type: instructorType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						course := source[db.Course](p)
+						if course.InstructorId == nil {
+							return nil, nil
+						}
+						return loadersFrom(p.Context).instructors.load(*course.InstructorId), nil
+					},
+				},
+				"students": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(studentType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).studentsByCourse.load(source[db.Course](p).Id), nil
+					},
+				},
+			}
+		}),
+	})
+
+	instructorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Instructor",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"fullName":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"age":             &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"maxTeachingLoad": &graphql.Field{Type: graphql.Int},
+				"version":         &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"department": &graphql.Field{
+					Type: departmentType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).departments.load(source[db.Instructor](p).DepartmentId), nil
+					},
+				},
+				"courses": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(courseType)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadersFrom(p.Context).coursesByInstructor.load(source[db.Instructor](p).Id), nil
+					},
+				},
+			}
+		}),
+	})
+}
+
+func NewSchema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType(),
+		Mutation: mutationType(),
+	})
+}
@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"context"
+	"exercise1/auth"
+	"exercise1/db"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate returns ctx with the claims of the access token in the
+// "authorization" metadata of the call, the gRPC counterpart of the
+// Authorization header of the HTTP API.
+func authenticate(ctx context.Context, authService *auth.Service) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	claims, err := authService.Authenticate(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, auth.ErrInvalidToken.Error())
+	}
+	return auth.WithClaims(ctx, claims), nil
+}
+
+func unaryAuthenticator(authService *auth.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, authService)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthenticator(authService *auth.Service) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), authService)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// unaryAllDepartments runs every call in a transaction that sees the rows of
+// every department, see db.AllDepartments, and rolls it back when the call
+// fails. The service package limits department admins to theirs again.
+func unaryAllDepartments(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	txErr := db.AllDepartments(ctx, func(ctx context.Context) error {
+		resp, err = handler(ctx, req)
+		return err
+	})
+	if err == nil && txErr != nil {
+		return nil, toStatus(ctx, txErr)
+	}
+	return resp, err
+}
+
+func streamAllDepartments(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	var err error
+	txErr := db.AllDepartments(stream.Context(), func(ctx context.Context) error {
+		err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		return err
+	})
+	if err == nil && txErr != nil {
+		return toStatus(stream.Context(), txErr)
+	}
+	return err
+}
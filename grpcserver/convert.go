@@ -0,0 +1,123 @@
+package grpcserver
+
+import (
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toUintPtr(v *uint64) *uint {
+	if v == nil {
+		return nil
+	}
+	u := uint(*v)
+	return &u
+}
+
+func fromUintPtr(v *uint) *uint64 {
+	if v == nil {
+		return nil
+	}
+	u := uint64(*v)
+	return &u
+}
+
+func toUintPtr32(v *uint32) *uint {
+	if v == nil {
+		return nil
+	}
+	u := uint(*v)
+	return &u
+}
+
+func fromUintPtr32(v *uint) *uint32 {
+	if v == nil {
+		return nil
+	}
+	u := uint32(*v)
+	return &u
+}
+
+func studentToProto(student db.Student) *pb.Student {
+	return &pb.Student{
+		Id:           uint64(student.Id),
+		FullName:     student.FullName,
+		Age:          uint32(student.Age),
+		City:         student.City,
+		DepartmentId: fromUintPtr(student.DepartmentId),
+		CreatedAt:    timestamppb.New(student.CreatedAt),
+		Version:      uint64(student.Version),
+	}
+}
+
+func studentFromProto(student *pb.Student) db.Student {
+	return db.Student{
+		FullName:     student.FullName,
+		Age:          uint(student.Age),
+		City:         student.City,
+		DepartmentId: toUintPtr(student.DepartmentId),
+	}
+}
+
+func courseToProto(course db.Course) *pb.Course {
+	return &pb.Course{
+		Id:           uint64(course.Id),
+		Name:         course.Name,
+		DepartmentId: uint64(course.DepartmentId),
+		InstructorId: fromUintPtr(course.InstructorId),
+		Credits:      uint32(course.Credits),
+		Term:         course.Term,
+		Version:      uint64(course.Version),
+	}
+}
+
+func courseFromProto(course *pb.Course) db.Course {
+	return db.Course{
+		Name:         course.Name,
+		DepartmentId: uint(course.DepartmentId),
+		InstructorId: toUintPtr(course.InstructorId),
+		Credits:      uint(course.Credits),
+		Term:         course.Term,
+	}
+}
+
+func departmentToProto(department db.Department) *pb.Department {
+	return &pb.Department{
+		Id:              uint64(department.Id),
+		Name:            department.Name,
+		Kind:            department.Kind,
+		ParentId:        fromUintPtr(department.ParentId),
+		MaxTeachingLoad: fromUintPtr32(department.MaxTeachingLoad),
+		Version:         uint64(department.Version),
+	}
+}
+
+func departmentFromProto(department *pb.Department) db.Department {
+	return db.Department{
+		Name:            department.Name,
+		Kind:            department.Kind,
+		ParentId:        toUintPtr(department.ParentId),
+		MaxTeachingLoad: toUintPtr32(department.MaxTeachingLoad),
+	}
+}
+
+func instructorToProto(instructor db.Instructor) *pb.Instructor {
+	return &pb.Instructor{
+		Id:              uint64(instructor.Id),
+		FullName:        instructor.FullName,
+		Age:             uint32(instructor.Age),
+		DepartmentId:    uint64(instructor.DepartmentId),
+		MaxTeachingLoad: fromUintPtr32(instructor.MaxTeachingLoad),
+		Version:         uint64(instructor.Version),
+	}
+}
+
+func instructorFromProto(instructor *pb.Instructor) db.Instructor {
+	return db.Instructor{
+		FullName:        instructor.FullName,
+		Age:             uint(instructor.Age),
+		DepartmentId:    uint(instructor.DepartmentId),
+		MaxTeachingLoad: toUintPtr32(instructor.MaxTeachingLoad),
+	}
+}
@@ -0,0 +1,96 @@
+package grpcserver
+
+import (
+	"context"
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+	"exercise1/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *server) CreateCourse(ctx context.Context, req *pb.Course) (*pb.Course, error) {
+	course, err := service.CreateCourse(ctx, courseFromProto(req))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return courseToProto(course), nil
+}
+
+func (s *server) GetCourse(ctx context.Context, req *pb.IdRequest) (*pb.Course, error) {
+	course := db.FindCourseById(ctx, int(req.Id))
+	if course.Id == 0 {
+		return nil, notFound("course", req.Id)
+	}
+	return courseToProto(course), nil
+}
+
+func (s *server) ListCourses(req *pb.ListCoursesRequest, stream grpc.ServerStreamingServer[pb.Course]) error {
+	ctx := stream.Context()
+	var conds []interface{}
+	switch {
+	case req.WithoutInstructor:
+		conds = []interface{}{"instructor_id IS NULL"}
+	case req.InstructorId != nil:
+		conds = []interface{}{"instructor_id = ?", *req.InstructorId}
+	}
+
+	err := db.FindInBatches(ctx, listBatchSize, func(courses []db.Course) error {
+		for _, course := range courses {
+			if err := stream.Send(courseToProto(course)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, conds...)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+func (s *server) UpdateCourse(ctx context.Context, req *pb.UpdateCourseRequest) (*pb.Course, error) {
+	course := db.FindCourseById(ctx, int(req.Id))
+	if course.Id == 0 {
+		return nil, notFound("course", req.Id)
+	}
+	course.Version = uint(req.Version)
+
+	courseWithUpdatedFields := db.Course{
+		Name:         req.GetName(),
+		DepartmentId: uint(req.GetDepartmentId()),
+		InstructorId: toUintPtr(req.InstructorId),
+		Credits:      uint(req.GetCredits()),
+		Term:         req.GetTerm(),
+	}
+	if err := service.UpdateCourse(ctx, course, courseWithUpdatedFields); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return courseToProto(db.FindCourseById(ctx, int(req.Id))), nil
+}
+
+func (s *server) DeleteCourse(ctx context.Context, req *pb.IdRequest) (*emptypb.Empty, error) {
+	course := db.FindCourseById(ctx, int(req.Id))
+	if course.Id == 0 {
+		return nil, notFound("course", req.Id)
+	}
+	if err := service.DeleteCourse(ctx, course); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) AssignInstructor(ctx context.Context, req *pb.AssignInstructorRequest) (*pb.Course, error) {
+	if err := service.AssignInstructor(ctx, uint(req.CourseId), uint(req.InstructorId)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return courseToProto(db.FindCourseById(ctx, int(req.CourseId))), nil
+}
+
+func (s *server) UnassignInstructor(ctx context.Context, req *pb.IdRequest) (*pb.Course, error) {
+	if err := service.UnassignInstructor(ctx, uint(req.Id)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return courseToProto(db.FindCourseById(ctx, int(req.Id))), nil
+}
@@ -0,0 +1,90 @@
+package grpcserver
+
+import (
+	"context"
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+	"exercise1/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *server) CreateDepartment(ctx context.Context, req *pb.Department) (*pb.Department, error) {
+	department, err := service.CreateDepartment(ctx, departmentFromProto(req))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return departmentToProto(department), nil
+}
+
+func (s *server) GetDepartment(ctx context.Context, req *pb.IdRequest) (*pb.Department, error) {
+	department := db.FindDepartmentById(ctx, int(req.Id))
+	if department.Id == 0 {
+		return nil, notFound("department", req.Id)
+	}
+	return departmentToProto(department), nil
+}
+
+func (s *server) ListDepartments(req *emptypb.Empty, stream grpc.ServerStreamingServer[pb.Department]) error {
+	ctx := stream.Context()
+	err := db.FindInBatches(ctx, listBatchSize, func(departments []db.Department) error {
+		for _, department := range departments {
+			if err := stream.Send(departmentToProto(department)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+func (s *server) UpdateDepartment(ctx context.Context, req *pb.UpdateDepartmentRequest) (*pb.Department, error) {
+	department := db.FindDepartmentById(ctx, int(req.Id))
+	if department.Id == 0 {
+		return nil, notFound("department", req.Id)
+	}
+	department.Version = uint(req.Version)
+
+	departmentWithUpdatedFields := db.Department{
+		Name:            req.GetName(),
+		Kind:            req.GetKind(),
+		MaxTeachingLoad: toUintPtr32(req.MaxTeachingLoad),
+	}
+	if err := service.UpdateDepartment(ctx, department, departmentWithUpdatedFields); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return departmentToProto(db.FindDepartmentById(ctx, int(req.Id))), nil
+}
+
+func (s *server) DeleteDepartment(ctx context.Context, req *pb.IdRequest) (*emptypb.Empty, error) {
+	department := db.FindDepartmentById(ctx, int(req.Id))
+	if department.Id == 0 {
+		return nil, notFound("department", req.Id)
+	}
+	if err := service.DeleteDepartment(ctx, department); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) GetStudentCountForEachDepartment(req *emptypb.Empty, stream grpc.ServerStreamingServer[pb.DepartmentStudentCount]) error {
+	ctx := stream.Context()
+	if err := service.AuthorizeReports(ctx); err != nil {
+		return toStatus(ctx, err)
+	}
+	for _, department := range db.GetStudentCountForEachDepartment(ctx) {
+		err := stream.Send(&pb.DepartmentStudentCount{
+			Id:           uint64(department.Id),
+			Name:         department.Name,
+			StudentCount: uint64(department.StudentCount),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package grpcserver
+
+import (
+	"context"
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+	"exercise1/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *server) EnrollStudent(ctx context.Context, req *pb.Enrollment) (*emptypb.Empty, error) {
+	if err := service.EnrollStudentForCourse(ctx, uint(req.StudentId), uint(req.CourseId)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListStudentCourses(req *pb.IdRequest, stream grpc.ServerStreamingServer[pb.Course]) error {
+	ctx := stream.Context()
+	student := db.FindStudentById(ctx, int(req.Id))
+	if student.Id == 0 {
+		return notFound("student", req.Id)
+	}
+	courses, err := service.GetStudentEnrolledCoursesByStudentId(ctx, student)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	for _, course := range courses {
+		if err := stream.Send(courseToProto(course)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) ListCourseStudents(req *pb.IdRequest, stream grpc.ServerStreamingServer[pb.Student]) error {
+	ctx := stream.Context()
+	if course := db.FindCourseById(ctx, int(req.Id)); course.Id == 0 {
+		return notFound("course", req.Id)
+	}
+	students, err := service.GetCourseEnrolledStudentsByCourseId(ctx, uint(req.Id))
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	for _, student := range students {
+		if err := stream.Send(studentToProto(student)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package grpcserver
+
+import (
+	"context"
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+	"exercise1/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *server) CreateInstructor(ctx context.Context, req *pb.Instructor) (*pb.Instructor, error) {
+	instructor, err := service.CreateInstructor(ctx, instructorFromProto(req))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return instructorToProto(instructor), nil
+}
+
+func (s *server) GetInstructor(ctx context.Context, req *pb.IdRequest) (*pb.Instructor, error) {
+	instructor := db.FindInstructorById(ctx, int(req.Id))
+	if instructor.Id == 0 {
+		return nil, notFound("instructor", req.Id)
+	}
+	return instructorToProto(instructor), nil
+}
+
+func (s *server) ListInstructors(req *emptypb.Empty, stream grpc.ServerStreamingServer[pb.Instructor]) error {
+	ctx := stream.Context()
+	err := db.FindInBatches(ctx, listBatchSize, func(instructors []db.Instructor) error {
+		for _, instructor := range instructors {
+			if err := stream.Send(instructorToProto(instructor)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+func (s *server) UpdateInstructor(ctx context.Context, req *pb.UpdateInstructorRequest) (*pb.Instructor, error) {
+	instructor := db.FindInstructorById(ctx, int(req.Id))
+	if instructor.Id == 0 {
+		return nil, notFound("instructor", req.Id)
+	}
+	instructor.Version = uint(req.Version)
+
+	instructorWithUpdatedFields := db.Instructor{
+		FullName:        req.GetFullName(),
+		Age:             uint(req.GetAge()),
+		DepartmentId:    uint(req.GetDepartmentId()),
+		MaxTeachingLoad: toUintPtr32(req.MaxTeachingLoad),
+	}
+	if err := service.UpdateInstructor(ctx, instructor, instructorWithUpdatedFields); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return instructorToProto(db.FindInstructorById(ctx, int(req.Id))), nil
+}
+
+func (s *server) DeleteInstructor(ctx context.Context, req *pb.IdRequest) (*emptypb.Empty, error) {
+	instructor := db.FindInstructorById(ctx, int(req.Id))
+	if instructor.Id == 0 {
+		return nil, notFound("instructor", req.Id)
+	}
+	if err := service.DeleteInstructor(ctx, instructor); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListStudentsOfInstructor(req *pb.ListStudentsOfInstructorRequest, stream grpc.ServerStreamingServer[pb.Student]) error {
+	ctx := stream.Context()
+	students, err := service.GetStudentsOfInstructor(ctx, uint(req.InstructorId), req.Roles...)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	for _, student := range students {
+		if err := stream.Send(studentToProto(student)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package grpcserver exposes the db package over gRPC as the
+// university.v1.University service.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"exercise1/access"
+	"exercise1/auth"
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+	"exercise1/validation"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// listBatchSize is how many rows the streaming list calls load per query.
+const listBatchSize = 500
+
+type server struct {
+	pb.UnimplementedUniversityServer
+}
+
+// NewServer returns the gRPC server. Every call requires an access token
+// issued by authService.
+func NewServer(authService *auth.Service, opts ...grpc.ServerOption) *grpc.Server {
+	// Every call is traced, continuing the trace of the caller when its
+	// metadata carries one, and logged with its request ID, including those
+	// that fail authentication.
+	opts = append([]grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryLogger, unaryAuthenticator(authService), unaryAllDepartments),
+		grpc.ChainStreamInterceptor(streamLogger, streamAuthenticator(authService), streamAllDepartments),
+	}, opts...)
+	s := grpc.NewServer(opts...)
+	pb.RegisterUniversityServer(s, &server{})
+	return s
+}
+
+// toStatus maps errors of the db package to gRPC status codes. Validation
+// errors carry a BadRequest detail listing every rejected field. Other errors
+// are logged and answered with a generic message, as they may tell clients
+// about the database and its contents.
+func toStatus(ctx context.Context, err error) error {
+	var invalid validation.Errors
+	if errors.As(err, &invalid) {
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldError := range invalid {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldError.Field,
+				Description: fieldError.Message,
+			})
+		}
+		st, detailErr := status.New(codes.InvalidArgument, invalid.Error()).WithDetails(badRequest)
+		if detailErr != nil {
+			return status.Error(codes.InvalidArgument, invalid.Error())
+		}
+		return st.Err()
+	}
+
+	if errors.Is(err, access.ErrForbidden) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	var conflict *db.ConflictError
+	if errors.As(err, &conflict) {
+		return status.Error(codes.Aborted, conflict.Error())
+	}
+	var load *db.TeachingLoadError
+	if errors.As(err, &load) {
+		return status.Error(codes.FailedPrecondition, load.Error())
+	}
+	if errors.Is(err, db.ErrDepartmentCycle) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	logger.ErrorContext(ctx, "call failed", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func notFound(entity string, id uint64) error {
+	return status.Errorf(codes.NotFound, "%s %d not found", entity, id)
+}
@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"exercise1/access"
+	"exercise1/db"
+	"exercise1/db/dbtest"
+	pb "exercise1/pb/universitypb"
+	"exercise1/validation"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func TestToStatusCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{gorm.ErrRecordNotFound, codes.NotFound},
+		{fmt.Errorf("enroll: %w", gorm.ErrRecordNotFound), codes.NotFound},
+		{&db.ConflictError{Entity: "course", Id: 1, ExpectedVersion: 1, ActualVersion: 2}, codes.Aborted},
+		{&db.TeachingLoadError{InstructorId: 1, Load: 30, Max: 20}, codes.FailedPrecondition},
+		{db.ErrDepartmentCycle, codes.FailedPrecondition},
+		{access.ErrForbidden, codes.PermissionDenied},
+		{errors.New("connection reset"), codes.Internal},
+	}
+	for _, test := range tests {
+		if actual := status.Code(toStatus(context.Background(), test.err)); actual != test.code {
+			t.Errorf("toStatus(%v) expected code %s, but got %s", test.err, test.code, actual)
+		}
+	}
+}
+
+func TestToStatusValidationDetails(t *testing.T) {
+	err := toStatus(context.Background(), validation.Errors{
+		{Field: "FullName", Message: "is required"},
+		{Field: "Age", Message: "must be between 16 and 100"},
+	})
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %s", st.Code())
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("expected one BadRequest detail, got %v", st.Details())
+	}
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	if !ok || len(badRequest.FieldViolations) != 2 {
+		t.Fatalf("expected 2 field violations, got %v", st.Details()[0])
+	}
+}
+
+func TestDeleteReportsFailure(t *testing.T) {
+	f := dbtest.New(t)
+	student := f.Student()
+
+	// The trigger goes with the transaction of the test.
+	for _, statement := range []string{
+		"CREATE FUNCTION pg_temp.refuse_delete() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN RAISE EXCEPTION 'deletes are refused'; END $$",
+		"CREATE TRIGGER refuse_delete BEFORE DELETE ON students FOR EACH ROW EXECUTE FUNCTION pg_temp.refuse_delete()",
+	} {
+		if err := db.Conn(f.Ctx).Exec(statement).Error; err != nil {
+			t.Fatalf("Creating the trigger failed: %v", err)
+		}
+	}
+
+	ctx := access.WithPrincipal(f.Ctx, access.Principal{Role: access.Registrar})
+	_, err := (&server{}).DeleteStudent(ctx, &pb.IdRequest{Id: uint64(student.Id)})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected a failed delete to return Internal, got %v", err)
+	}
+	if message := status.Convert(err).Message(); strings.Contains(message, "refused") {
+		t.Fatalf("expected the database error to be hidden from the client, got %q", message)
+	}
+}
@@ -0,0 +1,96 @@
+package grpcserver
+
+import (
+	"context"
+	"exercise1/db"
+	pb "exercise1/pb/universitypb"
+	"exercise1/service"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *server) CreateStudent(ctx context.Context, req *pb.Student) (*pb.Student, error) {
+	student, err := service.CreateStudent(ctx, studentFromProto(req))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return studentToProto(student), nil
+}
+
+func (s *server) GetStudent(ctx context.Context, req *pb.IdRequest) (*pb.Student, error) {
+	student, err := service.FindStudentById(ctx, int(req.Id))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if student.Id == 0 {
+		return nil, notFound("student", req.Id)
+	}
+	return studentToProto(student), nil
+}
+
+func (s *server) ListStudents(req *pb.ListStudentsRequest, stream grpc.ServerStreamingServer[pb.Student]) error {
+	ctx := stream.Context()
+	var conditions []string
+	var args []interface{}
+	if req.DepartmentId != nil {
+		conditions = append(conditions, "department_id = ?")
+		args = append(args, *req.DepartmentId)
+	}
+	if req.Age != nil {
+		conditions = append(conditions, "age = ?")
+		args = append(args, *req.Age)
+	}
+
+	var conds []interface{}
+	if len(conditions) > 0 {
+		conds = append([]interface{}{strings.Join(conditions, " AND ")}, args...)
+	}
+
+	err := db.FindInBatches(ctx, listBatchSize, func(students []db.Student) error {
+		students, err := service.VisibleStudents(ctx, students)
+		if err != nil {
+			return err
+		}
+		for _, student := range students {
+			if err := stream.Send(studentToProto(student)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, conds...)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+func (s *server) UpdateStudentAge(ctx context.Context, req *pb.UpdateStudentAgeRequest) (*pb.Student, error) {
+	student, err := service.FindStudentById(ctx, int(req.Id))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if student.Id == 0 {
+		return nil, notFound("student", req.Id)
+	}
+	student.Version = uint(req.Version)
+	if err := service.UpdateStudentAge(ctx, student, int(req.Age)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return studentToProto(db.FindStudentById(ctx, int(req.Id))), nil
+}
+
+func (s *server) DeleteStudent(ctx context.Context, req *pb.IdRequest) (*emptypb.Empty, error) {
+	student, err := service.FindStudentById(ctx, int(req.Id))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	if student.Id == 0 {
+		return nil, notFound("student", req.Id)
+	}
+	if err := service.DeleteStudent(ctx, student); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
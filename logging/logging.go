@@ -0,0 +1,124 @@
+// Package logging provides the structured loggers of the project. Every
+// package logs through a logger of its component (db, api, grpc, serve, ...)
+// whose level can be set on its own, and every record logged with the
+// context of a request carries that request's ID.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"exercise1/config"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// output is what Setup installed: the handler records are written with and
+// the levels they are filtered by.
+type output struct {
+	handler slog.Handler
+	level   slog.Level
+	levels  map[string]slog.Level
+}
+
+func (o *output) levelOf(component string) slog.Level {
+	if level, ok := o.levels[component]; ok {
+		return level
+	}
+	return o.level
+}
+
+var current atomic.Pointer[output]
+
+func init() {
+	current.Store(&output{handler: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}), level: slog.LevelInfo})
+}
+
+// Setup makes all loggers, including those created before, write to w as
+// configured, and installs the logger of component "app" as the slog
+// default.
+func Setup(cfg config.Logging, w io.Writer) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return err
+	}
+	levels, err := cfg.ComponentLevels()
+	if err != nil {
+		return err
+	}
+
+	// Filtering happens per component in Enabled, so the handler itself
+	// lets everything through.
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler = slog.NewTextHandler(w, options)
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	current.Store(&output{handler: handler, level: level, levels: levels})
+	slog.SetDefault(For("app"))
+	return nil
+}
+
+// For returns the logger of component. It can be created before Setup
+// runs, e.g. in a package variable.
+func For(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+// componentHandler forwards records to the handler installed by Setup,
+// adding the component and the request ID.
+type componentHandler struct {
+	component string
+	// wrap replays the WithAttrs and WithGroup calls on the installed
+	// handler, which can change after they were made.
+	wrap []func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= current.Load().levelOf(h.component)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	handler := current.Load().handler.WithAttrs([]slog.Attr{slog.String("component", h.component)})
+	if id := RequestID(ctx); id != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("request_id", id)})
+	}
+	for _, wrap := range h.wrap {
+		handler = wrap(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *componentHandler) with(wrap func(slog.Handler) slog.Handler) *componentHandler {
+	return &componentHandler{component: h.component, wrap: append(h.wrap[:len(h.wrap):len(h.wrap)], wrap)}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "" outside of requests.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random ID for a request that did not bring one.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
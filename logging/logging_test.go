@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"exercise1/config"
+	"strings"
+	"testing"
+)
+
+func TestComponentLevels(t *testing.T) {
+	var out bytes.Buffer
+	if err := Setup(config.Logging{Level: "warn", Levels: "db=debug", Format: "text"}, &out); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	db, api := For("db"), For("api")
+	db.Debug("db detail")
+	api.Info("api detail")
+	api.Warn("api warning")
+
+	logged := out.String()
+	if !strings.Contains(logged, "db detail") {
+		t.Errorf("expected debug message of db to be logged, got %s", logged)
+	}
+	if strings.Contains(logged, "api detail") {
+		t.Errorf("expected info message of api to be dropped, got %s", logged)
+	}
+	if !strings.Contains(logged, "api warning") {
+		t.Errorf("expected warning of api to be logged, got %s", logged)
+	}
+}
+
+func TestRecordsCarryComponentAndRequestID(t *testing.T) {
+	// Created before Setup, like the package variables of other packages.
+	logger := For("api").With("route", "/students")
+
+	var out bytes.Buffer
+	if err := Setup(config.Logging{Level: "info", Format: "json"}, &out); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	logger.InfoContext(WithRequestID(context.Background(), "abc123"), "request")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %s", out.String())
+	}
+	for key, expected := range map[string]string{"component": "api", "request_id": "abc123", "route": "/students", "msg": "request"} {
+		if record[key] != expected {
+			t.Errorf("expected %s to be %q, got %v", key, expected, record[key])
+		}
+	}
+}
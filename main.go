@@ -1,28 +1,17 @@
 package main
 
 import (
-	"exercise1/db"
+	"exercise1/cli"
+	"exercise1/config"
 	"fmt"
-	"log"
 	"os"
-
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load()
+	cfg, args, err := config.Load(os.Args[1:], "")
 	if err != nil {
-		log.Fatalf("There's no .env file in directory! %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	port := os.Getenv("DB_PORT")
-	sslmode := os.Getenv("SSL_MODE")
-	timeZone := os.Getenv("TIME_ZONE")
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s timeZone=%s", host, user, password, dbname, port, sslmode, timeZone)
-
-	db.Connect(dsn)
+	os.Exit(cli.Run(cfg, args, os.Stdout, os.Stderr))
 }
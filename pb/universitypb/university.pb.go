@@ -0,0 +1,1324 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: university/v1/university.proto
+
+package universitypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Student struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	FullName      string                 `protobuf:"bytes,2,opt,name=full_name,json=fullName,proto3" json:"full_name,omitempty"`
+	Age           uint32                 `protobuf:"varint,3,opt,name=age,proto3" json:"age,omitempty"`
+	City          string                 `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	DepartmentId  *uint64                `protobuf:"varint,5,opt,name=department_id,json=departmentId,proto3,oneof" json:"department_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Version       uint64                 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Student) Reset() {
+	*x = Student{}
+	mi := &file_university_v1_university_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Student) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Student) ProtoMessage() {}
+
+func (x *Student) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Student.ProtoReflect.Descriptor instead.
+func (*Student) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Student) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Student) GetFullName() string {
+	if x != nil {
+		return x.FullName
+	}
+	return ""
+}
+
+func (x *Student) GetAge() uint32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+func (x *Student) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Student) GetDepartmentId() uint64 {
+	if x != nil && x.DepartmentId != nil {
+		return *x.DepartmentId
+	}
+	return 0
+}
+
+func (x *Student) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Student) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Course struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	DepartmentId  uint64                 `protobuf:"varint,3,opt,name=department_id,json=departmentId,proto3" json:"department_id,omitempty"`
+	InstructorId  *uint64                `protobuf:"varint,4,opt,name=instructor_id,json=instructorId,proto3,oneof" json:"instructor_id,omitempty"`
+	Credits       uint32                 `protobuf:"varint,5,opt,name=credits,proto3" json:"credits,omitempty"`
+	Term          string                 `protobuf:"bytes,6,opt,name=term,proto3" json:"term,omitempty"`
+	Version       uint64                 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Course) Reset() {
+	*x = Course{}
+	mi := &file_university_v1_university_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Course) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Course) ProtoMessage() {}
+
+func (x *Course) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Course.ProtoReflect.Descriptor instead.
+func (*Course) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Course) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Course) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Course) GetDepartmentId() uint64 {
+	if x != nil {
+		return x.DepartmentId
+	}
+	return 0
+}
+
+func (x *Course) GetInstructorId() uint64 {
+	if x != nil && x.InstructorId != nil {
+		return *x.InstructorId
+	}
+	return 0
+}
+
+func (x *Course) GetCredits() uint32 {
+	if x != nil {
+		return x.Credits
+	}
+	return 0
+}
+
+func (x *Course) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+func (x *Course) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Department struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Kind            string                 `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	ParentId        *uint64                `protobuf:"varint,4,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	MaxTeachingLoad *uint32                `protobuf:"varint,5,opt,name=max_teaching_load,json=maxTeachingLoad,proto3,oneof" json:"max_teaching_load,omitempty"`
+	Version         uint64                 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Department) Reset() {
+	*x = Department{}
+	mi := &file_university_v1_university_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Department) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Department) ProtoMessage() {}
+
+func (x *Department) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Department.ProtoReflect.Descriptor instead.
+func (*Department) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Department) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Department) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Department) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Department) GetParentId() uint64 {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return 0
+}
+
+func (x *Department) GetMaxTeachingLoad() uint32 {
+	if x != nil && x.MaxTeachingLoad != nil {
+		return *x.MaxTeachingLoad
+	}
+	return 0
+}
+
+func (x *Department) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Instructor struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	FullName        string                 `protobuf:"bytes,2,opt,name=full_name,json=fullName,proto3" json:"full_name,omitempty"`
+	Age             uint32                 `protobuf:"varint,3,opt,name=age,proto3" json:"age,omitempty"`
+	DepartmentId    uint64                 `protobuf:"varint,4,opt,name=department_id,json=departmentId,proto3" json:"department_id,omitempty"`
+	MaxTeachingLoad *uint32                `protobuf:"varint,5,opt,name=max_teaching_load,json=maxTeachingLoad,proto3,oneof" json:"max_teaching_load,omitempty"`
+	Version         uint64                 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Instructor) Reset() {
+	*x = Instructor{}
+	mi := &file_university_v1_university_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instructor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instructor) ProtoMessage() {}
+
+func (x *Instructor) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instructor.ProtoReflect.Descriptor instead.
+func (*Instructor) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Instructor) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Instructor) GetFullName() string {
+	if x != nil {
+		return x.FullName
+	}
+	return ""
+}
+
+func (x *Instructor) GetAge() uint32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+func (x *Instructor) GetDepartmentId() uint64 {
+	if x != nil {
+		return x.DepartmentId
+	}
+	return 0
+}
+
+func (x *Instructor) GetMaxTeachingLoad() uint32 {
+	if x != nil && x.MaxTeachingLoad != nil {
+		return *x.MaxTeachingLoad
+	}
+	return 0
+}
+
+func (x *Instructor) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type Enrollment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudentId     uint64                 `protobuf:"varint,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	CourseId      uint64                 `protobuf:"varint,2,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enrollment) Reset() {
+	*x = Enrollment{}
+	mi := &file_university_v1_university_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enrollment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enrollment) ProtoMessage() {}
+
+func (x *Enrollment) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enrollment.ProtoReflect.Descriptor instead.
+func (*Enrollment) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Enrollment) GetStudentId() uint64 {
+	if x != nil {
+		return x.StudentId
+	}
+	return 0
+}
+
+func (x *Enrollment) GetCourseId() uint64 {
+	if x != nil {
+		return x.CourseId
+	}
+	return 0
+}
+
+type DepartmentStudentCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	StudentCount  uint64                 `protobuf:"varint,3,opt,name=student_count,json=studentCount,proto3" json:"student_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DepartmentStudentCount) Reset() {
+	*x = DepartmentStudentCount{}
+	mi := &file_university_v1_university_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DepartmentStudentCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepartmentStudentCount) ProtoMessage() {}
+
+func (x *DepartmentStudentCount) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepartmentStudentCount.ProtoReflect.Descriptor instead.
+func (*DepartmentStudentCount) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DepartmentStudentCount) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DepartmentStudentCount) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DepartmentStudentCount) GetStudentCount() uint64 {
+	if x != nil {
+		return x.StudentCount
+	}
+	return 0
+}
+
+type IdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdRequest) Reset() {
+	*x = IdRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdRequest) ProtoMessage() {}
+
+func (x *IdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdRequest.ProtoReflect.Descriptor instead.
+func (*IdRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IdRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListStudentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DepartmentId  *uint64                `protobuf:"varint,1,opt,name=department_id,json=departmentId,proto3,oneof" json:"department_id,omitempty"`
+	Age           *uint32                `protobuf:"varint,2,opt,name=age,proto3,oneof" json:"age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStudentsRequest) Reset() {
+	*x = ListStudentsRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStudentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStudentsRequest) ProtoMessage() {}
+
+func (x *ListStudentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStudentsRequest.ProtoReflect.Descriptor instead.
+func (*ListStudentsRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListStudentsRequest) GetDepartmentId() uint64 {
+	if x != nil && x.DepartmentId != nil {
+		return *x.DepartmentId
+	}
+	return 0
+}
+
+func (x *ListStudentsRequest) GetAge() uint32 {
+	if x != nil && x.Age != nil {
+		return *x.Age
+	}
+	return 0
+}
+
+type UpdateStudentAgeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// version the change is based on; the update fails with ABORTED if the
+	// student was modified since.
+	Version       uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Age           uint32 `protobuf:"varint,3,opt,name=age,proto3" json:"age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStudentAgeRequest) Reset() {
+	*x = UpdateStudentAgeRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStudentAgeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStudentAgeRequest) ProtoMessage() {}
+
+func (x *UpdateStudentAgeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStudentAgeRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStudentAgeRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateStudentAgeRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateStudentAgeRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateStudentAgeRequest) GetAge() uint32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+type ListCoursesRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	InstructorId      *uint64                `protobuf:"varint,1,opt,name=instructor_id,json=instructorId,proto3,oneof" json:"instructor_id,omitempty"`
+	WithoutInstructor bool                   `protobuf:"varint,2,opt,name=without_instructor,json=withoutInstructor,proto3" json:"without_instructor,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ListCoursesRequest) Reset() {
+	*x = ListCoursesRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCoursesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoursesRequest) ProtoMessage() {}
+
+func (x *ListCoursesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoursesRequest.ProtoReflect.Descriptor instead.
+func (*ListCoursesRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListCoursesRequest) GetInstructorId() uint64 {
+	if x != nil && x.InstructorId != nil {
+		return *x.InstructorId
+	}
+	return 0
+}
+
+func (x *ListCoursesRequest) GetWithoutInstructor() bool {
+	if x != nil {
+		return x.WithoutInstructor
+	}
+	return false
+}
+
+// Fields left unset in the update requests keep their current value.
+type UpdateCourseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name          *string                `protobuf:"bytes,3,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	DepartmentId  *uint64                `protobuf:"varint,4,opt,name=department_id,json=departmentId,proto3,oneof" json:"department_id,omitempty"`
+	InstructorId  *uint64                `protobuf:"varint,5,opt,name=instructor_id,json=instructorId,proto3,oneof" json:"instructor_id,omitempty"`
+	Credits       *uint32                `protobuf:"varint,6,opt,name=credits,proto3,oneof" json:"credits,omitempty"`
+	Term          *string                `protobuf:"bytes,7,opt,name=term,proto3,oneof" json:"term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCourseRequest) Reset() {
+	*x = UpdateCourseRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCourseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCourseRequest) ProtoMessage() {}
+
+func (x *UpdateCourseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCourseRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCourseRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateCourseRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCourseRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateCourseRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCourseRequest) GetDepartmentId() uint64 {
+	if x != nil && x.DepartmentId != nil {
+		return *x.DepartmentId
+	}
+	return 0
+}
+
+func (x *UpdateCourseRequest) GetInstructorId() uint64 {
+	if x != nil && x.InstructorId != nil {
+		return *x.InstructorId
+	}
+	return 0
+}
+
+func (x *UpdateCourseRequest) GetCredits() uint32 {
+	if x != nil && x.Credits != nil {
+		return *x.Credits
+	}
+	return 0
+}
+
+func (x *UpdateCourseRequest) GetTerm() string {
+	if x != nil && x.Term != nil {
+		return *x.Term
+	}
+	return ""
+}
+
+type AssignInstructorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourseId      uint64                 `protobuf:"varint,1,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	InstructorId  uint64                 `protobuf:"varint,2,opt,name=instructor_id,json=instructorId,proto3" json:"instructor_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignInstructorRequest) Reset() {
+	*x = AssignInstructorRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignInstructorRequest) ProtoMessage() {}
+
+func (x *AssignInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignInstructorRequest.ProtoReflect.Descriptor instead.
+func (*AssignInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AssignInstructorRequest) GetCourseId() uint64 {
+	if x != nil {
+		return x.CourseId
+	}
+	return 0
+}
+
+func (x *AssignInstructorRequest) GetInstructorId() uint64 {
+	if x != nil {
+		return x.InstructorId
+	}
+	return 0
+}
+
+type UpdateDepartmentRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version         uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name            *string                `protobuf:"bytes,3,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Kind            *string                `protobuf:"bytes,4,opt,name=kind,proto3,oneof" json:"kind,omitempty"`
+	MaxTeachingLoad *uint32                `protobuf:"varint,5,opt,name=max_teaching_load,json=maxTeachingLoad,proto3,oneof" json:"max_teaching_load,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateDepartmentRequest) Reset() {
+	*x = UpdateDepartmentRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDepartmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDepartmentRequest) ProtoMessage() {}
+
+func (x *UpdateDepartmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDepartmentRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDepartmentRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateDepartmentRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateDepartmentRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateDepartmentRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateDepartmentRequest) GetKind() string {
+	if x != nil && x.Kind != nil {
+		return *x.Kind
+	}
+	return ""
+}
+
+func (x *UpdateDepartmentRequest) GetMaxTeachingLoad() uint32 {
+	if x != nil && x.MaxTeachingLoad != nil {
+		return *x.MaxTeachingLoad
+	}
+	return 0
+}
+
+type UpdateInstructorRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version         uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	FullName        *string                `protobuf:"bytes,3,opt,name=full_name,json=fullName,proto3,oneof" json:"full_name,omitempty"`
+	Age             *uint32                `protobuf:"varint,4,opt,name=age,proto3,oneof" json:"age,omitempty"`
+	DepartmentId    *uint64                `protobuf:"varint,5,opt,name=department_id,json=departmentId,proto3,oneof" json:"department_id,omitempty"`
+	MaxTeachingLoad *uint32                `protobuf:"varint,6,opt,name=max_teaching_load,json=maxTeachingLoad,proto3,oneof" json:"max_teaching_load,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateInstructorRequest) Reset() {
+	*x = UpdateInstructorRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInstructorRequest) ProtoMessage() {}
+
+func (x *UpdateInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInstructorRequest.ProtoReflect.Descriptor instead.
+func (*UpdateInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateInstructorRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateInstructorRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateInstructorRequest) GetFullName() string {
+	if x != nil && x.FullName != nil {
+		return *x.FullName
+	}
+	return ""
+}
+
+func (x *UpdateInstructorRequest) GetAge() uint32 {
+	if x != nil && x.Age != nil {
+		return *x.Age
+	}
+	return 0
+}
+
+func (x *UpdateInstructorRequest) GetDepartmentId() uint64 {
+	if x != nil && x.DepartmentId != nil {
+		return *x.DepartmentId
+	}
+	return 0
+}
+
+func (x *UpdateInstructorRequest) GetMaxTeachingLoad() uint32 {
+	if x != nil && x.MaxTeachingLoad != nil {
+		return *x.MaxTeachingLoad
+	}
+	return 0
+}
+
+type ListStudentsOfInstructorRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	InstructorId uint64                 `protobuf:"varint,1,opt,name=instructor_id,json=instructorId,proto3" json:"instructor_id,omitempty"`
+	// staff roles to include besides primary instructorship, e.g. "ta".
+	Roles         []string `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStudentsOfInstructorRequest) Reset() {
+	*x = ListStudentsOfInstructorRequest{}
+	mi := &file_university_v1_university_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStudentsOfInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStudentsOfInstructorRequest) ProtoMessage() {}
+
+func (x *ListStudentsOfInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_university_v1_university_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStudentsOfInstructorRequest.ProtoReflect.Descriptor instead.
+func (*ListStudentsOfInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_university_v1_university_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListStudentsOfInstructorRequest) GetInstructorId() uint64 {
+	if x != nil {
+		return x.InstructorId
+	}
+	return 0
+}
+
+func (x *ListStudentsOfInstructorRequest) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+var File_university_v1_university_proto protoreflect.FileDescriptor
+
+const file_university_v1_university_proto_rawDesc = "" +
+	"\n" +
+	"\x1euniversity/v1/university.proto\x12\runiversity.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xed\x01\n" +
+	"\aStudent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1b\n" +
+	"\tfull_name\x18\x02 \x01(\tR\bfullName\x12\x10\n" +
+	"\x03age\x18\x03 \x01(\rR\x03age\x12\x12\n" +
+	"\x04city\x18\x04 \x01(\tR\x04city\x12(\n" +
+	"\rdepartment_id\x18\x05 \x01(\x04H\x00R\fdepartmentId\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x18\n" +
+	"\aversion\x18\a \x01(\x04R\aversionB\x10\n" +
+	"\x0e_department_id\"\xd5\x01\n" +
+	"\x06Course\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12#\n" +
+	"\rdepartment_id\x18\x03 \x01(\x04R\fdepartmentId\x12(\n" +
+	"\rinstructor_id\x18\x04 \x01(\x04H\x00R\finstructorId\x88\x01\x01\x12\x18\n" +
+	"\acredits\x18\x05 \x01(\rR\acredits\x12\x12\n" +
+	"\x04term\x18\x06 \x01(\tR\x04term\x12\x18\n" +
+	"\aversion\x18\a \x01(\x04R\aversionB\x10\n" +
+	"\x0e_instructor_id\"\xd5\x01\n" +
+	"\n" +
+	"Department\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\x12 \n" +
+	"\tparent_id\x18\x04 \x01(\x04H\x00R\bparentId\x88\x01\x01\x12/\n" +
+	"\x11max_teaching_load\x18\x05 \x01(\rH\x01R\x0fmaxTeachingLoad\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x04R\aversionB\f\n" +
+	"\n" +
+	"_parent_idB\x14\n" +
+	"\x12_max_teaching_load\"\xd1\x01\n" +
+	"\n" +
+	"Instructor\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1b\n" +
+	"\tfull_name\x18\x02 \x01(\tR\bfullName\x12\x10\n" +
+	"\x03age\x18\x03 \x01(\rR\x03age\x12#\n" +
+	"\rdepartment_id\x18\x04 \x01(\x04R\fdepartmentId\x12/\n" +
+	"\x11max_teaching_load\x18\x05 \x01(\rH\x00R\x0fmaxTeachingLoad\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x04R\aversionB\x14\n" +
+	"\x12_max_teaching_load\"H\n" +
+	"\n" +
+	"Enrollment\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\x04R\tstudentId\x12\x1b\n" +
+	"\tcourse_id\x18\x02 \x01(\x04R\bcourseId\"a\n" +
+	"\x16DepartmentStudentCount\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12#\n" +
+	"\rstudent_count\x18\x03 \x01(\x04R\fstudentCount\"\x1b\n" +
+	"\tIdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"p\n" +
+	"\x13ListStudentsRequest\x12(\n" +
+	"\rdepartment_id\x18\x01 \x01(\x04H\x00R\fdepartmentId\x88\x01\x01\x12\x15\n" +
+	"\x03age\x18\x02 \x01(\rH\x01R\x03age\x88\x01\x01B\x10\n" +
+	"\x0e_department_idB\x06\n" +
+	"\x04_age\"U\n" +
+	"\x17UpdateStudentAgeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x10\n" +
+	"\x03age\x18\x03 \x01(\rR\x03age\"\x7f\n" +
+	"\x12ListCoursesRequest\x12(\n" +
+	"\rinstructor_id\x18\x01 \x01(\x04H\x00R\finstructorId\x88\x01\x01\x12-\n" +
+	"\x12without_instructor\x18\x02 \x01(\bR\x11withoutInstructorB\x10\n" +
+	"\x0e_instructor_id\"\xa6\x02\n" +
+	"\x13UpdateCourseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x17\n" +
+	"\x04name\x18\x03 \x01(\tH\x00R\x04name\x88\x01\x01\x12(\n" +
+	"\rdepartment_id\x18\x04 \x01(\x04H\x01R\fdepartmentId\x88\x01\x01\x12(\n" +
+	"\rinstructor_id\x18\x05 \x01(\x04H\x02R\finstructorId\x88\x01\x01\x12\x1d\n" +
+	"\acredits\x18\x06 \x01(\rH\x03R\acredits\x88\x01\x01\x12\x17\n" +
+	"\x04term\x18\a \x01(\tH\x04R\x04term\x88\x01\x01B\a\n" +
+	"\x05_nameB\x10\n" +
+	"\x0e_department_idB\x10\n" +
+	"\x0e_instructor_idB\n" +
+	"\n" +
+	"\b_creditsB\a\n" +
+	"\x05_term\"[\n" +
+	"\x17AssignInstructorRequest\x12\x1b\n" +
+	"\tcourse_id\x18\x01 \x01(\x04R\bcourseId\x12#\n" +
+	"\rinstructor_id\x18\x02 \x01(\x04R\finstructorId\"\xce\x01\n" +
+	"\x17UpdateDepartmentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x17\n" +
+	"\x04name\x18\x03 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x17\n" +
+	"\x04kind\x18\x04 \x01(\tH\x01R\x04kind\x88\x01\x01\x12/\n" +
+	"\x11max_teaching_load\x18\x05 \x01(\rH\x02R\x0fmaxTeachingLoad\x88\x01\x01B\a\n" +
+	"\x05_nameB\a\n" +
+	"\x05_kindB\x14\n" +
+	"\x12_max_teaching_load\"\x95\x02\n" +
+	"\x17UpdateInstructorRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12 \n" +
+	"\tfull_name\x18\x03 \x01(\tH\x00R\bfullName\x88\x01\x01\x12\x15\n" +
+	"\x03age\x18\x04 \x01(\rH\x01R\x03age\x88\x01\x01\x12(\n" +
+	"\rdepartment_id\x18\x05 \x01(\x04H\x02R\fdepartmentId\x88\x01\x01\x12/\n" +
+	"\x11max_teaching_load\x18\x06 \x01(\rH\x03R\x0fmaxTeachingLoad\x88\x01\x01B\f\n" +
+	"\n" +
+	"_full_nameB\x06\n" +
+	"\x04_ageB\x10\n" +
+	"\x0e_department_idB\x14\n" +
+	"\x12_max_teaching_load\"\\\n" +
+	"\x1fListStudentsOfInstructorRequest\x12#\n" +
+	"\rinstructor_id\x18\x01 \x01(\x04R\finstructorId\x12\x14\n" +
+	"\x05roles\x18\x02 \x03(\tR\x05roles2\xec\x0f\n" +
+	"\n" +
+	"University\x12?\n" +
+	"\rCreateStudent\x12\x16.university.v1.Student\x1a\x16.university.v1.Student\x12>\n" +
+	"\n" +
+	"GetStudent\x12\x18.university.v1.IdRequest\x1a\x16.university.v1.Student\x12L\n" +
+	"\fListStudents\x12\".university.v1.ListStudentsRequest\x1a\x16.university.v1.Student0\x01\x12R\n" +
+	"\x10UpdateStudentAge\x12&.university.v1.UpdateStudentAgeRequest\x1a\x16.university.v1.Student\x12A\n" +
+	"\rDeleteStudent\x12\x18.university.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12<\n" +
+	"\fCreateCourse\x12\x15.university.v1.Course\x1a\x15.university.v1.Course\x12<\n" +
+	"\tGetCourse\x12\x18.university.v1.IdRequest\x1a\x15.university.v1.Course\x12I\n" +
+	"\vListCourses\x12!.university.v1.ListCoursesRequest\x1a\x15.university.v1.Course0\x01\x12I\n" +
+	"\fUpdateCourse\x12\".university.v1.UpdateCourseRequest\x1a\x15.university.v1.Course\x12@\n" +
+	"\fDeleteCourse\x12\x18.university.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12Q\n" +
+	"\x10AssignInstructor\x12&.university.v1.AssignInstructorRequest\x1a\x15.university.v1.Course\x12E\n" +
+	"\x12UnassignInstructor\x12\x18.university.v1.IdRequest\x1a\x15.university.v1.Course\x12H\n" +
+	"\x10CreateDepartment\x12\x19.university.v1.Department\x1a\x19.university.v1.Department\x12D\n" +
+	"\rGetDepartment\x12\x18.university.v1.IdRequest\x1a\x19.university.v1.Department\x12F\n" +
+	"\x0fListDepartments\x12\x16.google.protobuf.Empty\x1a\x19.university.v1.Department0\x01\x12U\n" +
+	"\x10UpdateDepartment\x12&.university.v1.UpdateDepartmentRequest\x1a\x19.university.v1.Department\x12D\n" +
+	"\x10DeleteDepartment\x12\x18.university.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12H\n" +
+	"\x10CreateInstructor\x12\x19.university.v1.Instructor\x1a\x19.university.v1.Instructor\x12D\n" +
+	"\rGetInstructor\x12\x18.university.v1.IdRequest\x1a\x19.university.v1.Instructor\x12F\n" +
+	"\x0fListInstructors\x12\x16.google.protobuf.Empty\x1a\x19.university.v1.Instructor0\x01\x12U\n" +
+	"\x10UpdateInstructor\x12&.university.v1.UpdateInstructorRequest\x1a\x19.university.v1.Instructor\x12D\n" +
+	"\x10DeleteInstructor\x12\x18.university.v1.IdRequest\x1a\x16.google.protobuf.Empty\x12B\n" +
+	"\rEnrollStudent\x12\x19.university.v1.Enrollment\x1a\x16.google.protobuf.Empty\x12G\n" +
+	"\x12ListStudentCourses\x12\x18.university.v1.IdRequest\x1a\x15.university.v1.Course0\x01\x12H\n" +
+	"\x12ListCourseStudents\x12\x18.university.v1.IdRequest\x1a\x16.university.v1.Student0\x01\x12c\n" +
+	" GetStudentCountForEachDepartment\x12\x16.google.protobuf.Empty\x1a%.university.v1.DepartmentStudentCount0\x01\x12d\n" +
+	"\x18ListStudentsOfInstructor\x12..university.v1.ListStudentsOfInstructorRequest\x1a\x16.university.v1.Student0\x01B\x1bZ\x19exercise1/pb/universitypbb\x06proto3"
+
+var (
+	file_university_v1_university_proto_rawDescOnce sync.Once
+	file_university_v1_university_proto_rawDescData []byte
+)
+
+func file_university_v1_university_proto_rawDescGZIP() []byte {
+	file_university_v1_university_proto_rawDescOnce.Do(func() {
+		file_university_v1_university_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_university_v1_university_proto_rawDesc), len(file_university_v1_university_proto_rawDesc)))
+	})
+	return file_university_v1_university_proto_rawDescData
+}
+
+var file_university_v1_university_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_university_v1_university_proto_goTypes = []any{
+	(*Student)(nil),                         // 0: university.v1.Student
+	(*Course)(nil),                          // 1: university.v1.Course
+	(*Department)(nil),                      // 2: university.v1.Department
+	(*Instructor)(nil),                      // 3: university.v1.Instructor
+	(*Enrollment)(nil),                      // 4: university.v1.Enrollment
+	(*DepartmentStudentCount)(nil),          // 5: university.v1.DepartmentStudentCount
+	(*IdRequest)(nil),                       // 6: university.v1.IdRequest
+	(*ListStudentsRequest)(nil),             // 7: university.v1.ListStudentsRequest
+	(*UpdateStudentAgeRequest)(nil),         // 8: university.v1.UpdateStudentAgeRequest
+	(*ListCoursesRequest)(nil),              // 9: university.v1.ListCoursesRequest
+	(*UpdateCourseRequest)(nil),             // 10: university.v1.UpdateCourseRequest
+	(*AssignInstructorRequest)(nil),         // 11: university.v1.AssignInstructorRequest
+	(*UpdateDepartmentRequest)(nil),         // 12: university.v1.UpdateDepartmentRequest
+	(*UpdateInstructorRequest)(nil),         // 13: university.v1.UpdateInstructorRequest
+	(*ListStudentsOfInstructorRequest)(nil), // 14: university.v1.ListStudentsOfInstructorRequest
+	(*timestamppb.Timestamp)(nil),           // 15: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                   // 16: google.protobuf.Empty
+}
+var file_university_v1_university_proto_depIdxs = []int32{
+	15, // 0: university.v1.Student.created_at:type_name -> google.protobuf.Timestamp
+	0,  // 1: university.v1.University.CreateStudent:input_type -> university.v1.Student
+	6,  // 2: university.v1.University.GetStudent:input_type -> university.v1.IdRequest
+	7,  // 3: university.v1.University.ListStudents:input_type -> university.v1.ListStudentsRequest
+	8,  // 4: university.v1.University.UpdateStudentAge:input_type -> university.v1.UpdateStudentAgeRequest
+	6,  // 5: university.v1.University.DeleteStudent:input_type -> university.v1.IdRequest
+	1,  // 6: university.v1.University.CreateCourse:input_type -> university.v1.Course
+	6,  // 7: university.v1.University.GetCourse:input_type -> university.v1.IdRequest
+	9,  // 8: university.v1.University.ListCourses:input_type -> university.v1.ListCoursesRequest
+	10, // 9: university.v1.University.UpdateCourse:input_type -> university.v1.UpdateCourseRequest
+	6,  // 10: university.v1.University.DeleteCourse:input_type -> university.v1.IdRequest
+	11, // 11: university.v1.University.AssignInstructor:input_type -> university.v1.AssignInstructorRequest
+	6,  // 12: university.v1.University.UnassignInstructor:input_type -> university.v1.IdRequest
+	2,  // 13: university.v1.University.CreateDepartment:input_type -> university.v1.Department
+	6,  // 14: university.v1.University.GetDepartment:input_type -> university.v1.IdRequest
+	16, // 15: university.v1.University.ListDepartments:input_type -> google.protobuf.Empty
+	12, // 16: university.v1.University.UpdateDepartment:input_type -> university.v1.UpdateDepartmentRequest
+	6,  // 17: university.v1.University.DeleteDepartment:input_type -> university.v1.IdRequest
+	3,  // 18: university.v1.University.CreateInstructor:input_type -> university.v1.Instructor
+	6,  // 19: university.v1.University.GetInstructor:input_type -> university.v1.IdRequest
+	16, // 20: university.v1.University.ListInstructors:input_type -> google.protobuf.Empty
+	13, // 21: university.v1.University.UpdateInstructor:input_type -> university.v1.UpdateInstructorRequest
+	6,  // 22: university.v1.University.DeleteInstructor:input_type -> university.v1.IdRequest
+	4,  // 23: university.v1.University.EnrollStudent:input_type -> university.v1.Enrollment
+	6,  // 24: university.v1.University.ListStudentCourses:input_type -> university.v1.IdRequest
+	6,  // 25: university.v1.University.ListCourseStudents:input_type -> university.v1.IdRequest
+	16, // 26: university.v1.University.GetStudentCountForEachDepartment:input_type -> google.protobuf.Empty
+	14, // 27: university.v1.University.ListStudentsOfInstructor:input_type -> university.v1.ListStudentsOfInstructorRequest
+	0,  // 28: university.v1.University.CreateStudent:output_type -> university.v1.Student
+	0,  // 29: university.v1.University.GetStudent:output_type -> university.v1.Student
+	0,  // 30: university.v1.University.ListStudents:output_type -> university.v1.Student
+	0,  // 31: university.v1.University.UpdateStudentAge:output_type -> university.v1.Student
+	16, // 32: university.v1.University.DeleteStudent:output_type -> google.protobuf.Empty
+	1,  // 33: university.v1.University.CreateCourse:output_type -> university.v1.Course
+	1,  // 34: university.v1.University.GetCourse:output_type -> university.v1.Course
+	1,  // 35: university.v1.University.ListCourses:output_type -> university.v1.Course
+	1,  // 36: university.v1.University.UpdateCourse:output_type -> university.v1.Course
+	16, // 37: university.v1.University.DeleteCourse:output_type -> google.protobuf.Empty
+	1,  // 38: university.v1.University.AssignInstructor:output_type -> university.v1.Course
+	1,  // 39: university.v1.University.UnassignInstructor:output_type -> university.v1.Course
+	2,  // 40: university.v1.University.CreateDepartment:output_type -> university.v1.Department
+	2,  // 41: university.v1.University.GetDepartment:output_type -> university.v1.Department
+	2,  // 42: university.v1.University.ListDepartments:output_type -> university.v1.Department
+	2,  // 43: university.v1.University.UpdateDepartment:output_type -> university.v1.Department
+	16, // 44: university.v1.University.DeleteDepartment:output_type -> google.protobuf.Empty
+	3,  // 45: university.v1.University.CreateInstructor:output_type -> university.v1.Instructor
+	3,  // 46: university.v1.University.GetInstructor:output_type -> university.v1.Instructor
+	3,  // 47: university.v1.University.ListInstructors:output_type -> university.v1.Instructor
+	3,  // 48: university.v1.University.UpdateInstructor:output_type -> university.v1.Instructor
+	16, // 49: university.v1.University.DeleteInstructor:output_type -> google.protobuf.Empty
+	16, // 50: university.v1.University.EnrollStudent:output_type -> google.protobuf.Empty
+	1,  // 51: university.v1.University.ListStudentCourses:output_type -> university.v1.Course
+	0,  // 52: university.v1.University.ListCourseStudents:output_type -> university.v1.Student
+	5,  // 53: university.v1.University.GetStudentCountForEachDepartment:output_type -> university.v1.DepartmentStudentCount
+	0,  // 54: university.v1.University.ListStudentsOfInstructor:output_type -> university.v1.Student
+	28, // [28:55] is the sub-list for method output_type
+	1,  // [1:28] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_university_v1_university_proto_init() }
+func file_university_v1_university_proto_init() {
+	if File_university_v1_university_proto != nil {
+		return
+	}
+	file_university_v1_university_proto_msgTypes[0].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[1].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[2].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[3].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[7].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[9].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[10].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[12].OneofWrappers = []any{}
+	file_university_v1_university_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_university_v1_university_proto_rawDesc), len(file_university_v1_university_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_university_v1_university_proto_goTypes,
+		DependencyIndexes: file_university_v1_university_proto_depIdxs,
+		MessageInfos:      file_university_v1_university_proto_msgTypes,
+	}.Build()
+	File_university_v1_university_proto = out.File
+	file_university_v1_university_proto_goTypes = nil
+	file_university_v1_university_proto_depIdxs = nil
+}
@@ -0,0 +1,1135 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: university/v1/university.proto
+
+package universitypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	University_CreateStudent_FullMethodName                    = "/university.v1.University/CreateStudent"
+	University_GetStudent_FullMethodName                       = "/university.v1.University/GetStudent"
+	University_ListStudents_FullMethodName                     = "/university.v1.University/ListStudents"
+	University_UpdateStudentAge_FullMethodName                 = "/university.v1.University/UpdateStudentAge"
+	University_DeleteStudent_FullMethodName                    = "/university.v1.University/DeleteStudent"
+	University_CreateCourse_FullMethodName                     = "/university.v1.University/CreateCourse"
+	University_GetCourse_FullMethodName                        = "/university.v1.University/GetCourse"
+	University_ListCourses_FullMethodName                      = "/university.v1.University/ListCourses"
+	University_UpdateCourse_FullMethodName                     = "/university.v1.University/UpdateCourse"
+	University_DeleteCourse_FullMethodName                     = "/university.v1.University/DeleteCourse"
+	University_AssignInstructor_FullMethodName                 = "/university.v1.University/AssignInstructor"
+	University_UnassignInstructor_FullMethodName               = "/university.v1.University/UnassignInstructor"
+	University_CreateDepartment_FullMethodName                 = "/university.v1.University/CreateDepartment"
+	University_GetDepartment_FullMethodName                    = "/university.v1.University/GetDepartment"
+	University_ListDepartments_FullMethodName                  = "/university.v1.University/ListDepartments"
+	University_UpdateDepartment_FullMethodName                 = "/university.v1.University/UpdateDepartment"
+	University_DeleteDepartment_FullMethodName                 = "/university.v1.University/DeleteDepartment"
+	University_CreateInstructor_FullMethodName                 = "/university.v1.University/CreateInstructor"
+	University_GetInstructor_FullMethodName                    = "/university.v1.University/GetInstructor"
+	University_ListInstructors_FullMethodName                  = "/university.v1.University/ListInstructors"
+	University_UpdateInstructor_FullMethodName                 = "/university.v1.University/UpdateInstructor"
+	University_DeleteInstructor_FullMethodName                 = "/university.v1.University/DeleteInstructor"
+	University_EnrollStudent_FullMethodName                    = "/university.v1.University/EnrollStudent"
+	University_ListStudentCourses_FullMethodName               = "/university.v1.University/ListStudentCourses"
+	University_ListCourseStudents_FullMethodName               = "/university.v1.University/ListCourseStudents"
+	University_GetStudentCountForEachDepartment_FullMethodName = "/university.v1.University/GetStudentCountForEachDepartment"
+	University_ListStudentsOfInstructor_FullMethodName         = "/university.v1.University/ListStudentsOfInstructor"
+)
+
+// UniversityClient is the client API for University service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UniversityClient interface {
+	CreateStudent(ctx context.Context, in *Student, opts ...grpc.CallOption) (*Student, error)
+	GetStudent(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Student, error)
+	ListStudents(ctx context.Context, in *ListStudentsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Student], error)
+	UpdateStudentAge(ctx context.Context, in *UpdateStudentAgeRequest, opts ...grpc.CallOption) (*Student, error)
+	DeleteStudent(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateCourse(ctx context.Context, in *Course, opts ...grpc.CallOption) (*Course, error)
+	GetCourse(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Course, error)
+	ListCourses(ctx context.Context, in *ListCoursesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Course], error)
+	UpdateCourse(ctx context.Context, in *UpdateCourseRequest, opts ...grpc.CallOption) (*Course, error)
+	DeleteCourse(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AssignInstructor(ctx context.Context, in *AssignInstructorRequest, opts ...grpc.CallOption) (*Course, error)
+	UnassignInstructor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Course, error)
+	CreateDepartment(ctx context.Context, in *Department, opts ...grpc.CallOption) (*Department, error)
+	GetDepartment(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Department, error)
+	ListDepartments(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Department], error)
+	UpdateDepartment(ctx context.Context, in *UpdateDepartmentRequest, opts ...grpc.CallOption) (*Department, error)
+	DeleteDepartment(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CreateInstructor(ctx context.Context, in *Instructor, opts ...grpc.CallOption) (*Instructor, error)
+	GetInstructor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Instructor, error)
+	ListInstructors(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Instructor], error)
+	UpdateInstructor(ctx context.Context, in *UpdateInstructorRequest, opts ...grpc.CallOption) (*Instructor, error)
+	DeleteInstructor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	EnrollStudent(ctx context.Context, in *Enrollment, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListStudentCourses(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Course], error)
+	ListCourseStudents(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Student], error)
+	GetStudentCountForEachDepartment(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DepartmentStudentCount], error)
+	ListStudentsOfInstructor(ctx context.Context, in *ListStudentsOfInstructorRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Student], error)
+}
+
+type universityClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUniversityClient(cc grpc.ClientConnInterface) UniversityClient {
+	return &universityClient{cc}
+}
+
+func (c *universityClient) CreateStudent(ctx context.Context, in *Student, opts ...grpc.CallOption) (*Student, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Student)
+	err := c.cc.Invoke(ctx, University_CreateStudent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) GetStudent(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Student, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Student)
+	err := c.cc.Invoke(ctx, University_GetStudent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) ListStudents(ctx context.Context, in *ListStudentsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Student], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[0], University_ListStudents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListStudentsRequest, Student]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListStudentsClient = grpc.ServerStreamingClient[Student]
+
+func (c *universityClient) UpdateStudentAge(ctx context.Context, in *UpdateStudentAgeRequest, opts ...grpc.CallOption) (*Student, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Student)
+	err := c.cc.Invoke(ctx, University_UpdateStudentAge_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) DeleteStudent(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, University_DeleteStudent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) CreateCourse(ctx context.Context, in *Course, opts ...grpc.CallOption) (*Course, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Course)
+	err := c.cc.Invoke(ctx, University_CreateCourse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) GetCourse(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Course, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Course)
+	err := c.cc.Invoke(ctx, University_GetCourse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) ListCourses(ctx context.Context, in *ListCoursesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Course], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[1], University_ListCourses_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListCoursesRequest, Course]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListCoursesClient = grpc.ServerStreamingClient[Course]
+
+func (c *universityClient) UpdateCourse(ctx context.Context, in *UpdateCourseRequest, opts ...grpc.CallOption) (*Course, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Course)
+	err := c.cc.Invoke(ctx, University_UpdateCourse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) DeleteCourse(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, University_DeleteCourse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) AssignInstructor(ctx context.Context, in *AssignInstructorRequest, opts ...grpc.CallOption) (*Course, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Course)
+	err := c.cc.Invoke(ctx, University_AssignInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) UnassignInstructor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Course, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Course)
+	err := c.cc.Invoke(ctx, University_UnassignInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) CreateDepartment(ctx context.Context, in *Department, opts ...grpc.CallOption) (*Department, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Department)
+	err := c.cc.Invoke(ctx, University_CreateDepartment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) GetDepartment(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Department, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Department)
+	err := c.cc.Invoke(ctx, University_GetDepartment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) ListDepartments(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Department], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[2], University_ListDepartments_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, Department]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListDepartmentsClient = grpc.ServerStreamingClient[Department]
+
+func (c *universityClient) UpdateDepartment(ctx context.Context, in *UpdateDepartmentRequest, opts ...grpc.CallOption) (*Department, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Department)
+	err := c.cc.Invoke(ctx, University_UpdateDepartment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) DeleteDepartment(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, University_DeleteDepartment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) CreateInstructor(ctx context.Context, in *Instructor, opts ...grpc.CallOption) (*Instructor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Instructor)
+	err := c.cc.Invoke(ctx, University_CreateInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) GetInstructor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*Instructor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Instructor)
+	err := c.cc.Invoke(ctx, University_GetInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) ListInstructors(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Instructor], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[3], University_ListInstructors_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, Instructor]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListInstructorsClient = grpc.ServerStreamingClient[Instructor]
+
+func (c *universityClient) UpdateInstructor(ctx context.Context, in *UpdateInstructorRequest, opts ...grpc.CallOption) (*Instructor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Instructor)
+	err := c.cc.Invoke(ctx, University_UpdateInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) DeleteInstructor(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, University_DeleteInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) EnrollStudent(ctx context.Context, in *Enrollment, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, University_EnrollStudent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *universityClient) ListStudentCourses(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Course], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[4], University_ListStudentCourses_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IdRequest, Course]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListStudentCoursesClient = grpc.ServerStreamingClient[Course]
+
+func (c *universityClient) ListCourseStudents(ctx context.Context, in *IdRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Student], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[5], University_ListCourseStudents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IdRequest, Student]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListCourseStudentsClient = grpc.ServerStreamingClient[Student]
+
+func (c *universityClient) GetStudentCountForEachDepartment(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DepartmentStudentCount], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[6], University_GetStudentCountForEachDepartment_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, DepartmentStudentCount]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_GetStudentCountForEachDepartmentClient = grpc.ServerStreamingClient[DepartmentStudentCount]
+
+func (c *universityClient) ListStudentsOfInstructor(ctx context.Context, in *ListStudentsOfInstructorRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Student], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &University_ServiceDesc.Streams[7], University_ListStudentsOfInstructor_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListStudentsOfInstructorRequest, Student]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListStudentsOfInstructorClient = grpc.ServerStreamingClient[Student]
+
+// UniversityServer is the server API for University service.
+// All implementations must embed UnimplementedUniversityServer
+// for forward compatibility.
+type UniversityServer interface {
+	CreateStudent(context.Context, *Student) (*Student, error)
+	GetStudent(context.Context, *IdRequest) (*Student, error)
+	ListStudents(*ListStudentsRequest, grpc.ServerStreamingServer[Student]) error
+	UpdateStudentAge(context.Context, *UpdateStudentAgeRequest) (*Student, error)
+	DeleteStudent(context.Context, *IdRequest) (*emptypb.Empty, error)
+	CreateCourse(context.Context, *Course) (*Course, error)
+	GetCourse(context.Context, *IdRequest) (*Course, error)
+	ListCourses(*ListCoursesRequest, grpc.ServerStreamingServer[Course]) error
+	UpdateCourse(context.Context, *UpdateCourseRequest) (*Course, error)
+	DeleteCourse(context.Context, *IdRequest) (*emptypb.Empty, error)
+	AssignInstructor(context.Context, *AssignInstructorRequest) (*Course, error)
+	UnassignInstructor(context.Context, *IdRequest) (*Course, error)
+	CreateDepartment(context.Context, *Department) (*Department, error)
+	GetDepartment(context.Context, *IdRequest) (*Department, error)
+	ListDepartments(*emptypb.Empty, grpc.ServerStreamingServer[Department]) error
+	UpdateDepartment(context.Context, *UpdateDepartmentRequest) (*Department, error)
+	DeleteDepartment(context.Context, *IdRequest) (*emptypb.Empty, error)
+	CreateInstructor(context.Context, *Instructor) (*Instructor, error)
+	GetInstructor(context.Context, *IdRequest) (*Instructor, error)
+	ListInstructors(*emptypb.Empty, grpc.ServerStreamingServer[Instructor]) error
+	UpdateInstructor(context.Context, *UpdateInstructorRequest) (*Instructor, error)
+	DeleteInstructor(context.Context, *IdRequest) (*emptypb.Empty, error)
+	EnrollStudent(context.Context, *Enrollment) (*emptypb.Empty, error)
+	ListStudentCourses(*IdRequest, grpc.ServerStreamingServer[Course]) error
+	ListCourseStudents(*IdRequest, grpc.ServerStreamingServer[Student]) error
+	GetStudentCountForEachDepartment(*emptypb.Empty, grpc.ServerStreamingServer[DepartmentStudentCount]) error
+	ListStudentsOfInstructor(*ListStudentsOfInstructorRequest, grpc.ServerStreamingServer[Student]) error
+	mustEmbedUnimplementedUniversityServer()
+}
+
+// UnimplementedUniversityServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUniversityServer struct{}
+
+func (UnimplementedUniversityServer) CreateStudent(context.Context, *Student) (*Student, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateStudent not implemented")
+}
+func (UnimplementedUniversityServer) GetStudent(context.Context, *IdRequest) (*Student, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStudent not implemented")
+}
+func (UnimplementedUniversityServer) ListStudents(*ListStudentsRequest, grpc.ServerStreamingServer[Student]) error {
+	return status.Error(codes.Unimplemented, "method ListStudents not implemented")
+}
+func (UnimplementedUniversityServer) UpdateStudentAge(context.Context, *UpdateStudentAgeRequest) (*Student, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateStudentAge not implemented")
+}
+func (UnimplementedUniversityServer) DeleteStudent(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteStudent not implemented")
+}
+func (UnimplementedUniversityServer) CreateCourse(context.Context, *Course) (*Course, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateCourse not implemented")
+}
+func (UnimplementedUniversityServer) GetCourse(context.Context, *IdRequest) (*Course, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCourse not implemented")
+}
+func (UnimplementedUniversityServer) ListCourses(*ListCoursesRequest, grpc.ServerStreamingServer[Course]) error {
+	return status.Error(codes.Unimplemented, "method ListCourses not implemented")
+}
+func (UnimplementedUniversityServer) UpdateCourse(context.Context, *UpdateCourseRequest) (*Course, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateCourse not implemented")
+}
+func (UnimplementedUniversityServer) DeleteCourse(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteCourse not implemented")
+}
+func (UnimplementedUniversityServer) AssignInstructor(context.Context, *AssignInstructorRequest) (*Course, error) {
+	return nil, status.Error(codes.Unimplemented, "method AssignInstructor not implemented")
+}
+func (UnimplementedUniversityServer) UnassignInstructor(context.Context, *IdRequest) (*Course, error) {
+	return nil, status.Error(codes.Unimplemented, "method UnassignInstructor not implemented")
+}
+func (UnimplementedUniversityServer) CreateDepartment(context.Context, *Department) (*Department, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateDepartment not implemented")
+}
+func (UnimplementedUniversityServer) GetDepartment(context.Context, *IdRequest) (*Department, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDepartment not implemented")
+}
+func (UnimplementedUniversityServer) ListDepartments(*emptypb.Empty, grpc.ServerStreamingServer[Department]) error {
+	return status.Error(codes.Unimplemented, "method ListDepartments not implemented")
+}
+func (UnimplementedUniversityServer) UpdateDepartment(context.Context, *UpdateDepartmentRequest) (*Department, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateDepartment not implemented")
+}
+func (UnimplementedUniversityServer) DeleteDepartment(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteDepartment not implemented")
+}
+func (UnimplementedUniversityServer) CreateInstructor(context.Context, *Instructor) (*Instructor, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateInstructor not implemented")
+}
+func (UnimplementedUniversityServer) GetInstructor(context.Context, *IdRequest) (*Instructor, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetInstructor not implemented")
+}
+func (UnimplementedUniversityServer) ListInstructors(*emptypb.Empty, grpc.ServerStreamingServer[Instructor]) error {
+	return status.Error(codes.Unimplemented, "method ListInstructors not implemented")
+}
+func (UnimplementedUniversityServer) UpdateInstructor(context.Context, *UpdateInstructorRequest) (*Instructor, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateInstructor not implemented")
+}
+func (UnimplementedUniversityServer) DeleteInstructor(context.Context, *IdRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteInstructor not implemented")
+}
+func (UnimplementedUniversityServer) EnrollStudent(context.Context, *Enrollment) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method EnrollStudent not implemented")
+}
+func (UnimplementedUniversityServer) ListStudentCourses(*IdRequest, grpc.ServerStreamingServer[Course]) error {
+	return status.Error(codes.Unimplemented, "method ListStudentCourses not implemented")
+}
+func (UnimplementedUniversityServer) ListCourseStudents(*IdRequest, grpc.ServerStreamingServer[Student]) error {
+	return status.Error(codes.Unimplemented, "method ListCourseStudents not implemented")
+}
+func (UnimplementedUniversityServer) GetStudentCountForEachDepartment(*emptypb.Empty, grpc.ServerStreamingServer[DepartmentStudentCount]) error {
+	return status.Error(codes.Unimplemented, "method GetStudentCountForEachDepartment not implemented")
+}
+func (UnimplementedUniversityServer) ListStudentsOfInstructor(*ListStudentsOfInstructorRequest, grpc.ServerStreamingServer[Student]) error {
+	return status.Error(codes.Unimplemented, "method ListStudentsOfInstructor not implemented")
+}
+func (UnimplementedUniversityServer) mustEmbedUnimplementedUniversityServer() {}
+func (UnimplementedUniversityServer) testEmbeddedByValue()                    {}
+
+// UnsafeUniversityServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UniversityServer will
+// result in compilation errors.
+type UnsafeUniversityServer interface {
+	mustEmbedUnimplementedUniversityServer()
+}
+
+func RegisterUniversityServer(s grpc.ServiceRegistrar, srv UniversityServer) {
+	// If the following call panics, it indicates UnimplementedUniversityServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&University_ServiceDesc, srv)
+}
+
+func _University_CreateStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Student)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).CreateStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_CreateStudent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).CreateStudent(ctx, req.(*Student))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_GetStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).GetStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_GetStudent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).GetStudent(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_ListStudents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListStudentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListStudents(m, &grpc.GenericServerStream[ListStudentsRequest, Student]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListStudentsServer = grpc.ServerStreamingServer[Student]
+
+func _University_UpdateStudentAge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStudentAgeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).UpdateStudentAge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_UpdateStudentAge_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).UpdateStudentAge(ctx, req.(*UpdateStudentAgeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_DeleteStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).DeleteStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_DeleteStudent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).DeleteStudent(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_CreateCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Course)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).CreateCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_CreateCourse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).CreateCourse(ctx, req.(*Course))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_GetCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).GetCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_GetCourse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).GetCourse(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_ListCourses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListCoursesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListCourses(m, &grpc.GenericServerStream[ListCoursesRequest, Course]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListCoursesServer = grpc.ServerStreamingServer[Course]
+
+func _University_UpdateCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCourseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).UpdateCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_UpdateCourse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).UpdateCourse(ctx, req.(*UpdateCourseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_DeleteCourse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).DeleteCourse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_DeleteCourse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).DeleteCourse(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_AssignInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignInstructorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).AssignInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_AssignInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).AssignInstructor(ctx, req.(*AssignInstructorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_UnassignInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).UnassignInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_UnassignInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).UnassignInstructor(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_CreateDepartment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Department)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).CreateDepartment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_CreateDepartment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).CreateDepartment(ctx, req.(*Department))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_GetDepartment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).GetDepartment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_GetDepartment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).GetDepartment(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_ListDepartments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListDepartments(m, &grpc.GenericServerStream[emptypb.Empty, Department]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListDepartmentsServer = grpc.ServerStreamingServer[Department]
+
+func _University_UpdateDepartment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDepartmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).UpdateDepartment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_UpdateDepartment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).UpdateDepartment(ctx, req.(*UpdateDepartmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_DeleteDepartment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).DeleteDepartment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_DeleteDepartment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).DeleteDepartment(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_CreateInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Instructor)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).CreateInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_CreateInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).CreateInstructor(ctx, req.(*Instructor))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_GetInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).GetInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_GetInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).GetInstructor(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_ListInstructors_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListInstructors(m, &grpc.GenericServerStream[emptypb.Empty, Instructor]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListInstructorsServer = grpc.ServerStreamingServer[Instructor]
+
+func _University_UpdateInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateInstructorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).UpdateInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_UpdateInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).UpdateInstructor(ctx, req.(*UpdateInstructorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_DeleteInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).DeleteInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_DeleteInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).DeleteInstructor(ctx, req.(*IdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_EnrollStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Enrollment)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UniversityServer).EnrollStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: University_EnrollStudent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UniversityServer).EnrollStudent(ctx, req.(*Enrollment))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _University_ListStudentCourses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IdRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListStudentCourses(m, &grpc.GenericServerStream[IdRequest, Course]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListStudentCoursesServer = grpc.ServerStreamingServer[Course]
+
+func _University_ListCourseStudents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IdRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListCourseStudents(m, &grpc.GenericServerStream[IdRequest, Student]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListCourseStudentsServer = grpc.ServerStreamingServer[Student]
+
+func _University_GetStudentCountForEachDepartment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).GetStudentCountForEachDepartment(m, &grpc.GenericServerStream[emptypb.Empty, DepartmentStudentCount]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_GetStudentCountForEachDepartmentServer = grpc.ServerStreamingServer[DepartmentStudentCount]
+
+func _University_ListStudentsOfInstructor_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListStudentsOfInstructorRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UniversityServer).ListStudentsOfInstructor(m, &grpc.GenericServerStream[ListStudentsOfInstructorRequest, Student]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type University_ListStudentsOfInstructorServer = grpc.ServerStreamingServer[Student]
+
+// University_ServiceDesc is the grpc.ServiceDesc for University service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var University_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "university.v1.University",
+	HandlerType: (*UniversityServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateStudent",
+			Handler:    _University_CreateStudent_Handler,
+		},
+		{
+			MethodName: "GetStudent",
+			Handler:    _University_GetStudent_Handler,
+		},
+		{
+			MethodName: "UpdateStudentAge",
+			Handler:    _University_UpdateStudentAge_Handler,
+		},
+		{
+			MethodName: "DeleteStudent",
+			Handler:    _University_DeleteStudent_Handler,
+		},
+		{
+			MethodName: "CreateCourse",
+			Handler:    _University_CreateCourse_Handler,
+		},
+		{
+			MethodName: "GetCourse",
+			Handler:    _University_GetCourse_Handler,
+		},
+		{
+			MethodName: "UpdateCourse",
+			Handler:    _University_UpdateCourse_Handler,
+		},
+		{
+			MethodName: "DeleteCourse",
+			Handler:    _University_DeleteCourse_Handler,
+		},
+		{
+			MethodName: "AssignInstructor",
+			Handler:    _University_AssignInstructor_Handler,
+		},
+		{
+			MethodName: "UnassignInstructor",
+			Handler:    _University_UnassignInstructor_Handler,
+		},
+		{
+			MethodName: "CreateDepartment",
+			Handler:    _University_CreateDepartment_Handler,
+		},
+		{
+			MethodName: "GetDepartment",
+			Handler:    _University_GetDepartment_Handler,
+		},
+		{
+			MethodName: "UpdateDepartment",
+			Handler:    _University_UpdateDepartment_Handler,
+		},
+		{
+			MethodName: "DeleteDepartment",
+			Handler:    _University_DeleteDepartment_Handler,
+		},
+		{
+			MethodName: "CreateInstructor",
+			Handler:    _University_CreateInstructor_Handler,
+		},
+		{
+			MethodName: "GetInstructor",
+			Handler:    _University_GetInstructor_Handler,
+		},
+		{
+			MethodName: "UpdateInstructor",
+			Handler:    _University_UpdateInstructor_Handler,
+		},
+		{
+			MethodName: "DeleteInstructor",
+			Handler:    _University_DeleteInstructor_Handler,
+		},
+		{
+			MethodName: "EnrollStudent",
+			Handler:    _University_EnrollStudent_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListStudents",
+			Handler:       _University_ListStudents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListCourses",
+			Handler:       _University_ListCourses_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListDepartments",
+			Handler:       _University_ListDepartments_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListInstructors",
+			Handler:       _University_ListInstructors_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListStudentCourses",
+			Handler:       _University_ListStudentCourses_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListCourseStudents",
+			Handler:       _University_ListCourseStudents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetStudentCountForEachDepartment",
+			Handler:       _University_GetStudentCountForEachDepartment_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListStudentsOfInstructor",
+			Handler:       _University_ListStudentsOfInstructor_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "university/v1/university.proto",
+}
@@ -0,0 +1,136 @@
+// Package report builds the statistics registrars ask for about departments,
+// students and courses.
+package report
+
+import (
+	"context"
+	"exercise1/db"
+	"sort"
+)
+
+type Department struct {
+	DepartmentId      uint
+	Name              string
+	StudentCount      uint
+	CourseCount       uint
+	InstructorCount   uint
+	AverageStudentAge float64
+}
+
+// Departments returns statistics for every department, including those
+// without any students, courses or instructors.
+func Departments(ctx context.Context) []Department {
+	var departments []Department
+	// A query on the model rather than raw SQL, so that it is scoped to the
+	// tenant; the rows counted belong to the department and thus its tenant.
+	db.Conn(ctx).Model(&db.Department{}).Select(`departments.id AS department_id, departments.name,
+		(SELECT COUNT(*) FROM students WHERE students.department_id = departments.id) AS student_count,
+		(SELECT COUNT(*) FROM courses WHERE courses.department_id = departments.id AND courses.deleted_at IS NULL) AS course_count,
+		(SELECT COUNT(*) FROM instructors WHERE instructors.department_id = departments.id) AS instructor_count,
+		(SELECT COALESCE(AVG(students.age), 0) FROM students WHERE students.department_id = departments.id) AS average_student_age`).
+		Order("departments.id").
+		Find(&departments)
+	return departments
+}
+
+type City struct {
+	// City is encrypted like that of the students.
+	City         string `gorm:"serializer:encrypted"`
+	StudentCount uint
+}
+
+// Cities returns how many students come from each city, largest first.
+// Cities are encrypted, so they are grouped by their blind index and sorted
+// once decrypted.
+func Cities(ctx context.Context) []City {
+	var cities []City
+	db.Conn(ctx).Model(&db.Student{}).Select("MIN(city) AS city, COUNT(*) AS student_count").
+		Group("city_index").
+		Find(&cities)
+	sort.Slice(cities, func(i, j int) bool {
+		if cities[i].StudentCount != cities[j].StudentCount {
+			return cities[i].StudentCount > cities[j].StudentCount
+		}
+		return cities[i].City < cities[j].City
+	})
+	return cities
+}
+
+type CourseEnrollment struct {
+	CourseId     uint
+	Name         string
+	StudentCount uint
+}
+
+// EnrollmentsPerCourse returns the number of enrolled students of every
+// course, including courses nobody is enrolled in.
+func EnrollmentsPerCourse(ctx context.Context) []CourseEnrollment {
+	var enrollments []CourseEnrollment
+	db.Conn(ctx).Model(&db.Course{}).Select("courses.id AS course_id, courses.name, COUNT(enrollments.student_id) AS student_count").
+		Joins("left join enrollments on enrollments.course_id = courses.id").
+		Group("courses.id, courses.name").
+		Order("courses.id").
+		Find(&enrollments)
+	return enrollments
+}
+
+func CoursesWithoutStudents(ctx context.Context) []db.Course {
+	var courses []db.Course
+	db.Conn(ctx).Where("NOT EXISTS (SELECT 1 FROM enrollments WHERE enrollments.course_id = courses.id)").
+		Order("id").
+		Find(&courses)
+	return courses
+}
+
+const (
+	WorkloadUnder = "under"
+	WorkloadOK    = "ok"
+	WorkloadOver  = "over"
+)
+
+// Instructors teaching less than this share of their maximum load are
+// reported as under-loaded.
+const underloadRatio = 0.5
+
+type Workload struct {
+	InstructorId uint
+	FullName     string
+	Term         string
+	CourseCount  uint
+	StudentCount uint
+	Load         uint
+	MaxLoad      uint
+	Status       string
+}
+
+// Workloads returns the teaching load of every instructor per term together
+// with their limit, flagging over- and under-loaded staff.
+func Workloads(ctx context.Context) []Workload {
+	instructors := map[uint]db.Instructor{}
+	for _, instructor := range db.FindAllInstructors(ctx) {
+		instructors[instructor.Id] = instructor
+	}
+
+	var workloads []Workload
+	for _, load := range db.GetTeachingLoads(ctx) {
+		instructor := instructors[load.InstructorId]
+		workload := Workload{
+			InstructorId: load.InstructorId,
+			FullName:     instructor.FullName,
+			Term:         load.Term,
+			CourseCount:  load.CourseCount,
+			StudentCount: load.StudentCount,
+			Load:         load.Load,
+			MaxLoad:      db.MaxTeachingLoad(ctx, instructor),
+			Status:       WorkloadOK,
+		}
+		switch {
+		case workload.Load > workload.MaxLoad:
+			workload.Status = WorkloadOver
+		case float64(workload.Load) < underloadRatio*float64(workload.MaxLoad):
+			workload.Status = WorkloadUnder
+		}
+		workloads = append(workloads, workload)
+	}
+	return workloads
+}
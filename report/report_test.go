@@ -0,0 +1,156 @@
+package report
+
+import (
+	"exercise1/db"
+	"exercise1/db/dbtest"
+	"testing"
+)
+
+func TestDepartments(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	busy, empty := f.Department(), f.Department()
+	f.Student(func(s *db.Student) { s.DepartmentId = &busy.Id; s.Age = 19 })
+	f.Student(func(s *db.Student) { s.DepartmentId = &busy.Id; s.Age = 22 })
+	f.Instructor(func(i *db.Instructor) { i.DepartmentId = busy.Id })
+	f.Course(func(c *db.Course) { c.DepartmentId = busy.Id })
+	deleted := f.Course(func(c *db.Course) { c.DepartmentId = busy.Id })
+	if err := db.DeleteCourse(f.Ctx, deleted); err != nil {
+		t.Fatalf("Deleting course %d failed: %v", deleted.Id, err)
+	}
+
+	expected := []Department{
+		{DepartmentId: busy.Id, Name: busy.Name, StudentCount: 2, CourseCount: 1, InstructorCount: 1, AverageStudentAge: 20.5},
+		{DepartmentId: empty.Id, Name: empty.Name},
+	}
+	actual := Departments(f.Ctx)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d departments, got %+v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("Expected department %+v, got %+v", expected[i], actual[i])
+		}
+	}
+}
+
+func TestCities(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	for _, city := range []string{"Astana", "Almaty", "Shymkent", "Almaty", "Astana", "Almaty"} {
+		f.Student(func(s *db.Student) { s.City = city })
+	}
+
+	expected := []City{{"Almaty", 3}, {"Astana", 2}, {"Shymkent", 1}}
+	actual := Cities(f.Ctx)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d cities, got %+v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("Expected city %+v at %d, got %+v", expected[i], i, actual[i])
+		}
+	}
+}
+
+func TestCitiesWithoutStudents(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	if cities := Cities(f.Ctx); len(cities) != 0 {
+		t.Fatalf("Expected no cities without students, got %+v", cities)
+	}
+}
+
+func TestEnrollmentsPerCourse(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	popular, unpopular := f.Course(), f.Course()
+	f.Enroll(f.Student(), popular)
+	f.Enroll(f.Student(), popular)
+
+	expected := []CourseEnrollment{
+		{CourseId: popular.Id, Name: popular.Name, StudentCount: 2},
+		{CourseId: unpopular.Id, Name: unpopular.Name},
+	}
+	actual := EnrollmentsPerCourse(f.Ctx)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d courses, got %+v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("Expected course %+v, got %+v", expected[i], actual[i])
+		}
+	}
+}
+
+func TestCoursesWithoutStudents(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	taken, first, second := f.Course(), f.Course(), f.Course()
+	student := f.Student()
+	f.Enroll(student, taken)
+	// A course whose only student dropped it has none left.
+	f.Enroll(student, second)
+	if err := db.DropStudentFromCourse(f.Ctx, student.Id, second.Id); err != nil {
+		t.Fatalf("Dropping student %d from course %d failed: %v", student.Id, second.Id, err)
+	}
+
+	courses := CoursesWithoutStudents(f.Ctx)
+	if len(courses) != 2 || courses[0].Id != first.Id || courses[1].Id != second.Id {
+		t.Fatalf("Expected courses %d and %d without students, got %+v", first.Id, second.Id, courses)
+	}
+}
+
+func TestWorkloads(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	// Courses have 5 credits and the default limit is 20, so the first
+	// instructor is under-loaded with one course, the second has what
+	// their limit allows and the third is over a limit lowered since.
+	under := f.Instructor()
+	limit := uint(10)
+	full := f.Instructor(func(i *db.Instructor) { i.MaxTeachingLoad = &limit })
+	over := f.Instructor()
+	idle := f.Instructor()
+	for _, instructor := range []db.Instructor{under, full, full, over, over} {
+		f.Course(func(c *db.Course) { c.DepartmentId = instructor.DepartmentId; c.InstructorId = &instructor.Id })
+	}
+	f.Enroll(f.Student(), db.FindAllCoursesByInstructorId(f.Ctx, under.Id)[0])
+	if err := db.Conn(f.Ctx).Exec("UPDATE instructors SET max_teaching_load = 8 WHERE id = ?", over.Id).Error; err != nil {
+		t.Fatalf("Lowering the limit of instructor %d failed: %v", over.Id, err)
+	}
+
+	expected := map[uint]Workload{
+		under.Id: {CourseCount: 1, StudentCount: 1, Load: 5, MaxLoad: db.DefaultMaxTeachingLoad, Status: WorkloadUnder},
+		full.Id:  {CourseCount: 2, Load: 10, MaxLoad: 10, Status: WorkloadOK},
+		over.Id:  {CourseCount: 2, Load: 10, MaxLoad: 8, Status: WorkloadOver},
+		idle.Id:  {MaxLoad: db.DefaultMaxTeachingLoad, Status: WorkloadUnder},
+	}
+	workloads := Workloads(f.Ctx)
+	if len(workloads) != len(expected) {
+		t.Fatalf("Expected a workload for each of the %d instructors in the one term, got %+v", len(expected), workloads)
+	}
+	for _, workload := range workloads {
+		want := expected[workload.InstructorId]
+		want.InstructorId, want.FullName, want.Term = workload.InstructorId, db.FindInstructorById(f.Ctx, int(workload.InstructorId)).FullName, "2024-fall"
+		if workload != want {
+			t.Fatalf("Expected workload %+v, got %+v", want, workload)
+		}
+	}
+}
+
+func TestWorkloadsWithoutCourses(t *testing.T) {
+	t.Parallel()
+	f := dbtest.New(t)
+
+	f.Instructor()
+	if workloads := Workloads(f.Ctx); len(workloads) != 0 {
+		t.Fatalf("Expected no workloads without a term with courses, got %+v", workloads)
+	}
+}
@@ -0,0 +1,177 @@
+package seed
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// The names and cities are listed most common first, see generator.skewed
+// and weighted.
+var (
+	firstNames = []string{
+		"Nurlan", "Aigerim", "Askar", "Dana", "Yerlan", "Aruzhan", "Daniyar", "Madina", "Ramazan", "Aliya",
+		"Timur", "Kamila", "Nursultan", "Zhanna", "Arman", "Saule", "Bekzat", "Dinara", "Alikhan", "Aisha",
+		"Ivan", "Anna", "Dmitry", "Elena", "Sergey", "Olga", "Maxim", "Natalia", "Alexander", "Maria",
+		"Nurdaulet", "Togzhan", "Miras", "Asel", "Yerassyl", "Gulnara", "Sanzhar", "Ainur", "Zhandos", "Laura",
+		"David", "Sofia", "Omar", "Leyla", "Chen", "Mei", "Lucas", "Emma", "Mehmet", "Zeynep",
+	}
+	lastNames = []string{
+		"Akhmetov", "Omarova", "Bekbergen", "Nurlanov", "Seitkali", "Zhakupova", "Kassymov", "Abenova", "Mamyrbek", "Tulegenov",
+		"Ivanov", "Petrova", "Smirnov", "Kuznetsova", "Sokolov", "Popova", "Agabek", "Suleimenov", "Iskakova", "Dzhaksybekov",
+		"Sadykov", "Baimukhanova", "Yesenov", "Karimova", "Tokayev", "Zhumabayeva", "Ospanov", "Kenzhebekova", "Tagvay", "Abdrakhmanov",
+		"Kim", "Li", "Park", "Novak", "Schmidt", "Yilmaz", "O'Brien", "Garcia", "Rossi", "Dubois",
+	}
+	cities = []weightedValue{
+		{"Almaty", 2200}, {"Astana", 1400}, {"Shymkent", 1200}, {"Aktobe", 560}, {"Karaganda", 500},
+		{"Taraz", 430}, {"Pavlodar", 360}, {"Semey", 350}, {"Oskemen", 340}, {"Atyrau", 300},
+		{"Kostanay", 260}, {"Kyzylorda", 250}, {"Uralsk", 240}, {"Petropavl", 220}, {"Aktau", 200},
+		{"Turkistan", 190}, {"Taldykorgan", 170}, {"Kokshetau", 150}, {"Ekibastuz", 130}, {"Kaskelen", 80},
+		{"Bishkek", 60}, {"Tashkent", 60}, {"Moscow", 40}, {"Istanbul", 20}, {"New York", 10},
+	}
+
+	schoolNames  = []string{"Sciences", "Engineering", "Humanities", "Social Sciences", "Medicine", "Business"}
+	facultyNames = []string{"Mathematics and Computing", "Natural Sciences", "Mechanical Engineering", "Civil Engineering", "Languages", "History and Philosophy", "Economics", "Law", "Health Sciences", "Arts"}
+	subjects     = []string{
+		"Computer Science", "Mathematics", "Statistics", "Physics", "Chemistry", "Biology", "Geology", "Astronomy",
+		"Mechanical Engineering", "Electrical Engineering", "Civil Engineering", "Chemical Engineering", "Architecture",
+		"Linguistics", "Kazakh Philology", "English Literature", "Translation Studies", "History", "Philosophy", "Archaeology",
+		"Economics", "Finance", "Management", "Marketing", "Sociology", "Psychology", "Political Science", "International Relations",
+		"Law", "Public Health", "Nursing", "Pharmacy", "Music", "Fine Arts", "Journalism", "Education",
+	}
+	courseNames = []string{"Introduction to %s", "Foundations of %s", "%s Methods", "Advanced %s", "Topics in %s", "%s Seminar", "%s Laboratory", "%s Project"}
+
+	citySums = cumulative(cities)
+
+	creditValues = []uint{5, 3, 4, 6}
+	creditSums   = sums([]float64{35, 30, 20, 15})
+
+	terms = []string{"2024-fall", "2025-spring"}
+)
+
+type weightedValue struct {
+	value  string
+	weight float64
+}
+
+// generator makes the random choices of a seed run. Every choice is drawn
+// from one source in a fixed order, so the same seed always generates the
+// same data.
+type generator struct {
+	r *rand.Rand
+}
+
+func newGenerator(seed int64) *generator {
+	return &generator{r: rand.New(rand.NewSource(seed))}
+}
+
+// skewed returns an index below n, lower ones being more likely, so that the
+// first entries of a list come up most often.
+func (g *generator) skewed(n int) int {
+	u := g.r.Float64()
+	return int(float64(n) * u * u)
+}
+
+func (g *generator) name() string {
+	return firstNames[g.skewed(len(firstNames))] + " " + lastNames[g.skewed(len(lastNames))]
+}
+
+func (g *generator) city() string {
+	return cities[g.weighted(citySums)].value
+}
+
+// studentAge is mostly around 20, with a tail of mature students.
+func (g *generator) studentAge() uint {
+	if g.r.Float64() < 0.07 {
+		return uint(25 + g.r.Intn(21))
+	}
+	return uint(clamp(math.Round(19.5+1.6*g.r.NormFloat64()), 17, 26))
+}
+
+func (g *generator) instructorAge() uint {
+	return uint(clamp(math.Round(46+10*g.r.NormFloat64()), 26, 70))
+}
+
+// size returns the relative size of a department; a few are much larger
+// than the rest, as in a real university.
+func (g *generator) size() float64 {
+	return math.Exp(0.6 * g.r.NormFloat64())
+}
+
+// capacity is how many students may enroll in a course.
+func (g *generator) capacity() int {
+	return int(clamp(math.Round(60*math.Exp(0.5*g.r.NormFloat64())), 12, 240))
+}
+
+func (g *generator) credits() uint {
+	return creditValues[g.weighted(creditSums)]
+}
+
+// weighted returns an index into the weights summed up by cumulative.
+func (g *generator) weighted(sums []float64) int {
+	return sort.SearchFloat64s(sums, g.r.Float64()*sums[len(sums)-1])
+}
+
+func cumulative(values []weightedValue) []float64 {
+	weights := make([]float64, len(values))
+	for i, value := range values {
+		weights[i] = value.weight
+	}
+	return sums(weights)
+}
+
+func sums(weights []float64) []float64 {
+	sums := make([]float64, len(weights))
+	total := 0.0
+	for i, weight := range weights {
+		total += weight
+		sums[i] = total
+	}
+	return sums
+}
+
+// allocate divides total among the weights in proportion, giving each at
+// least one as long as there are enough.
+func allocate(total int, weights []float64) []int {
+	counts := make([]int, len(weights))
+	if len(weights) == 0 {
+		return counts
+	}
+	base := 0
+	if total >= len(weights) {
+		base = 1
+	}
+	rest := total - base*len(weights)
+	sum := sums(weights)[len(weights)-1]
+
+	order := make([]int, len(weights))
+	fractions := make([]float64, len(weights))
+	assigned := 0
+	for i, weight := range weights {
+		share := float64(rest) * weight / sum
+		counts[i] = base + int(share)
+		assigned += int(share)
+		order[i], fractions[i] = i, share-math.Floor(share)
+	}
+	// The largest remainders get what rounding down left over.
+	sort.SliceStable(order, func(a, b int) bool { return fractions[order[a]] > fractions[order[b]] })
+	for _, i := range order[:max(0, rest-assigned)] {
+		counts[i]++
+	}
+	return counts
+}
+
+// numbered returns the n-th value of values, numbering them once they run
+// out, e.g. "Physics 2".
+func numbered(values []string, n int) string {
+	value := values[n%len(values)]
+	if round := n / len(values); round > 0 {
+		value += " " + strconv.Itoa(round+1)
+	}
+	return value
+}
+
+func clamp(value, low, high float64) float64 {
+	return math.Max(low, math.Min(high, value))
+}
@@ -0,0 +1,311 @@
+// Package seed fills an empty database with generated departments,
+// instructors, courses, students and enrollments, for development and load
+// tests. The data is drawn from a fixed seed, so the same Config always
+// generates the same rows, up to their ids and timestamps.
+//
+// Rows are inserted in bulk, bypassing the validation of the db package, so
+// everything generated keeps to its rules by construction: names and ages
+// are in range, courses are taught within their department and no
+// instructor gets more than their maximum teaching load, counting the
+// students enrolled.
+package seed
+
+import (
+	"context"
+	"errors"
+	"exercise1/db"
+	"fmt"
+)
+
+var ErrNotEmpty = errors.New("the database already has departments; seed an empty one")
+
+// Config is the scale of the generated data.
+type Config struct {
+	Seed int64
+
+	// Departments is the number of departments students belong to; the
+	// faculties and schools above them come on top.
+	Departments int
+	Instructors int
+	Courses     int
+	Students    int
+	// CoursesPerStudent is the average number of courses a student is
+	// enrolled in. Full courses can leave a student with fewer.
+	CoursesPerStudent int
+
+	// BatchSize is the number of rows inserted per statement.
+	BatchSize int
+}
+
+// Scaled returns the configuration for the given number of students, with
+// the other counts in proportion.
+func Scaled(students int) Config {
+	return Config{
+		Seed:              1,
+		Departments:       max(3, students/1000),
+		Instructors:       max(3, students/20),
+		Courses:           max(3, students/15),
+		Students:          students,
+		CoursesPerStudent: 4,
+		BatchSize:         1000,
+	}
+}
+
+// Validate checks that the counts make sense.
+func (c Config) Validate() error {
+	switch {
+	case c.Departments < 1:
+		return errors.New("at least one department is needed")
+	case c.Instructors < 0 || c.Courses < 0 || c.Students < 0 || c.CoursesPerStudent < 0:
+		return errors.New("counts cannot be negative")
+	case c.BatchSize < 1:
+		return errors.New("the batch size must be at least 1")
+	}
+	return nil
+}
+
+// department is a generated department with what is needed to fill it.
+type department struct {
+	id          uint
+	name        string
+	size        float64
+	instructors []int
+	courses     []int
+}
+
+// course is a generated course with the room it has left.
+type course struct {
+	id       uint
+	capacity int
+}
+
+// Run generates the data of cfg into the database, in the tenant of ctx if
+// it has one, and calls progress after each batch it inserts. Everything is
+// inserted in one transaction, so a run that fails or is interrupted leaves
+// the database empty to seed again.
+func Run(ctx context.Context, cfg Config, progress func(table string, rows int)) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return db.Transaction(ctx, func(ctx context.Context) error {
+		if len(db.FindAllDepartments(ctx)) > 0 {
+			return ErrNotEmpty
+		}
+		g := newGenerator(cfg.Seed)
+
+		departments, err := createDepartments(ctx, g, cfg, progress)
+		if err != nil {
+			return err
+		}
+		instructors, err := createInstructors(ctx, g, cfg, departments, progress)
+		if err != nil {
+			return err
+		}
+		courses, err := createCourses(ctx, g, cfg, departments, instructors, progress)
+		if err != nil {
+			return err
+		}
+		return createStudents(ctx, g, cfg, departments, courses, progress)
+	})
+}
+
+// createDepartments creates the departments under faculties of about four
+// departments, and the faculties under schools of about three faculties.
+func createDepartments(ctx context.Context, g *generator, cfg Config, progress func(string, int)) ([]department, error) {
+	faculties := (cfg.Departments + 3) / 4
+	schools := (faculties + 2) / 3
+
+	levels := []struct {
+		kind  string
+		count int
+		name  func(i int) string
+	}{
+		{db.KindSchool, schools, func(i int) string { return "School of " + numbered(schoolNames, i) }},
+		{db.KindFaculty, faculties, func(i int) string { return "Faculty of " + numbered(facultyNames, i) }},
+		{db.KindDepartment, cfg.Departments, func(i int) string { return numbered(subjects, i) }},
+	}
+	var parents []db.Department
+	for _, level := range levels {
+		rows := make([]db.Department, level.count)
+		for i := range rows {
+			rows[i] = db.Department{Name: level.name(i), Kind: level.kind}
+			if len(parents) > 0 {
+				rows[i].ParentId = &parents[i%len(parents)].Id
+			}
+		}
+		if err := db.CreateInBatches(ctx, rows, cfg.BatchSize); err != nil {
+			return nil, fmt.Errorf("failed to create departments: %w", err)
+		}
+		progress("departments", len(rows))
+		parents = rows
+	}
+
+	departments := make([]department, len(parents))
+	for i, row := range parents {
+		departments[i] = department{id: row.Id, name: row.Name, size: g.size()}
+	}
+	return departments, nil
+}
+
+func createInstructors(ctx context.Context, g *generator, cfg Config, departments []department, progress func(string, int)) ([]db.Instructor, error) {
+	var instructors []db.Instructor
+	for i, count := range allocate(cfg.Instructors, sizes(departments)) {
+		for ; count > 0; count-- {
+			instructor := db.Instructor{FullName: g.name(), Age: g.instructorAge(), DepartmentId: departments[i].id}
+			// A few have a limit of their own.
+			if g.r.Float64() < 0.1 {
+				limit := uint(12 + 4*g.r.Intn(4))
+				instructor.MaxTeachingLoad = &limit
+			}
+			departments[i].instructors = append(departments[i].instructors, len(instructors))
+			instructors = append(instructors, instructor)
+		}
+	}
+	if err := db.CreateInBatches(ctx, instructors, cfg.BatchSize); err != nil {
+		return nil, fmt.Errorf("failed to create instructors: %w", err)
+	}
+	progress("instructors", len(instructors))
+	return instructors, nil
+}
+
+// createCourses creates the courses of every department and has them taught
+// by instructors of the department with room in their teaching load for the
+// course when full. Courses no instructor has room for stay unassigned.
+// Large courses get a teaching assistant if another instructor of the
+// department has room for them, as assistants count towards the load too.
+func createCourses(ctx context.Context, g *generator, cfg Config, departments []department, instructors []db.Instructor, progress func(string, int)) ([]course, error) {
+	// loads holds the load each instructor has left per term.
+	loads := make([][]uint, len(instructors))
+	for i, instructor := range instructors {
+		limit := uint(db.DefaultMaxTeachingLoad)
+		if instructor.MaxTeachingLoad != nil {
+			limit = *instructor.MaxTeachingLoad
+		}
+		loads[i] = make([]uint, len(terms))
+		for term := range terms {
+			loads[i][term] = limit
+		}
+	}
+
+	var rows []db.Course
+	var courses []course
+	// staff holds the teaching assistant of each course, -1 for none.
+	var staff []int
+	for i, count := range allocate(cfg.Courses, sizes(departments)) {
+		d := &departments[i]
+		for n := 0; n < count; n++ {
+			row := db.Course{
+				Name:         fmt.Sprintf(courseNames[n%len(courseNames)], d.name),
+				DepartmentId: d.id,
+				Credits:      g.credits(),
+			}
+			term := g.r.Intn(len(terms))
+			row.Term = terms[term]
+			capacity := g.capacity()
+			load := db.CourseLoad(row.Credits, uint(capacity))
+
+			teacher := -1
+			for _, k := range g.r.Perm(len(d.instructors)) {
+				if candidate := d.instructors[k]; loads[candidate][term] >= load {
+					teacher = candidate
+					break
+				}
+			}
+			if teacher >= 0 {
+				loads[teacher][term] -= load
+				row.InstructorId = &instructors[teacher].Id
+			}
+			assistant := -1
+			if capacity >= 90 {
+				for _, k := range g.r.Perm(len(d.instructors)) {
+					if candidate := d.instructors[k]; candidate != teacher && loads[candidate][term] >= load {
+						assistant = candidate
+						break
+					}
+				}
+			}
+			if assistant >= 0 {
+				loads[assistant][term] -= load
+			}
+
+			d.courses = append(d.courses, len(rows))
+			rows = append(rows, row)
+			courses = append(courses, course{capacity: capacity})
+			staff = append(staff, assistant)
+		}
+	}
+	if err := db.CreateInBatches(ctx, rows, cfg.BatchSize); err != nil {
+		return nil, fmt.Errorf("failed to create courses: %w", err)
+	}
+	progress("courses", len(rows))
+
+	var assistants []db.CourseStaff
+	for i, row := range rows {
+		courses[i].id = row.Id
+		if staff[i] >= 0 {
+			assistants = append(assistants, db.CourseStaff{CourseId: row.Id, InstructorId: instructors[staff[i]].Id, Role: db.RoleTeachingAssistant})
+		}
+	}
+	if err := db.CreateInBatches(ctx, assistants, cfg.BatchSize); err != nil {
+		return nil, fmt.Errorf("failed to create course staff: %w", err)
+	}
+	progress("course_staffs", len(assistants))
+	return courses, nil
+}
+
+// createStudents creates the students batch by batch, each batch with its
+// enrollments, so that millions of them never have to be held in memory.
+// Students mostly take courses of their own department.
+func createStudents(ctx context.Context, g *generator, cfg Config, departments []department, courses []course, progress func(string, int)) error {
+	departmentSums := sums(sizes(departments))
+	for start := 0; start < cfg.Students; start += cfg.BatchSize {
+		students := make([]db.Student, min(cfg.BatchSize, cfg.Students-start))
+		homes := make([]int, len(students))
+		for i := range students {
+			homes[i] = g.weighted(departmentSums)
+			students[i] = db.Student{FullName: g.name(), Age: g.studentAge(), City: g.city(), DepartmentId: &departments[homes[i]].id}
+		}
+		if err := db.CreateInBatches(ctx, students, cfg.BatchSize); err != nil {
+			return fmt.Errorf("failed to create students: %w", err)
+		}
+		progress("students", len(students))
+
+		var enrollments []db.Enrollment
+		for i, student := range students {
+			wanted := 0
+			if cfg.CoursesPerStudent > 0 {
+				wanted = max(1, cfg.CoursesPerStudent+g.r.Intn(3)-1)
+			}
+			taken := map[int]bool{}
+			for attempt := 0; len(taken) < wanted && attempt < 4*wanted; attempt++ {
+				d := departments[homes[i]]
+				if g.r.Float64() < 0.15 {
+					d = departments[g.weighted(departmentSums)]
+				}
+				if len(d.courses) == 0 {
+					continue
+				}
+				k := d.courses[g.r.Intn(len(d.courses))]
+				if taken[k] || courses[k].capacity == 0 {
+					continue
+				}
+				taken[k] = true
+				courses[k].capacity--
+				enrollments = append(enrollments, db.Enrollment{StudentId: student.Id, CourseId: courses[k].id})
+			}
+		}
+		if err := db.CreateInBatches(ctx, enrollments, cfg.BatchSize); err != nil {
+			return fmt.Errorf("failed to create enrollments: %w", err)
+		}
+		progress("enrollments", len(enrollments))
+	}
+	return nil
+}
+
+func sizes(departments []department) []float64 {
+	sizes := make([]float64, len(departments))
+	for i, d := range departments {
+		sizes[i] = d.size
+	}
+	return sizes
+}
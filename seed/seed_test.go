@@ -0,0 +1,91 @@
+package seed
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGeneratorIsDeterministic(t *testing.T) {
+	draw := func(seed int64) []interface{} {
+		g := newGenerator(seed)
+		var values []interface{}
+		for i := 0; i < 100; i++ {
+			values = append(values, g.name(), g.city(), g.studentAge(), g.instructorAge(), g.credits(), g.capacity())
+		}
+		return values
+	}
+	first, second, other := draw(42), draw(42), draw(43)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected the same seed to draw the same values, value %d differs: %v and %v", i, first[i], second[i])
+		}
+	}
+	same := 0
+	for i := range first {
+		if first[i] == other[i] {
+			same++
+		}
+	}
+	if same == len(first) {
+		t.Errorf("expected another seed to draw other values")
+	}
+}
+
+// personName is the rule of db.studentSchema and db.instructorSchema.
+var personName = regexp.MustCompile(`^[\p{L}][\p{L} .'-]*$`)
+
+func TestGeneratedValuesKeepToTheModelRules(t *testing.T) {
+	g := newGenerator(1)
+	ages := map[uint]int{}
+	for i := 0; i < 10000; i++ {
+		if name := g.name(); !personName.MatchString(name) || len(name) > 100 {
+			t.Fatalf("invalid name %q", name)
+		}
+		age := g.studentAge()
+		if age < 16 || age > 100 {
+			t.Fatalf("student age %d out of range", age)
+		}
+		ages[age]++
+		if age := g.instructorAge(); age < 18 || age > 100 {
+			t.Fatalf("instructor age %d out of range", age)
+		}
+		if credits := g.credits(); credits > 30 {
+			t.Fatalf("credits %d out of range", credits)
+		}
+	}
+	if ages[19]+ages[20] < ages[25]+ages[26]+ages[30] {
+		t.Errorf("expected most students to be around 20, got %v", ages)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		total    int
+		weights  []float64
+		expected []int
+	}{
+		{10, []float64{1, 1}, []int{5, 5}},
+		{10, []float64{3, 1}, []int{7, 3}},
+		{3, []float64{100, 1, 1}, []int{1, 1, 1}},
+		{2, []float64{1, 1, 1}, []int{1, 1, 0}},
+		{0, []float64{1}, []int{0}},
+	}
+	for _, test := range tests {
+		actual := allocate(test.total, test.weights)
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("allocate(%d, %v): expected %v, got %v", test.total, test.weights, test.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestNumbered(t *testing.T) {
+	values := []string{"Physics", "History"}
+	for n, expected := range []string{"Physics", "History", "Physics 2", "History 2", "Physics 3"} {
+		if actual := numbered(values, n); actual != expected {
+			t.Errorf("numbered(%d): expected %q, got %q", n, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"exercise1/access"
+	"exercise1/db"
+)
+
+func CreateCourse(ctx context.Context, course db.Course) (db.Course, error) {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageCourse(course) }); err != nil {
+		return db.Course{}, err
+	}
+	var created db.Course
+	err := scoped(ctx, func(ctx context.Context) (err error) {
+		created, err = db.CreateCourse(ctx, course)
+		return err
+	})
+	return created, err
+}
+
+// UpdateCourse checks the course before and after the change, so department
+// admins cannot move courses out of their department.
+func UpdateCourse(ctx context.Context, course db.Course, courseWithUpdatedFields db.Course) error {
+	updated := course
+	if courseWithUpdatedFields.DepartmentId != 0 {
+		updated.DepartmentId = courseWithUpdatedFields.DepartmentId
+	}
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageCourse(course) && p.CanManageCourse(updated) }); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.UpdateCourse(ctx, course, courseWithUpdatedFields) })
+}
+
+func DeleteCourse(ctx context.Context, course db.Course) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageCourse(course) }); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.DeleteCourse(ctx, course) })
+}
+
+func AssignInstructor(ctx context.Context, courseId, instructorId uint) error {
+	if err := checkCourse(ctx, courseId); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.AssignInstructor(ctx, courseId, instructorId) })
+}
+
+func UnassignInstructor(ctx context.Context, courseId uint) error {
+	if err := checkCourse(ctx, courseId); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.UnassignInstructor(ctx, courseId) })
+}
+
+func checkCourse(ctx context.Context, courseId uint) error {
+	course := db.FindCourseById(ctx, int(courseId))
+	return check(ctx, func(p *access.Policy) bool { return p.CanManageCourse(course) })
+}
+
+func CreateDepartment(ctx context.Context, department db.Department) (db.Department, error) {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanCreateOrDeleteDepartment(department) }); err != nil {
+		return db.Department{}, err
+	}
+	return db.CreateDepartment(ctx, department)
+}
+
+func UpdateDepartment(ctx context.Context, department db.Department, departmentWithUpdatedFields db.Department) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageDepartment(department) }); err != nil {
+		return err
+	}
+	return db.UpdateDepartment(ctx, department, departmentWithUpdatedFields)
+}
+
+func DeleteDepartment(ctx context.Context, department db.Department) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanCreateOrDeleteDepartment(department) }); err != nil {
+		return err
+	}
+	return db.DeleteDepartment(ctx, department)
+}
+
+func CreateInstructor(ctx context.Context, instructor db.Instructor) (db.Instructor, error) {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageInstructor(instructor) }); err != nil {
+		return db.Instructor{}, err
+	}
+	var created db.Instructor
+	err := scoped(ctx, func(ctx context.Context) (err error) {
+		created, err = db.CreateInstructor(ctx, instructor)
+		return err
+	})
+	return created, err
+}
+
+// UpdateInstructor checks the instructor before and after the change, so
+// department admins cannot move instructors out of their department.
+func UpdateInstructor(ctx context.Context, instructor db.Instructor, instructorWithUpdatedFields db.Instructor) error {
+	updated := instructor
+	if instructorWithUpdatedFields.DepartmentId != 0 {
+		updated.DepartmentId = instructorWithUpdatedFields.DepartmentId
+	}
+	if err := check(ctx, func(p *access.Policy) bool {
+		return p.CanManageInstructor(instructor) && p.CanManageInstructor(updated)
+	}); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error {
+		return db.UpdateInstructor(ctx, instructor, instructorWithUpdatedFields)
+	})
+}
+
+func DeleteInstructor(ctx context.Context, instructor db.Instructor) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageInstructor(instructor) }); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.DeleteInstructor(ctx, instructor) })
+}
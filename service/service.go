@@ -0,0 +1,94 @@
+// Package service is what the HTTP, gRPC and GraphQL servers read and change
+// the university through. It checks the policies of the access package for
+// the principal of the request and leaves the rest to the db package.
+//
+// Courses, departments and instructors are read from the db package
+// directly, as every user may see them. Departments are not under row-level
+// security, so their changes are not scoped.
+package service
+
+import (
+	"context"
+	"exercise1/access"
+	"exercise1/db"
+	"sync"
+)
+
+type policyKey struct{}
+
+// WithPolicy returns a context that remembers the decisions of the policy of
+// its principal, so requests asking many questions, like GraphQL queries,
+// look up the relations of the principal only once.
+func WithPolicy(ctx context.Context) context.Context {
+	p, ok := access.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, policyKey{}, &lockedPolicy{policy: access.NewPolicy(p, relations{ctx})})
+}
+
+// lockedPolicy lets the resolvers of one request share a policy.
+type lockedPolicy struct {
+	sync.Mutex
+	policy *access.Policy
+}
+
+// check runs decide with the policy of the principal of ctx and returns
+// access.ErrForbidden when it says no. Requests without a principal are
+// always forbidden.
+func check(ctx context.Context, decide func(p *access.Policy) bool) error {
+	if shared, ok := ctx.Value(policyKey{}).(*lockedPolicy); ok {
+		shared.Lock()
+		defer shared.Unlock()
+		if !decide(shared.policy) {
+			return access.ErrForbidden
+		}
+		return nil
+	}
+	p, ok := access.FromContext(ctx)
+	if !ok || !decide(access.NewPolicy(p, relations{ctx})) {
+		return access.ErrForbidden
+	}
+	return nil
+}
+
+// scoped runs fn with a context in which the database itself, by row-level
+// security, limits the queries of a department admin to their departments,
+// in case a check of this package misses something. The queries of other
+// principals see every department, see db.AllDepartments.
+func scoped(ctx context.Context, fn func(ctx context.Context) error) error {
+	p, ok := access.FromContext(ctx)
+	if !ok || p.Role != access.DepartmentAdmin || p.DepartmentId == nil {
+		return db.AllDepartments(ctx, fn)
+	}
+	return db.WithDepartment(ctx, *p.DepartmentId, fn)
+}
+
+// relations looks up what the policies need in the database.
+type relations struct {
+	ctx context.Context
+}
+
+// StudentsOf counts every course the instructor has a staff role in, not
+// only those they are the primary instructor of.
+func (r relations) StudentsOf(instructorId uint) map[uint]bool {
+	ids := map[uint]bool{}
+	for _, student := range db.GetStudentsOfInstructor(r.ctx, instructorId, db.StaffRoles...) {
+		ids[student.Id] = true
+	}
+	return ids
+}
+
+func (r relations) Subtree(departmentId uint) map[uint]bool {
+	ids := map[uint]bool{}
+	for _, department := range db.FindDepartmentSubtree(r.ctx, departmentId) {
+		ids[department.Id] = true
+	}
+	return ids
+}
+
+// AuthorizeReports returns access.ErrForbidden unless the principal may see
+// the reports.
+func AuthorizeReports(ctx context.Context) error {
+	return check(ctx, func(p *access.Policy) bool { return p.CanViewReports() })
+}
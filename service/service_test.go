@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"exercise1/access"
+	"exercise1/db"
+	"testing"
+)
+
+func TestRequestsWithoutPrincipalAreForbidden(t *testing.T) {
+	if _, err := VisibleStudents(context.Background(), []db.Student{{Id: 1}}); !errors.Is(err, access.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden without principal, got %v", err)
+	}
+	if err := AuthorizeReports(WithPolicy(context.Background())); !errors.Is(err, access.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden without principal, got %v", err)
+	}
+}
+
+func TestSharedPolicy(t *testing.T) {
+	ctx := WithPolicy(access.WithPrincipal(context.Background(), access.Principal{Role: access.Student, StudentId: new(uint)}))
+	visible, err := VisibleStudents(ctx, []db.Student{{Id: 0}, {Id: 1}})
+	if err != nil || len(visible) != 1 {
+		t.Fatalf("expected the student to see only themselves, got %v, %v", visible, err)
+	}
+	if err := AuthorizeReports(ctx); !errors.Is(err, access.ErrForbidden) {
+		t.Fatalf("expected students not to see reports, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"exercise1/access"
+	"exercise1/db"
+	"time"
+)
+
+// VisibleStudents returns the students the principal may see.
+func VisibleStudents(ctx context.Context, students []db.Student) ([]db.Student, error) {
+	var visible []db.Student
+	err := check(ctx, func(p *access.Policy) bool {
+		visible = p.VisibleStudents(students)
+		return true
+	})
+	return visible, err
+}
+
+func FindAllStudents(ctx context.Context) ([]db.Student, error) {
+	var students []db.Student
+	if err := scoped(ctx, func(ctx context.Context) error {
+		students = db.FindAllStudents(ctx)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return VisibleStudents(ctx, students)
+}
+
+func FindAllStudentsByDepartmentId(ctx context.Context, departmentId uint) ([]db.Student, error) {
+	var students []db.Student
+	if err := scoped(ctx, func(ctx context.Context) error {
+		students = db.FindAllStudentsByDepartmentId(ctx, departmentId)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return VisibleStudents(ctx, students)
+}
+
+// FindStudentById returns the student with id, or a zero Student when there
+// is none. A student the principal may not see returns access.ErrForbidden.
+func FindStudentById(ctx context.Context, id int) (db.Student, error) {
+	student := db.FindStudentById(ctx, id)
+	if student.Id == 0 {
+		return student, nil
+	}
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanViewStudent(student) }); err != nil {
+		return db.Student{}, err
+	}
+	return student, nil
+}
+
+// GetStudentEnrolledCoursesByStudentId returns the courses of a student the
+// principal may see.
+func GetStudentEnrolledCoursesByStudentId(ctx context.Context, student db.Student) ([]db.Course, error) {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanViewStudent(student) }); err != nil {
+		return nil, err
+	}
+	return db.GetStudentEnrolledCoursesByStudentId(ctx, student.Id), nil
+}
+
+// GetCourseEnrolledStudentsByCourseId returns the students of the course the
+// principal may see.
+func GetCourseEnrolledStudentsByCourseId(ctx context.Context, courseId uint) ([]db.Student, error) {
+	return VisibleStudents(ctx, db.GetCourseEnrolledStudentsByCourseId(ctx, courseId))
+}
+
+// GetStudentsOfInstructor returns the students of the instructor the
+// principal may see.
+func GetStudentsOfInstructor(ctx context.Context, instructorId uint, roles ...string) ([]db.Student, error) {
+	return VisibleStudents(ctx, db.GetStudentsOfInstructor(ctx, instructorId, roles...))
+}
+
+func CreateStudent(ctx context.Context, student db.Student) (db.Student, error) {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageStudent(student) }); err != nil {
+		return db.Student{}, err
+	}
+	var created db.Student
+	err := scoped(ctx, func(ctx context.Context) (err error) {
+		created, err = db.CreateStudent(ctx, student)
+		return err
+	})
+	return created, err
+}
+
+func UpdateStudentAge(ctx context.Context, student db.Student, age int) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageStudent(student) }); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.UpdateStudentAge(ctx, student, age) })
+}
+
+func DeleteStudent(ctx context.Context, student db.Student) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageStudent(student) }); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.DeleteStudent(ctx, student) })
+}
+
+// ExportStudent returns everything stored about the student, for the
+// student themselves or whoever manages them.
+func ExportStudent(ctx context.Context, student db.Student) (db.StudentExport, error) {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanExportStudent(student) }); err != nil {
+		return db.StudentExport{}, err
+	}
+	var export db.StudentExport
+	err := scoped(ctx, func(ctx context.Context) (err error) {
+		export, err = db.ExportStudent(ctx, student.Id)
+		return err
+	})
+	return export, err
+}
+
+// EraseStudent anonymises the student for good, see db.EraseStudent.
+func EraseStudent(ctx context.Context, student db.Student) error {
+	if err := check(ctx, func(p *access.Policy) bool { return p.CanManageStudent(student) }); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.EraseStudent(ctx, student.Id, time.Now()) })
+}
+
+// EnrollStudentForCourse enrolls the student in the course, if the
+// principal may.
+func EnrollStudentForCourse(ctx context.Context, studentId, courseId uint) error {
+	if err := checkEnroll(ctx, studentId, courseId); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.EnrollStudentForCourse(ctx, studentId, courseId) })
+}
+
+func DropStudentFromCourse(ctx context.Context, studentId, courseId uint) error {
+	if err := checkEnroll(ctx, studentId, courseId); err != nil {
+		return err
+	}
+	return scoped(ctx, func(ctx context.Context) error { return db.DropStudentFromCourse(ctx, studentId, courseId) })
+}
+
+func checkEnroll(ctx context.Context, studentId, courseId uint) error {
+	course := db.FindCourseById(ctx, int(courseId))
+	return check(ctx, func(p *access.Policy) bool { return p.CanEnroll(studentId, course) })
+}
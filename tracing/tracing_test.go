@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"exercise1/config"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupStdoutExportsSpans(t *testing.T) {
+	var out bytes.Buffer
+	shutdown, err := Setup(context.Background(), config.Tracing{Exporter: "stdout"}, &out)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "db.CreateStudent")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("expected shutdown to flush without error, got %v", err)
+	}
+
+	if !strings.Contains(out.String(), `"Name":"db.CreateStudent"`) {
+		t.Fatalf("expected the span to be written to stdout, got %s", out.String())
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), config.Tracing{Exporter: "zipkin"}, nil); err == nil {
+		t.Fatalf("expected error for unknown exporter")
+	}
+}
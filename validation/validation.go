@@ -0,0 +1,164 @@
+// Package validation checks struct fields against declarative rule sets and
+// reports every violated field at once.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Rule checks a single field value and returns a human readable message
+// describing the violation, or an empty string when the value is valid.
+type Rule interface {
+	Check(value interface{}) string
+}
+
+type RuleFunc func(value interface{}) string
+
+func (f RuleFunc) Check(value interface{}) string {
+	return f(value)
+}
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Errors lists every violated field of a validated value.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Error()
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+type Field struct {
+	Name  string
+	Rules []Rule
+}
+
+func F(name string, rules ...Rule) Field {
+	return Field{Name: name, Rules: rules}
+}
+
+// Schema is the list of field rules for one struct type.
+type Schema []Field
+
+// Validate runs every rule against the named fields of v, which must be a
+// struct or a pointer to one. It returns nil or an Errors value.
+func (s Schema) Validate(v interface{}) error {
+	return s.validate(v, false)
+}
+
+// ValidatePartial is Validate for partial updates: fields left at their zero
+// value are not being changed, so their rules are skipped.
+func (s Schema) ValidatePartial(v interface{}) error {
+	return s.validate(v, true)
+}
+
+func (s Schema) validate(v interface{}, partial bool) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	var errs Errors
+	for _, field := range s {
+		fieldValue := value.FieldByName(field.Name)
+		if !fieldValue.IsValid() {
+			panic(fmt.Sprintf("validation: %s has no field %s", value.Type(), field.Name))
+		}
+		if partial && fieldValue.IsZero() {
+			continue
+		}
+		actual := indirect(fieldValue)
+		for _, rule := range field.Rules {
+			if message := rule.Check(actual); message != "" {
+				errs = append(errs, FieldError{Field: field.Name, Message: message})
+				break
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// indirect dereferences pointer fields so rules only deal with plain values;
+// a nil pointer is reported as nil.
+func indirect(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	return value.Interface()
+}
+
+func isZero(value interface{}) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
+}
+
+func Required() Rule {
+	return RuleFunc(func(value interface{}) string {
+		if isZero(value) {
+			return "is required"
+		}
+		return ""
+	})
+}
+
+// Length limits the number of characters of a string field.
+func Length(min, max int) Rule {
+	return RuleFunc(func(value interface{}) string {
+		s, _ := value.(string)
+		if n := utf8.RuneCountInString(s); n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d characters long", min, max)
+		}
+		return ""
+	})
+}
+
+// Range limits the value of an integer field. Nil values are not checked,
+// use Required for that.
+func Range(min, max int64) Rule {
+	return RuleFunc(func(value interface{}) string {
+		if value == nil {
+			return ""
+		}
+		var n int64
+		switch v := reflect.ValueOf(value); v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = v.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = int64(v.Uint())
+		default:
+			return "must be a number"
+		}
+		if n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+		return ""
+	})
+}
+
+// Matches requires a string field to match pattern. Empty strings are
+// accepted so optional fields can still be left blank.
+func Matches(pattern string, description string) Rule {
+	re := regexp.MustCompile(pattern)
+	return RuleFunc(func(value interface{}) string {
+		s, _ := value.(string)
+		if s != "" && !re.MatchString(s) {
+			return "must " + description
+		}
+		return ""
+	})
+}
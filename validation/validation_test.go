@@ -0,0 +1,54 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+type person struct {
+	Name         string
+	Age          uint
+	City         string
+	DepartmentId *uint
+}
+
+var personSchema = Schema{
+	F("Name", Required(), Length(2, 10)),
+	F("Age", Required(), Range(16, 100)),
+	F("City", Matches(`^[A-Za-z ]*$`, "contain only latin letters")),
+	F("DepartmentId", Required()),
+}
+
+func TestValidateReportsEveryViolatedField(t *testing.T) {
+	err := personSchema.Validate(person{Name: "A", Age: 500, City: "Almaty1"})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	expected := []string{"Name", "Age", "City", "DepartmentId"}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d violated fields, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, field := range expected {
+		if errs[i].Field != field {
+			t.Errorf("expected violation %d to be on %s, got %s", i, field, errs[i].Field)
+		}
+	}
+}
+
+func TestValidateAcceptsValidValue(t *testing.T) {
+	departmentId := uint(1)
+	if err := personSchema.Validate(&person{Name: "Askar", Age: 20, City: "Almaty", DepartmentId: &departmentId}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestValidatePartialSkipsZeroFields(t *testing.T) {
+	if err := personSchema.ValidatePartial(person{Age: 30}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := personSchema.ValidatePartial(person{Age: 3}); err == nil {
+		t.Fatalf("expected Age to be rejected")
+	}
+}